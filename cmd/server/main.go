@@ -1,11 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/commitlog/internal/config"
 	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/handler"
 	"github.com/commitlog/internal/router"
+	"github.com/commitlog/internal/service"
+	"github.com/commitlog/internal/staticsite"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,18 +22,206 @@ func main() {
 	cfg := config.Load()
 	gin.SetMode(cfg.GinMode)
 
+	// 子命令执行完毕后直接退出，不启动服务：
+	// export 导出整站 Markdown，build-static 预渲染静态站点，restore 从备份恢复，
+	// reindex-search 重建全文搜索索引
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			runExport(cfg, os.Args[2:])
+			return
+		case "build-static":
+			runBuildStatic(cfg, os.Args[2:])
+			return
+		case "restore":
+			runRestore(cfg, os.Args[2:])
+			return
+		case "reindex-search":
+			runReindexSearch(cfg)
+			return
+		}
+	}
+
 	// 初始化数据库
 	if err := db.Init(cfg.DatabasePath); err != nil {
 		log.Fatalf("failed to initialize database: %v", err)
 	}
 
+	// 运行期间持有数据库文件锁，restore 子命令据此拒绝在服务运行时替换数据库
+	databaseLock, err := db.LockFile(cfg.DatabasePath)
+	if err != nil {
+		log.Fatalf("failed to lock database: %v", err)
+	}
+	defer databaseLock.Release()
+
 	if err := db.EnsureUser(cfg.SuperRootUserName, cfg.SuperRootPassword); err != nil {
 		log.Fatalf("failed to ensure super user: %v", err)
 	}
 
+	postService := service.NewPostService(db.DB)
+
+	// 为历史已发布文章补齐 slug，旧的数字链接会 301 跳转过去
+	if count, err := postService.BackfillSlugs(); err != nil {
+		log.Fatalf("failed to backfill post slugs: %v", err)
+	} else if count > 0 {
+		log.Printf("backfilled slugs for %d posts", count)
+	}
+
+	// 首次启用全文搜索时为已发布文章建立索引，SQLite 未启用 FTS5 时搜索退回逐行匹配
+	if ready, err := postService.EnsureSearchIndex(); err != nil {
+		log.Fatalf("failed to prepare search index: %v", err)
+	} else if !ready {
+		log.Printf("sqlite FTS5 is unavailable, build with -tags sqlite_fts5 to enable full-text search")
+	}
+
+	// 后台定时发布，启动时会先补发停机期间到期的文章
+	go service.NewPublishScheduler(postService, service.DefaultPublishSchedulerInterval).Run(context.Background())
+
+	// 已发布文章变化后在后台重新计算相关推荐，页面只读取保存的结果
+	go service.NewRelatedPostRefresher(handler.NewRelatedPostService(db.DB), service.DefaultRelatedPostRefreshInterval).Run(context.Background())
+
+	// 回收站超过保留天数的记录自动彻底清除
+	if cfg.TrashRetentionDays > 0 {
+		trashService := service.NewTrashService(db.DB, cfg.UploadDir, cfg.UploadURLPath)
+		retention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+		go service.NewTrashPurger(trashService, retention, service.DefaultTrashPurgeInterval).Run(context.Background())
+	}
+
+	// 定时将数据库快照与上传文件备份到本地目录
+	if cfg.BackupDir != "" {
+		backupService := service.NewBackupService(db.DB, cfg.UploadDir)
+		interval := time.Duration(cfg.BackupIntervalHours) * time.Hour
+		go service.NewBackupScheduler(backupService, cfg.BackupDir, cfg.BackupKeep, interval).Run(context.Background())
+	}
+
+	// 定时检查文章、页面与相册中的失效链接和缺失图片
+	if cfg.LinkCheckIntervalHours > 0 {
+		linkChecker := service.NewLinkChecker(db.DB, cfg.UploadDir, cfg.UploadURLPath)
+		interval := time.Duration(cfg.LinkCheckIntervalHours) * time.Hour
+		go service.NewLinkCheckScheduler(linkChecker, interval).Run(context.Background())
+	}
+
 	// 设置并运行 Gin 服务器
 	r := router.SetupRouter(cfg.SessionSecret, cfg.UploadDir, cfg.UploadURLPath, cfg.SiteBaseURL)
 	if err := r.Run(cfg.ListenAddr); err != nil {
 		log.Fatalf("failed to run server: %v", err)
 	}
 }
+
+// runExport 实现 `server export` 子命令，将整站内容导出为 Markdown 压缩包。
+func runExport(cfg config.AppConfig, args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	output := flags.String("o", fmt.Sprintf("commitlog-export-%s.zip", time.Now().Format("20060102")), "导出的 zip 文件路径")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: server export [-o output.zip]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if err := db.Init(cfg.DatabasePath); err != nil {
+		log.Fatalf("failed to initialize database: %v", err)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		log.Fatalf("failed to create %s: %v", *output, err)
+	}
+
+	exporter := service.NewSiteExporter(db.DB, cfg.UploadDir, cfg.UploadURLPath)
+	if err := exporter.Export(file); err != nil {
+		file.Close()
+		os.Remove(*output)
+		log.Fatalf("failed to export site: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatalf("failed to write %s: %v", *output, err)
+	}
+	log.Printf("exported site to %s", *output)
+}
+
+// runBuildStatic 实现 `server build-static` 子命令，复用前台模板把站点渲染为静态 HTML 目录。
+func runBuildStatic(cfg config.AppConfig, args []string) {
+	flags := flag.NewFlagSet("build-static", flag.ExitOnError)
+	output := flags.String("o", "dist", "静态站点输出目录")
+	baseURL := flags.String("base-url", cfg.SiteBaseURL, "静态站点的对外地址，默认读取 SITE_BASE_URL")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: server build-static [-o dist] [-base-url https://example.com]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if err := db.Init(cfg.DatabasePath); err != nil {
+		log.Fatalf("failed to initialize database: %v", err)
+	}
+
+	// 静态站点不运行后台任务，生成前补算尚未处理的相关推荐
+	if _, err := handler.NewRelatedPostService(db.DB).RefreshIfStale(); err != nil {
+		log.Fatalf("failed to refresh related posts: %v", err)
+	}
+
+	engine := router.SetupStaticRouter(cfg.UploadDir, cfg.UploadURLPath, *baseURL)
+	generator := staticsite.NewGenerator(db.DB, engine, staticsite.Options{
+		OutputDir: *output,
+		BaseURL:   *baseURL,
+		StaticDir: "web/static",
+		UploadDir: cfg.UploadDir,
+		UploadURL: cfg.UploadURLPath,
+	})
+	result, err := generator.Generate()
+	if err != nil {
+		if errors.Is(err, staticsite.ErrBaseURLRequired) {
+			log.Fatalf("a site base url is required, set SITE_BASE_URL or pass -base-url")
+		}
+		log.Fatalf("failed to build static site: %v", err)
+	}
+	log.Printf("built static site to %s: %d pages, %d assets", *output, result.Pages, result.Assets)
+}
+
+// runRestore 实现 `server restore` 子命令，校验备份包后替换数据库与上传目录，执行前需停止服务。
+func runRestore(cfg config.AppConfig, args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	from := flags.String("from", "", "备份文件路径（commitlog-backup-*.tar）")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: server restore -from commitlog-backup.tar")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if *from == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(*from)
+	if err != nil {
+		log.Fatalf("failed to open backup: %v", err)
+	}
+	defer file.Close()
+
+	result, err := service.RestoreBackup(file, cfg.DatabasePath, cfg.UploadDir)
+	if err != nil {
+		log.Fatalf("failed to restore backup: %v", err)
+	}
+	log.Printf("restored backup created at %s (schema %d)", result.Manifest.CreatedAt.Format(time.RFC3339), result.Manifest.SchemaVersion)
+	if result.PreviousDatabase != "" {
+		log.Printf("previous database kept at %s", result.PreviousDatabase)
+	}
+	if result.PreviousUploads != "" {
+		log.Printf("previous uploads kept at %s", result.PreviousUploads)
+	}
+}
+
+// runReindexSearch 实现 `server reindex-search` 子命令，清空并按已发布文章重建全文搜索索引。
+func runReindexSearch(cfg config.AppConfig) {
+	if err := db.Init(cfg.DatabasePath); err != nil {
+		log.Fatalf("failed to initialize database: %v", err)
+	}
+
+	count, err := service.NewPostService(db.DB).ReindexSearch()
+	if err != nil {
+		if errors.Is(err, service.ErrSearchIndexUnavailable) {
+			log.Fatalf("sqlite FTS5 is unavailable, rebuild the server with -tags sqlite_fts5")
+		}
+		log.Fatalf("failed to reindex search: %v", err)
+	}
+	log.Printf("indexed %d published posts", count)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -18,6 +19,15 @@ type AppConfig struct {
 	SuperRootUserName string
 	SuperRootPassword string
 	SiteBaseURL       string
+	// TrashRetentionDays 为回收站记录的保留天数，非正数表示不自动清除
+	TrashRetentionDays int
+	// BackupDir 为定时备份的写入目录，为空表示不启用定时备份
+	BackupDir string
+	// BackupIntervalHours 为定时备份间隔，BackupKeep 为保留的备份份数
+	BackupIntervalHours int
+	BackupKeep          int
+	// LinkCheckIntervalHours 为定时检查失效链接的间隔，非正数表示不自动检查
+	LinkCheckIntervalHours int
 }
 
 // Load 从环境变量读取应用配置，并为缺失项提供安全的默认值。
@@ -62,19 +72,54 @@ func Load() AppConfig {
 		siteBaseURL = "https://blog.jaxrene.dev"
 	}
 
+	trashRetentionDays := 30
+	if raw := strings.TrimSpace(os.Getenv("TRASH_RETENTION_DAYS")); raw != "" {
+		if days, err := strconv.Atoi(raw); err == nil {
+			trashRetentionDays = days
+		}
+	}
+
+	backupDir := strings.TrimSpace(os.Getenv("BACKUP_DIR"))
+
+	backupIntervalHours := 24
+	if raw := strings.TrimSpace(os.Getenv("BACKUP_INTERVAL_HOURS")); raw != "" {
+		if hours, err := strconv.Atoi(raw); err == nil && hours > 0 {
+			backupIntervalHours = hours
+		}
+	}
+
+	backupKeep := 7
+	if raw := strings.TrimSpace(os.Getenv("BACKUP_KEEP")); raw != "" {
+		if keep, err := strconv.Atoi(raw); err == nil {
+			backupKeep = keep
+		}
+	}
+
+	linkCheckIntervalHours := 24
+	if raw := strings.TrimSpace(os.Getenv("LINK_CHECK_INTERVAL_HOURS")); raw != "" {
+		if hours, err := strconv.Atoi(raw); err == nil {
+			linkCheckIntervalHours = hours
+		}
+	}
+
 	superRootUserName := strings.TrimSpace(os.Getenv("SUPER_ROOT_USER_NAME"))
 	superRootPassword := strings.TrimSpace(os.Getenv("SUPER_ROOT_PASSWORD"))
 
 	return AppConfig{
-		ListenAddr:        listenAddr,
-		Port:              port,
-		DatabasePath:      databasePath,
-		SessionSecret:     sessionSecret,
-		GinMode:           ginMode,
-		UploadDir:         uploadDir,
-		UploadURLPath:     uploadURLPath,
-		SuperRootUserName: superRootUserName,
-		SuperRootPassword: superRootPassword,
-		SiteBaseURL:       siteBaseURL,
+		ListenAddr:             listenAddr,
+		Port:                   port,
+		DatabasePath:           databasePath,
+		SessionSecret:          sessionSecret,
+		GinMode:                ginMode,
+		UploadDir:              uploadDir,
+		UploadURLPath:          uploadURLPath,
+		SuperRootUserName:      superRootUserName,
+		SuperRootPassword:      superRootPassword,
+		SiteBaseURL:            siteBaseURL,
+		TrashRetentionDays:     trashRetentionDays,
+		BackupDir:              backupDir,
+		BackupIntervalHours:    backupIntervalHours,
+		BackupKeep:             backupKeep,
+		LinkCheckIntervalHours: linkCheckIntervalHours,
 	}
 }
@@ -13,6 +13,10 @@ import (
 // DB 是一个全局的数据库连接实例
 var DB *gorm.DB
 
+// SchemaVersion 标记数据库结构的兼容版本，出现 AutoMigrate 无法处理的不兼容变更时递增。
+// 备份清单会记录该值，恢复时拒绝来自更高版本的备份。
+const SchemaVersion = 1
+
 // Init 初始化数据库连接并执行自动迁移。
 // databasePath 为空时将回退到默认值 commitlog.db。
 func Init(databasePath string) error {
@@ -31,14 +35,23 @@ func Init(databasePath string) error {
 		return err
 	}
 
+	return Migrate(DB)
+}
+
+// Migrate 将数据库结构迁移到当前版本，恢复备份前也用它校验结构是否兼容。
+func Migrate(gdb *gorm.DB) error {
 	// 自动迁移模式，为核心模型创建表
-	if err = DB.AutoMigrate(
+	if err := gdb.AutoMigrate(
 		&User{},
 		&PostTemplate{},
 		&Post{},
 		&PostDraftVersion{},
 		&PostPublication{},
+		&PostSlugRedirect{},
+		&PostPreviewToken{},
 		&Tag{},
+		&Series{},
+		&SeriesPost{},
 		&Page{},
 		&GalleryImage{},
 		&ProfileContact{},
@@ -47,11 +60,16 @@ func Init(databasePath string) error {
 		&SiteHourlySnapshot{},
 		&SiteHourlyVisitor{},
 		&SystemSetting{},
+		&LinkPreview{},
+		&PostLink{},
+		&LinkCheckRun{},
+		&LinkCheckIssue{},
+		&RelatedPost{},
 	); err != nil {
 		return err
 	}
 
-	migrator := DB.Migrator()
+	migrator := gdb.Migrator()
 	if migrator.HasColumn(&Post{}, "title") {
 		if dropErr := migrator.DropColumn(&Post{}, "title"); dropErr != nil {
 			return dropErr
@@ -64,6 +82,10 @@ func Init(databasePath string) error {
 		}
 	}
 
+	if err := hashLegacyPreviewTokens(gdb); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,57 @@
+package db
+
+import "time"
+
+// 链接检查发现问题的来源类型。
+const (
+	LinkCheckSourcePost    = "post"
+	LinkCheckSourcePage    = "page"
+	LinkCheckSourceGallery = "gallery"
+)
+
+// 被检查链接的类型。
+const (
+	// LinkCheckKindUpload 是上传目录中的文件，直接检查磁盘。
+	LinkCheckKindUpload = "upload"
+	// LinkCheckKindInternal 是站内文章链接，检查文章是否仍已发布。
+	LinkCheckKindInternal = "internal"
+	// LinkCheckKindExternal 是外部链接，通过 HEAD 请求检查。
+	LinkCheckKindExternal = "external"
+)
+
+// LinkCheckRun 记录一次失效链接检查的执行情况。
+type LinkCheckRun struct {
+	ID         uint      `gorm:"primaryKey"`
+	StartedAt  time.Time `gorm:"index;not null"`
+	FinishedAt *time.Time
+	// Checked 是检查过的不重复链接数量，Broken 是发现问题的引用数量。
+	Checked   int    `gorm:"not null;default:0"`
+	Broken    int    `gorm:"not null;default:0"`
+	Error     string `gorm:"size:500"`
+	CreatedAt time.Time
+}
+
+// TableName 指定自定义表名。
+func (LinkCheckRun) TableName() string {
+	return "link_check_runs"
+}
+
+// LinkCheckIssue 是一次检查中发现的失效链接或缺失图片。
+type LinkCheckIssue struct {
+	ID          uint   `gorm:"primaryKey"`
+	RunID       uint   `gorm:"index;not null"`
+	SourceType  string `gorm:"size:16;index:idx_link_check_issues_source;not null"`
+	SourceID    uint   `gorm:"index:idx_link_check_issues_source;not null"`
+	SourceTitle string `gorm:"size:300"`
+	URL         string `gorm:"size:2048;not null"`
+	Kind        string `gorm:"size:16;not null"`
+	// StatusCode 是外部链接返回的状态码，网络错误与本地检查时为 0。
+	StatusCode int
+	Error      string `gorm:"size:500"`
+	CreatedAt  time.Time
+}
+
+// TableName 指定自定义表名。
+func (LinkCheckIssue) TableName() string {
+	return "link_check_issues"
+}
@@ -0,0 +1,29 @@
+package db
+
+import "time"
+
+// LinkPreview 缓存正文中独立成行链接的 Open Graph 信息，用于渲染链接卡片。
+// 抓取失败同样会记录，避免每次保存都重复请求不可用的页面。
+type LinkPreview struct {
+	ID          uint   `gorm:"primaryKey"`
+	URL         string `gorm:"size:2048;uniqueIndex;not null"`
+	Title       string `gorm:"size:300"`
+	Description string `gorm:"size:1000"`
+	SiteName    string `gorm:"size:191"`
+	// ImageURL 是缓存到本站上传目录后的图片地址，未缓存时为空。
+	ImageURL   string    `gorm:"size:2048"`
+	FetchError string    `gorm:"size:500"`
+	FetchedAt  time.Time `gorm:"index;not null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TableName 指定自定义表名。
+func (LinkPreview) TableName() string {
+	return "link_previews"
+}
+
+// Usable 判断抓取结果是否足以渲染为卡片。
+func (p LinkPreview) Usable() bool {
+	return p.FetchError == "" && p.Title != ""
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrDatabaseInUse 表示数据库文件正被其他进程（通常是运行中的服务）持有。
+var ErrDatabaseInUse = errors.New("database is in use by another process")
+
+// FileLock 是数据库文件上的进程级排他锁，进程退出时由系统自动释放。
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile 以非阻塞方式获取 path 的排他锁，已被其他进程持有时返回 ErrDatabaseInUse。
+// 服务运行期间持有数据库文件的锁，离线恢复备份前据此确认服务已经停止。
+func LockFile(path string) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileLock{file: file}, nil
+}
+
+// Release 释放锁。
+func (l *FileLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
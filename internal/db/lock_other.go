@@ -0,0 +1,10 @@
+//go:build !unix
+
+package db
+
+import "os"
+
+// lockFile 在不支持 flock 的平台上不做检查，恢复前需人工确认服务已停止。
+func lockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package db
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func lockFile(file *os.File) error {
+	// flock 与 SQLite 使用的 fcntl 记录锁互不影响，不会阻塞服务自身的读写
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return ErrDatabaseInUse
+	}
+	return err
+}
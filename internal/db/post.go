@@ -1,6 +1,7 @@
 package db
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -13,14 +14,18 @@ var markdownEmphasisReplacer = strings.NewReplacer("**", "", "*", "")
 const (
 	PostVisibilityPublic   = "public"
 	PostVisibilityUnlisted = "unlisted"
+	// PostVisibilityProtected 表示文章出现在列表中，但需输入密码才能阅读正文
+	PostVisibilityProtected = "protected"
 )
 
 // Post 定义了文章模型
 type Post struct {
 	gorm.Model
+	// Slug 是文章在前台的永久链接标识，为空时回退使用数字 ID。
+	Slug        string `gorm:"size:191;uniqueIndex:idx_posts_slug,where:slug <> ''"`
 	Content     string
 	Summary     string
-	Status      string `gorm:"default:draft"` // draft, published
+	Status      string `gorm:"default:draft"` // draft, scheduled, published
 	Visibility  string `gorm:"size:16;not null;default:public"`
 	ReadingTime int
 	CoverURL    string
@@ -33,12 +38,18 @@ type Post struct {
 	User             User
 	Tags             []Tag `gorm:"many2many:post_tags;"`
 	PublishedAt      time.Time
+	// ScheduledAt 记录待执行的定时发布时间，为空表示没有定时任务
+	ScheduledAt *time.Time `gorm:"index"`
 	// PublicationCount 记录文章发布次数，用于版本号展示
 	PublicationCount int
 	// LatestPublicationID 指向最近一次发布的快照
 	LatestPublicationID *uint
+	// PasswordHash 保存受密码保护文章的 bcrypt 哈希，不会输出到 JSON
+	PasswordHash string `gorm:"size:255" json:"-"`
 	// Title 是根据 Content 动态推导出的字段，不在数据库中存储
 	Title string `gorm:"-"`
+	// HasPassword 表示文章是否已设置访问密码
+	HasPassword bool `gorm:"-"`
 }
 
 // PostPublication 存储文章发布时的快照数据
@@ -58,6 +69,10 @@ type PostPublication struct {
 	PublishedAt time.Time
 	Version     int
 	Tags        []Tag `gorm:"many2many:post_publication_tags;"`
+	// PostSlug 通过关联 posts 表查询得到，用于生成前台链接
+	PostSlug string `gorm:"->;-:migration;column:post_slug"`
+	// SearchSnippet 是全文检索命中的正文片段，命中部分以 \x02 与 \x03 包围，仅在搜索结果中填充
+	SearchSnippet string `gorm:"->;-:migration;column:search_snippet"`
 	// Title 与 Post.Title 一样由 Content 动态生成
 	Title string `gorm:"-"`
 }
@@ -88,6 +103,12 @@ type PostDraftVersion struct {
 func (p *Post) PopulateDerivedFields() {
 	p.Title = DeriveTitleFromContent(p.Content)
 	p.Visibility = NormalizePostVisibility(p.Visibility)
+	p.HasPassword = strings.TrimSpace(p.PasswordHash) != ""
+}
+
+// IsProtected 判断文章是否需要密码才能阅读。
+func (p Post) IsProtected() bool {
+	return NormalizePostVisibility(p.Visibility) == PostVisibilityProtected
 }
 
 // AfterFind 在查询后填充衍生字段。
@@ -108,6 +129,11 @@ func (pp *PostPublication) AfterFind(tx *gorm.DB) error {
 	return nil
 }
 
+// IsProtected 判断发布快照是否需要密码才能阅读。
+func (pp PostPublication) IsProtected() bool {
+	return NormalizePostVisibility(pp.Visibility) == PostVisibilityProtected
+}
+
 // PopulateDerivedFields 根据内容动态生成标题等衍生信息。
 func (pv *PostDraftVersion) PopulateDerivedFields() {
 	pv.Title = DeriveTitleFromContent(pv.Content)
@@ -120,6 +146,24 @@ func (pv *PostDraftVersion) AfterFind(tx *gorm.DB) error {
 	return nil
 }
 
+// PostPath 返回文章的前台访问路径，未设置 slug 时回退为数字 ID。
+func PostPath(slug string, id uint) string {
+	if trimmed := strings.TrimSpace(slug); trimmed != "" {
+		return "/posts/" + trimmed
+	}
+	return fmt.Sprintf("/posts/%d", id)
+}
+
+// PublicPath 返回文章的前台访问路径。
+func (p Post) PublicPath() string {
+	return PostPath(p.Slug, p.ID)
+}
+
+// PublicPath 返回发布快照对应文章的前台访问路径。
+func (pp PostPublication) PublicPath() string {
+	return PostPath(pp.PostSlug, pp.PostID)
+}
+
 // DeriveTitleFromContent 读取内容首行并去除 Markdown 语法以生成标题。
 func DeriveTitleFromContent(content string) string {
 	if content == "" {
@@ -159,6 +203,8 @@ func NormalizePostVisibility(raw string) string {
 	switch normalized {
 	case PostVisibilityUnlisted:
 		return PostVisibilityUnlisted
+	case PostVisibilityProtected:
+		return PostVisibilityProtected
 	default:
 		return PostVisibilityPublic
 	}
@@ -0,0 +1,17 @@
+package db
+
+import "time"
+
+// PostLink 记录已发布文章之间的 Wiki 链接，用于展示反向链接。
+// 索引在文章发布、下线、删除与恢复时按最新发布快照增量更新。
+type PostLink struct {
+	ID           uint `gorm:"primaryKey"`
+	SourcePostID uint `gorm:"uniqueIndex:idx_post_links_source_target;not null"`
+	TargetPostID uint `gorm:"uniqueIndex:idx_post_links_source_target;index;not null"`
+	CreatedAt    time.Time
+}
+
+// TableName 指定自定义表名。
+func (PostLink) TableName() string {
+	return "post_links"
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostPreviewToken 是可分享的草稿预览凭证，持有链接的访客无需登录即可查看文章当前草稿。
+// 数据库只保存凭证的 SHA-256，原始凭证仅在创建时返回一次。
+type PostPreviewToken struct {
+	ID     uint `gorm:"primaryKey"`
+	PostID uint `gorm:"index;not null"`
+	// TokenHash 沿用原 token 列，旧版本保存的明文凭证在迁移时改写为摘要
+	TokenHash string `gorm:"column:token;size:64;uniqueIndex;not null" json:"-"`
+	// Token 是原始凭证，只在创建后的返回值中存在
+	Token      string    `gorm:"-" json:",omitempty"`
+	Note       string    `gorm:"size:191"`
+	ExpiresAt  time.Time `gorm:"index;not null"`
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+	UseCount   int `gorm:"not null;default:0"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TableName 指定自定义表名。
+func (PostPreviewToken) TableName() string {
+	return "post_preview_tokens"
+}
+
+// PublicPath 返回预览链接的访问路径，读取自数据库的记录没有原始凭证，返回空字符串。
+func (t PostPreviewToken) PublicPath() string {
+	if t.Token == "" {
+		return ""
+	}
+	return "/preview/" + t.Token
+}
+
+// HashPreviewToken 返回预览凭证保存与查找时使用的摘要。
+func HashPreviewToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashLegacyPreviewTokens 将旧版本明文保存的凭证改写为摘要，已分享的链接继续有效。
+// 明文凭证为 48 位十六进制，摘要为 64 位，按长度区分。
+func hashLegacyPreviewTokens(gdb *gorm.DB) error {
+	var legacy []PostPreviewToken
+	if err := gdb.Where("LENGTH(token) <> ?", sha256.Size*2).Find(&legacy).Error; err != nil {
+		return err
+	}
+	for _, record := range legacy {
+		if err := gdb.Model(&PostPreviewToken{}).
+			Where("id = ?", record.ID).
+			Update("token", HashPreviewToken(record.TokenHash)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Active 判断预览凭证在给定时间是否仍可使用。
+func (t PostPreviewToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
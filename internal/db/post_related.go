@@ -0,0 +1,19 @@
+package db
+
+import "time"
+
+// RelatedPost 记录预先计算的相关文章推荐，Position 越小越靠前。
+// 文章发布、撤回、删除或恢复后由后台任务按所有已发布文章整体重建。
+type RelatedPost struct {
+	ID            uint    `gorm:"primaryKey"`
+	PostID        uint    `gorm:"uniqueIndex:idx_related_posts_post_related;not null"`
+	RelatedPostID uint    `gorm:"uniqueIndex:idx_related_posts_post_related;index;not null"`
+	Score         float64 `gorm:"not null;default:0"`
+	Position      int     `gorm:"not null;default:0"`
+	CreatedAt     time.Time
+}
+
+// TableName 指定自定义表名。
+func (RelatedPost) TableName() string {
+	return "related_posts"
+}
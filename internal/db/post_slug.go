@@ -0,0 +1,17 @@
+package db
+
+import "time"
+
+// PostSlugRedirect 记录文章曾经使用过的 slug，用于旧链接的永久重定向。
+type PostSlugRedirect struct {
+	ID        uint   `gorm:"primaryKey"`
+	PostID    uint   `gorm:"index;not null"`
+	Slug      string `gorm:"size:191;uniqueIndex;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 指定自定义表名。
+func (PostSlugRedirect) TableName() string {
+	return "post_slug_redirects"
+}
@@ -50,6 +50,7 @@ func TestNormalizePostVisibility(t *testing.T) {
 		{name: "public keeps public", input: "public", want: PostVisibilityPublic},
 		{name: "unlisted keeps unlisted", input: "unlisted", want: PostVisibilityUnlisted},
 		{name: "case insensitive", input: "UNLISTED", want: PostVisibilityUnlisted},
+		{name: "protected keeps protected", input: " Protected ", want: PostVisibilityProtected},
 		{name: "unknown fallback public", input: "private", want: PostVisibilityPublic},
 	}
 
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Series 定义了系列（多篇连载）模型，与标签不同，系列内的文章有明确顺序。
+type Series struct {
+	gorm.Model
+	Slug        string `gorm:"size:191;uniqueIndex;not null"`
+	Title       string `gorm:"not null"`
+	Description string
+	CoverURL    string
+	CoverWidth  int
+	CoverHeight int
+	Members     []SeriesPost
+	// PostCount 为查询时统计的成员数量，不在数据库中存储
+	PostCount int64 `gorm:"->;-:migration;column:post_count" json:"post_count"`
+}
+
+// SeriesPost 记录系列与文章的有序关联，一篇文章最多属于一个系列。
+type SeriesPost struct {
+	ID        uint `gorm:"primaryKey"`
+	SeriesID  uint `gorm:"index;not null"`
+	PostID    uint `gorm:"uniqueIndex;not null"`
+	Position  int  `gorm:"not null;default:0"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Title 由关联文章内容推导，仅用于后台展示
+	Title string `gorm:"-"`
+}
+
+// TableName 指定系列成员表名。
+func (SeriesPost) TableName() string {
+	return "series_posts"
+}
+
+// PublicPath 返回系列在前台的访问路径。
+func (s Series) PublicPath() string {
+	return "/series/" + s.Slug
+}
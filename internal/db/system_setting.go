@@ -49,6 +49,8 @@ const (
 	SettingKeyGallerySubtitle = "gallery_subtitle"
 	// SettingKeyGalleryEnabled 表示是否启用 Gallery。
 	SettingKeyGalleryEnabled = "gallery_enabled"
+	// SettingKeyLiteEmbeds 表示是否以点击加载的缩略图代替嵌入的 iframe。
+	SettingKeyLiteEmbeds = "lite_embeds"
 	// SettingKeyNavButtons 表示前台顶部导航按钮配置。
 	SettingKeyNavButtons = "nav_buttons"
 )
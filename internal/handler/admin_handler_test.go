@@ -76,7 +76,7 @@ func setupAdminHandlerTestDB(t *testing.T) (*gorm.DB, func()) {
 		t.Fatalf("failed to open test db: %v", err)
 	}
 
-	if err := gdb.AutoMigrate(&db.User{}, &db.Post{}, &db.PostPublication{}, &db.Tag{}, &db.SystemSetting{}); err != nil {
+	if err := gdb.AutoMigrate(&db.User{}, &db.Post{}, &db.PostPublication{}, &db.PostSlugRedirect{}, &db.Tag{}, &db.SystemSetting{}); err != nil {
 		t.Fatalf("failed to migrate test db: %v", err)
 	}
 
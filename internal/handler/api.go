@@ -19,17 +19,30 @@ type API struct {
 	posts           *service.PostService
 	templates       *service.TemplateService
 	tags            *service.TagService
+	series          *service.SeriesService
+	diffs           *service.DiffService
+	previews        *service.PreviewService
+	importer        *service.PostImporter
+	exporter        *service.SiteExporter
+	backups         *service.BackupService
 	pages           *service.PageService
 	galleries       *service.GalleryService
+	trash           *service.TrashService
 	profiles        *service.ProfileService
 	analytics       analyticsProvider
 	system          *service.SystemSettingService
 	summaries       service.SummaryGenerator
 	optimizer       service.ContentOptimizer
 	snippetRewriter service.SnippetRewriter
+	thumbnails      *service.EmbedThumbnailCache
+	linkPreviews    *service.LinkPreviewService
+	linkChecker     *service.LinkChecker
+	related         *service.RelatedPostService
 	uploadDir       string
 	uploadURL       string
 	baseURL         string
+	// staticExport 表示处理器用于生成静态站点，页面中不输出需要服务端处理的表单
+	staticExport bool
 }
 
 type siteViewModel struct {
@@ -45,6 +58,7 @@ type siteViewModel struct {
 	SocialImage     string
 	GallerySubtitle string
 	GalleryEnabled  bool
+	LiteEmbeds      bool
 	NavButtons      []navButtonView
 }
 
@@ -61,26 +75,53 @@ func NewAPI(db *gorm.DB, uploadDir, uploadURL, baseURL string) *API {
 	systemService := service.NewSystemSettingService(db)
 	summaryService := service.NewAISummaryService(systemService)
 	rewriteService := service.NewAIRewriteService(systemService)
+	thumbnailCache := service.NewEmbedThumbnailCache(uploadDir, uploadURL)
 
 	return &API{
 		db:              db,
 		posts:           service.NewPostService(db),
 		templates:       service.NewTemplateService(db),
 		tags:            service.NewTagService(db),
+		series:          service.NewSeriesService(db),
+		diffs:           service.NewDiffService(db),
+		previews:        service.NewPreviewService(db),
+		importer:        service.NewPostImporter(db, uploadDir, uploadURL),
+		exporter:        service.NewSiteExporter(db, uploadDir, uploadURL),
+		backups:         service.NewBackupService(db, uploadDir),
 		pages:           service.NewPageService(db),
 		galleries:       service.NewGalleryService(db),
+		trash:           service.NewTrashService(db, uploadDir, uploadURL),
 		profiles:        service.NewProfileService(db),
 		analytics:       service.NewAnalyticsService(db),
 		system:          systemService,
 		summaries:       summaryService,
 		optimizer:       rewriteService,
 		snippetRewriter: rewriteService,
+		thumbnails:      thumbnailCache,
+		linkPreviews:    service.NewLinkPreviewService(db, thumbnailCache),
+		linkChecker:     service.NewLinkChecker(db, uploadDir, uploadURL),
+		related:         NewRelatedPostService(db),
 		uploadDir:       uploadDir,
 		uploadURL:       uploadURL,
 		baseURL:         normalizeBaseURL(baseURL),
 	}
 }
 
+// SetSessionSecret 设置服务端密钥，用于签发受保护文章的解锁令牌。
+func (a *API) SetSessionSecret(secret string) {
+	a.posts.SetUnlockSecret(secret)
+}
+
+// DisableAnalytics 关闭访问统计，用于静态站点生成等非真实访问的渲染场景。
+func (a *API) DisableAnalytics() {
+	a.analytics = nil
+}
+
+// EnableStaticExport 标记为静态站点渲染：静态托管无法提交解锁表单，受保护文章只输出不可阅读的提示页。
+func (a *API) EnableStaticExport() {
+	a.staticExport = true
+}
+
 // DB exposes the underlying gorm instance for legacy paths.
 func (a *API) DB() *gorm.DB {
 	return a.db
@@ -110,6 +151,7 @@ func (a *API) siteSettings(c *gin.Context) siteViewModel {
 		SocialImage:     strings.TrimSpace(settings.SiteSocialImage),
 		GallerySubtitle: strings.TrimSpace(settings.GallerySubtitle),
 		GalleryEnabled:  settings.GalleryEnabled,
+		LiteEmbeds:      settings.LiteEmbeds,
 		NavButtons:      buildNavButtons(settings, isLoggedIn(c)),
 	}
 	if view.Name == "" {
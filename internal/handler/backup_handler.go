@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadBackup 在线生成数据库与上传文件的备份包并以附件形式下载
+func (a *API) DownloadBackup(c *gin.Context) {
+	c.Header("Content-Type", "application/x-tar")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, service.BackupFileName(time.Now())))
+	c.Header("Cache-Control", "no-store")
+
+	if _, err := a.backups.Write(c.Writer); err != nil {
+		// 快照阶段失败时尚未写出内容，仍可返回错误信息
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			respondError(c, http.StatusInternalServerError, "生成备份失败")
+			return
+		}
+		log.Printf("write backup: %v", err)
+		c.Abort()
+	}
+}
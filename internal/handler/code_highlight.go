@@ -0,0 +1,335 @@
+package handler
+
+import (
+	htmlstd "html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/commitlog/internal/service"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// codeHighlightClassPattern 限定代码高亮输出的类名，供内容过滤规则放行。
+var codeHighlightClassPattern = regexp.MustCompile(`^(?:code-block|code-block-title|chroma|line|hl|ln|cl|k|kt|kc|s|c|m|nb|nf|nt|nv|gi|gd|gu)(?: (?:line|hl))?$`)
+
+// codeLanguageClassPattern 限定 <code> 上的语言类名。
+var codeLanguageClassPattern = regexp.MustCompile(`^language-[a-z0-9_+#-]+$`)
+
+// codeFenceOptions 是从代码块信息串解析出的渲染选项。
+//
+// 支持的写法：
+//
+//	```go {linenos=true hl_lines=[2,"4-6"] title="main.go"}
+//	```go title="main.go" {hl_lines="2 4-6" linenostart=10}
+//	```go:main.go
+type codeFenceOptions struct {
+	Language        string
+	Title           string
+	LineNumbers     bool
+	LineNumberStart int
+	Highlight       map[int]bool
+}
+
+// parseCodeFenceInfo 解析代码块信息串，无法识别的属性会被忽略。
+func parseCodeFenceInfo(info string) codeFenceOptions {
+	options := codeFenceOptions{LineNumberStart: 1}
+	info = strings.TrimSpace(info)
+	if info == "" {
+		return options
+	}
+
+	language := info
+	if end := strings.IndexAny(info, " \t{"); end >= 0 {
+		language = info[:end]
+	}
+	rest := info[len(language):]
+	if name, file, ok := strings.Cut(language, ":"); ok {
+		language = name
+		options.Title = file
+	}
+	options.Language = strings.ToLower(language)
+
+	for key, value := range parseCodeFenceAttributes(rest) {
+		switch key {
+		case "title", "filename", "file":
+			options.Title = value
+		case "linenos", "line-numbers", "linenumbers":
+			options.LineNumbers = value != "false" && value != "0"
+		case "linenostart":
+			if start, err := strconv.Atoi(value); err == nil && start > 0 {
+				options.LineNumberStart = start
+			}
+		case "hl_lines", "highlight":
+			options.Highlight = parseHighlightLines(value)
+		}
+	}
+	return options
+}
+
+// parseCodeFenceAttributes 解析 key=value 形式的属性，值可以加引号或方括号，花括号仅作分组。
+func parseCodeFenceAttributes(input string) map[string]string {
+	attrs := make(map[string]string)
+	runes := []rune(input)
+	i := 0
+	for i < len(runes) {
+		for i < len(runes) && strings.ContainsRune(" \t,{}", runes[i]) {
+			i++
+		}
+		start := i
+		for i < len(runes) && !strings.ContainsRune(" \t,{}=", runes[i]) {
+			i++
+		}
+		key := strings.ToLower(string(runes[start:i]))
+		if key == "" {
+			i++
+			continue
+		}
+		if i >= len(runes) || runes[i] != '=' {
+			// 只写属性名时视为开关，例如 {linenos}
+			attrs[key] = "true"
+			continue
+		}
+		i++
+
+		var value strings.Builder
+		if i < len(runes) && (runes[i] == '"' || runes[i] == '\'' || runes[i] == '[') {
+			closing := runes[i]
+			if closing == '[' {
+				closing = ']'
+			}
+			i++
+			for i < len(runes) && runes[i] != closing {
+				value.WriteRune(runes[i])
+				i++
+			}
+			i++
+		} else {
+			for i < len(runes) && !strings.ContainsRune(" \t,}", runes[i]) {
+				value.WriteRune(runes[i])
+				i++
+			}
+		}
+		attrs[key] = strings.TrimSpace(value.String())
+	}
+	return attrs
+}
+
+// parseHighlightLines 解析 "2 4-6"、[2,"4-6"] 等形式的行号列表。
+func parseHighlightLines(value string) map[int]bool {
+	lines := make(map[int]bool)
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '"' || r == '\'' || r == '[' || r == ']'
+	}) {
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil || start <= 0 {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil || end < start {
+				continue
+			}
+		}
+		// 防止异常的大范围占用内存
+		if end-start > 10000 {
+			continue
+		}
+		for line := start; line <= end; line++ {
+			lines[line] = true
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return lines
+}
+
+// codeHighlightStyles 为订阅输出提供内联配色，RSS 阅读器不会加载站点样式表。
+var codeHighlightStyles = map[string]string{
+	"code-block-title": "color:#57606a",
+	"chroma":           "background-color:#f6f8fa;color:#1f2328",
+	"hl":               "background-color:#fff8c5",
+	"ln":               "color:#8c959f",
+	tokenKeyword:       "color:#cf222e",
+	tokenType:          "color:#953800",
+	tokenConstant:      "color:#0550ae",
+	tokenString:        "color:#0a3069",
+	tokenComment:       "color:#6e7781;font-style:italic",
+	tokenNumber:        "color:#0550ae",
+	tokenBuiltin:       "color:#8250df",
+	tokenFunction:      "color:#8250df",
+	tokenTag:           "color:#116329",
+	tokenVariable:      "color:#953800",
+	tokenInserted:      "color:#116329;background-color:#dafbe1",
+	tokenDeleted:       "color:#82071e;background-color:#ffebe9",
+	tokenSubhead:       "color:#8250df",
+}
+
+// codeStyleColorPattern 限定订阅中代码配色允许的颜色值。
+var codeStyleColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// buildFeedSanitizer 在正文过滤规则基础上放行代码高亮的内联样式。
+func buildFeedSanitizer() *bluemonday.Policy {
+	policy := buildContentSanitizer()
+	policy.AllowStyles("color", "background-color").Matching(codeStyleColorPattern).OnElements("span", "pre", "figcaption")
+	policy.AllowStyles("font-style").MatchingEnum("italic").OnElements("span")
+	policy.AllowStyles("padding-right").MatchingEnum("1em").OnElements("span")
+	return policy
+}
+
+// diagramClassPattern 与 diagramTypePattern 限定图表容器输出的类名与类型。
+var (
+	diagramClassPattern = regexp.MustCompile(`^diagram(?:-source|-title)?$`)
+	diagramTypePattern  = regexp.MustCompile(`^(?:mermaid|plantuml)$`)
+)
+
+// codeHighlighter 是 goldmark 扩展，在服务端为围栏代码块生成高亮标记。
+// inlineStyles 为 true 时额外写入内联样式，用于 RSS 等无法加载样式表的场景；
+// diagramFallback 为 true 时图表代码块输出替代文字而不是图表源码。
+type codeHighlighter struct {
+	inlineStyles    bool
+	diagramFallback bool
+}
+
+// Extend 实现 goldmark.Extender。
+func (h *codeHighlighter) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(h, 200)))
+}
+
+// RegisterFuncs 实现 renderer.NodeRenderer，接管围栏代码块的输出。
+func (h *codeHighlighter) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, h.renderFencedCodeBlock)
+}
+
+func (h *codeHighlighter) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+	info := ""
+	if n.Info != nil {
+		info = string(n.Info.Segment.Value(source))
+	}
+	options := parseCodeFenceInfo(info)
+
+	var code strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		code.Write(segment.Value(source))
+	}
+
+	if name, ok := service.DiagramLanguageName(options.Language); ok {
+		h.writeDiagram(w, code.String(), name, options)
+		return ast.WalkSkipChildren, nil
+	}
+	h.writeCodeBlock(w, code.String(), options)
+	return ast.WalkSkipChildren, nil
+}
+
+// writeDiagram 输出图表容器并保留源码供前端渲染，替代模式下只输出说明文字。
+func (h *codeHighlighter) writeDiagram(w util.BufWriter, source, name string, options codeFenceOptions) {
+	if h.diagramFallback {
+		_, _ = w.WriteString("<p>" + htmlstd.EscapeString(service.DiagramFallbackText(name)) + "</p>\n")
+		return
+	}
+	diagramType := strings.ToLower(name)
+	_, _ = w.WriteString(`<figure class="diagram" data-diagram="` + diagramType + `">`)
+	if options.Title != "" {
+		_, _ = w.WriteString(`<figcaption class="diagram-title">` + htmlstd.EscapeString(options.Title) + "</figcaption>")
+	}
+	_, _ = w.WriteString(`<pre class="diagram-source">`)
+	_, _ = w.WriteString(htmlstd.EscapeString(source))
+	_, _ = w.WriteString("</pre></figure>\n")
+}
+
+func (h *codeHighlighter) writeCodeBlock(w util.BufWriter, code string, options codeFenceOptions) {
+	_, _ = w.WriteString("<figure" + h.attrs("code-block") + ">")
+	if options.Title != "" {
+		_, _ = w.WriteString("<figcaption" + h.attrs("code-block-title") + ">")
+		_, _ = w.WriteString(htmlstd.EscapeString(options.Title))
+		_, _ = w.WriteString("</figcaption>")
+	}
+	_, _ = w.WriteString("<pre" + h.attrs("chroma") + "><code")
+	if options.Language != "" && codeLanguageClassPattern.MatchString("language-"+options.Language) {
+		_, _ = w.WriteString(` class="language-` + options.Language + `"`)
+	}
+	_, _ = w.WriteString(">")
+
+	tokens := tokenizeCode(code, lookupCodeLexer(options.Language))
+	for index, line := range splitTokenLines(tokens) {
+		number := index + 1
+		lineClass := "line"
+		if options.Highlight[number] {
+			lineClass = "line hl"
+		}
+		_, _ = w.WriteString("<span" + h.attrs(lineClass) + ">")
+		if options.LineNumbers {
+			_, _ = w.WriteString("<span" + h.attrs("ln") + ">")
+			_, _ = w.WriteString(strconv.Itoa(options.LineNumberStart + index))
+			_, _ = w.WriteString("</span>")
+		}
+		_, _ = w.WriteString("<span" + h.attrs("cl") + ">")
+		for _, token := range line {
+			text := htmlstd.EscapeString(token.Text)
+			if token.Class == tokenPlain {
+				_, _ = w.WriteString(text)
+				continue
+			}
+			_, _ = w.WriteString("<span" + h.attrs(token.Class) + ">" + text + "</span>")
+		}
+		_, _ = w.WriteString("</span></span>")
+	}
+	_, _ = w.WriteString("</code></pre></figure>\n")
+}
+
+// attrs 输出类名，内联模式下同时写入对应样式。
+func (h *codeHighlighter) attrs(class string) string {
+	out := ` class="` + class + `"`
+	if !h.inlineStyles {
+		return out
+	}
+	var styles []string
+	for _, name := range strings.Fields(class) {
+		if style, ok := codeHighlightStyles[name]; ok {
+			styles = append(styles, style)
+		}
+	}
+	if class == "ln" {
+		styles = append(styles, "padding-right:1em")
+	}
+	if len(styles) > 0 {
+		out += ` style="` + strings.Join(styles, ";") + `"`
+	}
+	return out
+}
+
+// splitTokenLines 按换行拆分片段，跨行的注释或字符串会被拆到各自的行中，每行保留结尾换行。
+func splitTokenLines(tokens []codeToken) [][]codeToken {
+	var lines [][]codeToken
+	var current []codeToken
+	for _, token := range tokens {
+		text := token.Text
+		for {
+			newline := strings.IndexByte(text, '\n')
+			if newline < 0 {
+				current = appendToken(current, token.Class, text)
+				break
+			}
+			current = appendToken(current, token.Class, text[:newline+1])
+			lines = append(lines, current)
+			current = nil
+			text = text[newline+1:]
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCodeFenceInfo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		info string
+		want codeFenceOptions
+	}{
+		{
+			info: `go {linenos=true hl_lines=[2,"4-5"] title="main.go"}`,
+			want: codeFenceOptions{Language: "go", Title: "main.go", LineNumbers: true, LineNumberStart: 1, Highlight: map[int]bool{2: true, 4: true, 5: true}},
+		},
+		{
+			info: `Python title="app.py" {hl_lines="3" linenostart=10 linenos}`,
+			want: codeFenceOptions{Language: "python", Title: "app.py", LineNumbers: true, LineNumberStart: 10, Highlight: map[int]bool{3: true}},
+		},
+		{
+			info: "js:src/index.js",
+			want: codeFenceOptions{Language: "js", Title: "src/index.js", LineNumberStart: 1},
+		},
+		{
+			info: "",
+			want: codeFenceOptions{LineNumberStart: 1},
+		},
+	}
+	for _, tt := range tests {
+		if got := parseCodeFenceInfo(tt.info); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCodeFenceInfo(%q) = %+v, want %+v", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMarkdown_HighlightsFencedCode(t *testing.T) {
+	t.Parallel()
+
+	source := "```go {linenos=true hl_lines=[2] title=\"main.go\"}\n// 入口\nfunc main() {\n\tfmt.Println(\"<hi>\")\n}\n```\n"
+	htmlContent, _, err := renderMarkdownWithTOC(source, embedRenderOptions{})
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+	rendered := string(htmlContent)
+	for _, want := range []string{
+		`<figcaption class="code-block-title">main.go</figcaption>`,
+		`<pre class="chroma"><code class="language-go">`,
+		`<span class="c">// 入口</span>`,
+		`<span class="line hl"><span class="ln">2</span><span class="cl"><span class="k">func</span> <span class="nf">main</span>`,
+		`<span class="s">&#34;&lt;hi&gt;&#34;</span>`,
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("expected %s in rendered html, got:\n%s", want, rendered)
+		}
+	}
+	if strings.Contains(rendered, "style=") {
+		t.Fatal("expected page output to rely on classes only")
+	}
+
+	feed, err := renderFeedMarkdown(source)
+	if err != nil {
+		t.Fatalf("render feed markdown: %v", err)
+	}
+	if !strings.Contains(string(feed), `<span class="k" style="color: #cf222e">func</span>`) {
+		t.Fatalf("expected feed output to keep inline colors, got:\n%s", feed)
+	}
+}
+
+func TestRenderMarkdown_SanitizesInjectedHighlightClasses(t *testing.T) {
+	t.Parallel()
+
+	htmlContent, _, err := renderMarkdownWithTOC(`<span class="evil k" style="color:red">x</span>`, embedRenderOptions{})
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+	if strings.Contains(string(htmlContent), "evil") || strings.Contains(string(htmlContent), "style=") {
+		t.Fatalf("expected unknown classes and styles to be stripped, got %s", htmlContent)
+	}
+}
+
+func TestRenderMarkdown_DiagramFences(t *testing.T) {
+	t.Parallel()
+
+	source := "```mermaid {title=\"流程\"}\ngraph TD\n  A-->B\n```\n\n正文"
+	htmlContent, _, err := renderMarkdownWithTOC(source, embedRenderOptions{})
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+	want := `<figure class="diagram" data-diagram="mermaid"><figcaption class="diagram-title">流程</figcaption><pre class="diagram-source">graph TD
+  A--&gt;B
+</pre></figure>`
+	if !strings.Contains(string(htmlContent), want) {
+		t.Fatalf("expected diagram container, got:\n%s", htmlContent)
+	}
+
+	feed, err := renderFeedMarkdown(source)
+	if err != nil {
+		t.Fatalf("render feed markdown: %v", err)
+	}
+	if strings.Contains(string(feed), "graph TD") || !strings.Contains(string(feed), "[Mermaid 图表]") {
+		t.Fatalf("expected feed to replace diagram source, got:\n%s", feed)
+	}
+
+	if plain := markdownToPlainText(source); plain != "[Mermaid 图表] 正文" {
+		t.Fatalf("unexpected plain text %q", plain)
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// 代码高亮只输出 Chroma 短类名中的一个子集，站点样式表、订阅内联配色与内容过滤规则都只需覆盖这些类名。
+const (
+	tokenPlain    = ""
+	tokenKeyword  = "k"
+	tokenType     = "kt"
+	tokenConstant = "kc"
+	tokenString   = "s"
+	tokenComment  = "c"
+	tokenNumber   = "m"
+	tokenBuiltin  = "nb"
+	tokenFunction = "nf"
+	tokenTag      = "nt"
+	tokenVariable = "nv"
+	tokenInserted = "gi"
+	tokenDeleted  = "gd"
+	tokenSubhead  = "gu"
+)
+
+// codeToken 是词法分析得到的一段同类文本。
+type codeToken struct {
+	Class string
+	Text  string
+}
+
+// lookupCodeLexer 按代码块语言标记、别名或扩展名查找 Chroma 词法分析器，未知语言返回 nil，按纯文本输出。
+func lookupCodeLexer(name string) chroma.Lexer {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil
+	}
+	lexer := lexers.Get(name)
+	if lexer == nil {
+		return nil
+	}
+	return chroma.Coalesce(lexer)
+}
+
+// tokenizeCode 使用 Chroma 将源码切分为带类名的片段，lexer 为 nil 或分析失败时整体作为纯文本。
+func tokenizeCode(source string, lexer chroma.Lexer) []codeToken {
+	plain := []codeToken{{Class: tokenPlain, Text: source}}
+	if lexer == nil || source == "" {
+		return plain
+	}
+	iterator, err := lexer.Tokenise(nil, source)
+	if err != nil {
+		return plain
+	}
+
+	var tokens []codeToken
+	for _, token := range iterator.Tokens() {
+		// 行注释等片段会带上结尾换行，换行单独作为纯文本，避免高亮标签跨行
+		text := strings.TrimRight(token.Value, "\n")
+		tokens = appendToken(tokens, codeTokenClass(token.Type), text)
+		tokens = appendToken(tokens, tokenPlain, token.Value[len(text):])
+	}
+	// Chroma 会为缺少结尾换行的源码补上换行，去掉以免多出一个空行
+	if !strings.HasSuffix(source, "\n") && len(tokens) > 0 {
+		last := &tokens[len(tokens)-1]
+		last.Text = strings.TrimSuffix(last.Text, "\n")
+		if last.Text == "" {
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+	return tokens
+}
+
+// codeTokenClass 将 Chroma 的细分词法类型归并到站点支持的类名。
+func codeTokenClass(kind chroma.TokenType) string {
+	switch kind {
+	case chroma.KeywordType, chroma.NameClass:
+		return tokenType
+	case chroma.KeywordConstant, chroma.NameConstant:
+		return tokenConstant
+	case chroma.NameBuiltin, chroma.NameBuiltinPseudo:
+		return tokenBuiltin
+	case chroma.NameFunction, chroma.NameFunctionMagic, chroma.NameDecorator:
+		return tokenFunction
+	case chroma.NameTag, chroma.NameAttribute:
+		return tokenTag
+	case chroma.NameVariable, chroma.NameVariableAnonymous, chroma.NameVariableClass,
+		chroma.NameVariableGlobal, chroma.NameVariableInstance, chroma.NameVariableMagic:
+		return tokenVariable
+	case chroma.GenericInserted:
+		return tokenInserted
+	case chroma.GenericDeleted:
+		return tokenDeleted
+	case chroma.GenericHeading, chroma.GenericSubheading:
+		return tokenSubhead
+	}
+	switch {
+	case kind.InCategory(chroma.Keyword), kind.InSubCategory(chroma.CommentPreproc):
+		return tokenKeyword
+	case kind.InCategory(chroma.Comment):
+		return tokenComment
+	case kind.InSubCategory(chroma.LiteralString):
+		return tokenString
+	case kind.InSubCategory(chroma.LiteralNumber):
+		return tokenNumber
+	}
+	return tokenPlain
+}
+
+// appendToken 追加片段，与前一个同类片段合并以减少输出的标签数量。
+func appendToken(tokens []codeToken, class, text string) []codeToken {
+	if text == "" {
+		return tokens
+	}
+	if n := len(tokens); n > 0 && tokens[n-1].Class == class {
+		tokens[n-1].Text += text
+		return tokens
+	}
+	return append(tokens, codeToken{Class: class, Text: text})
+}
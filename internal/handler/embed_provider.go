@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/commitlog/internal/service"
+)
+
+// 嵌入容器的宽高比，前端按 data-video-aspect 调整容器尺寸。
+const (
+	embedAspectLandscape = "16:9"
+	embedAspectPortrait  = "9:16"
+	embedAspectClassic   = "4:3"
+	// embedAspectAudio 与 embedAspectPlaylist 用于音频播放器，容器使用固定高度。
+	embedAspectAudio    = "audio"
+	embedAspectPlaylist = "playlist"
+)
+
+// embedSpec 是平台的静态声明。
+type embedSpec struct {
+	// Name 写入 data-video-platform，同时作为注册表中的唯一键。
+	Name string
+	// Title 用作 iframe 的 title 与链接卡片的文字。
+	Title string
+	// Hosts 是分享链接的域名，子域名同样匹配。
+	Hosts []string
+	// Aspect 是默认宽高比，Resolve 可以按链接类型覆盖。
+	Aspect string
+	// Sandbox 非空时为 iframe 写入 sandbox 属性。
+	Sandbox string
+	// SrcPattern 是 iframe 地址白名单（不含 https:// 的正则片段），
+	// 为空表示该平台不输出 iframe，只渲染为链接卡片。
+	SrcPattern string
+}
+
+// embedTarget 是分享链接解析出的嵌入地址。
+type embedTarget struct {
+	EmbedURL string
+	// Aspect 为空时使用 embedSpec.Aspect。
+	Aspect string
+	// Thumbnail 描述轻量嵌入使用的缩略图，Key 为空表示平台没有缩略图。
+	Thumbnail service.EmbedThumbnailSource
+}
+
+// embedProvider 将某个平台的分享链接转换为可嵌入的播放器。
+//
+// 新增平台只需实现该接口并加入 embedProviders，
+// 链接识别、iframe 输出与内容过滤白名单都从注册表中读取。
+type embedProvider interface {
+	Spec() embedSpec
+	// Resolve 解析已确认域名匹配的链接，无法识别的路径返回 false。
+	Resolve(u *url.URL) (embedTarget, bool)
+}
+
+// embedRegistry 按注册顺序匹配链接，先注册的平台优先。
+type embedRegistry struct {
+	providers []embedProvider
+	byName    map[string]embedProvider
+}
+
+func newEmbedRegistry(providers ...embedProvider) *embedRegistry {
+	registry := &embedRegistry{byName: make(map[string]embedProvider)}
+	for _, provider := range providers {
+		registry.register(provider)
+	}
+	return registry
+}
+
+// register 添加平台，名称重复或缺少域名属于编码错误，直接 panic。
+func (r *embedRegistry) register(provider embedProvider) {
+	spec := provider.Spec()
+	if spec.Name == "" || len(spec.Hosts) == 0 {
+		panic("embed provider requires a name and at least one host")
+	}
+	if _, exists := r.byName[spec.Name]; exists {
+		panic(fmt.Sprintf("embed provider %q registered twice", spec.Name))
+	}
+	r.providers = append(r.providers, provider)
+	r.byName[spec.Name] = provider
+}
+
+// lookup 返回链接所属平台的嵌入信息。
+func (r *embedRegistry) lookup(u *url.URL, source string) (videoEmbed, bool) {
+	host := strings.ToLower(u.Hostname())
+	for _, provider := range r.providers {
+		spec := provider.Spec()
+		if !matchesAnyHost(host, spec.Hosts) {
+			continue
+		}
+		target, ok := provider.Resolve(u)
+		if !ok {
+			continue
+		}
+		aspect := target.Aspect
+		if aspect == "" {
+			aspect = spec.Aspect
+		}
+		if aspect == "" {
+			aspect = embedAspectLandscape
+		}
+		return videoEmbed{
+			Platform:  spec.Name,
+			Source:    source,
+			EmbedURL:  target.EmbedURL,
+			Aspect:    aspect,
+			LinkOnly:  spec.SrcPattern == "",
+			Thumbnail: target.Thumbnail,
+		}, true
+	}
+	return videoEmbed{}, false
+}
+
+// spec 返回指定平台的声明，未注册时返回 false。
+func (r *embedRegistry) spec(name string) (embedSpec, bool) {
+	provider, ok := r.byName[name]
+	if !ok {
+		return embedSpec{}, false
+	}
+	return provider.Spec(), true
+}
+
+// knownHost 判断无协议的链接是否以已注册平台的域名开头，例如 "youtu.be/xxx"。
+func (r *embedRegistry) knownHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, provider := range r.providers {
+		if matchesAnyHost(host, provider.Spec().Hosts) {
+			return true
+		}
+	}
+	return false
+}
+
+// srcPattern 汇总各平台的 iframe 地址白名单。
+func (r *embedRegistry) srcPattern() *regexp.Regexp {
+	var parts []string
+	for _, provider := range r.providers {
+		if pattern := provider.Spec().SrcPattern; pattern != "" {
+			parts = append(parts, pattern)
+		}
+	}
+	if len(parts) == 0 {
+		// 没有任何平台输出 iframe 时不放行任何地址
+		return regexp.MustCompile(`$^`)
+	}
+	return regexp.MustCompile(`^https://(?:` + strings.Join(parts, "|") + `)`)
+}
+
+func matchesAnyHost(host string, domains []string) bool {
+	for _, domain := range domains {
+		if isHostOrSubdomain(host, domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,424 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/commitlog/internal/service"
+)
+
+// embedProviders 是正文中可自动嵌入的平台，顺序即匹配优先级。
+var embedProviders = newEmbedRegistry(
+	youtubeProvider{},
+	bilibiliProvider{},
+	vimeoProvider{},
+	&templateProvider{
+		spec: embedSpec{
+			Name:       "codepen",
+			Title:      "CodePen 代码演示",
+			Hosts:      []string{"codepen.io"},
+			Aspect:     embedAspectClassic,
+			Sandbox:    "allow-scripts allow-same-origin allow-popups allow-forms",
+			SrcPattern: `codepen\.io/[\w-]+/embed/[\w-]+`,
+		},
+		path:     regexp.MustCompile(`^/([\w-]+)/(?:pen|full|details|embed)/([\w-]+)/?$`),
+		template: "https://codepen.io/$1/embed/$2?default-tab=result",
+	},
+	&templateProvider{
+		spec: embedSpec{
+			Name:       "gist",
+			Title:      "GitHub Gist 代码片段",
+			Hosts:      []string{"gist.github.com"},
+			Aspect:     embedAspectClassic,
+			Sandbox:    "allow-popups",
+			SrcPattern: `gist\.github\.com/[\w-]+/[0-9a-f]+\.pibb$`,
+		},
+		// .pibb 是 Gist 提供的纯 HTML 版本，可以放进 iframe 而无需执行脚本
+		path:     regexp.MustCompile(`^/([\w-]+)/([0-9a-f]+)/?$`),
+		template: "https://gist.github.com/$1/$2.pibb",
+	},
+	spotifyProvider{},
+	netEaseMusicProvider{},
+	figmaProvider{},
+	shareLinkProvider{spec: embedSpec{
+		Name:   "douyin",
+		Title:  "抖音视频",
+		Hosts:  []string{"douyin.com", "iesdouyin.com"},
+		Aspect: embedAspectPortrait,
+	}},
+	shareLinkProvider{spec: embedSpec{
+		Name:   "xiaohongshu",
+		Title:  "小红书笔记",
+		Hosts:  []string{"xiaohongshu.com", "xhslink.com"},
+		Aspect: embedAspectPortrait,
+	}},
+)
+
+// templateProvider 用正则匹配链接路径，并按模板展开为 iframe 地址。
+type templateProvider struct {
+	spec     embedSpec
+	path     *regexp.Regexp
+	template string
+}
+
+// Spec 实现 embedProvider。
+func (p *templateProvider) Spec() embedSpec { return p.spec }
+
+// Resolve 实现 embedProvider。
+func (p *templateProvider) Resolve(u *url.URL) (embedTarget, bool) {
+	match := p.path.FindStringSubmatchIndex(u.Path)
+	if match == nil {
+		return embedTarget{}, false
+	}
+	embedURL := p.path.ExpandString(nil, p.template, u.Path, match)
+	return embedTarget{EmbedURL: string(embedURL)}, true
+}
+
+// shareLinkProvider 用于不提供稳定外链播放器的平台，链接渲染为卡片而不是 iframe。
+type shareLinkProvider struct {
+	spec embedSpec
+}
+
+// Spec 实现 embedProvider。
+func (p shareLinkProvider) Spec() embedSpec { return p.spec }
+
+// Resolve 实现 embedProvider，只要求链接指向具体内容而不是首页。
+func (p shareLinkProvider) Resolve(u *url.URL) (embedTarget, bool) {
+	if strings.Trim(u.Path, "/") == "" && u.RawQuery == "" {
+		return embedTarget{}, false
+	}
+	return embedTarget{}, true
+}
+
+type youtubeProvider struct{}
+
+// Spec 实现 embedProvider。
+func (youtubeProvider) Spec() embedSpec {
+	return embedSpec{
+		Name:       "youtube",
+		Title:      "YouTube 视频播放器",
+		Hosts:      []string{"youtube.com", "youtu.be"},
+		Aspect:     embedAspectLandscape,
+		SrcPattern: `(?:www\.)?youtube\.com/embed/|(?:www\.)?youtube-nocookie\.com/embed/`,
+	}
+}
+
+// Resolve 实现 embedProvider，支持 watch、shorts、embed、live 与 youtu.be 短链。
+func (youtubeProvider) Resolve(u *url.URL) (embedTarget, bool) {
+	host := strings.ToLower(u.Hostname())
+	var videoID string
+
+	switch {
+	case host == "youtu.be":
+		videoID = strings.Trim(strings.TrimPrefix(u.Path, "/"), "/")
+		if strings.Contains(videoID, "/") {
+			videoID = strings.Split(videoID, "/")[0]
+		}
+	case isHostOrSubdomain(host, "youtube.com"):
+		path := strings.Trim(u.Path, "/")
+		if path == "watch" {
+			videoID = u.Query().Get("v")
+		} else if strings.HasPrefix(path, "shorts/") {
+			videoID = strings.TrimPrefix(path, "shorts/")
+		} else if strings.HasPrefix(path, "embed/") {
+			videoID = strings.TrimPrefix(path, "embed/")
+		} else if strings.HasPrefix(path, "live/") {
+			videoID = strings.TrimPrefix(path, "live/")
+		}
+		if strings.Contains(videoID, "/") {
+			videoID = strings.Split(videoID, "/")[0]
+		}
+	default:
+		return embedTarget{}, false
+	}
+
+	if videoID == "" {
+		return embedTarget{}, false
+	}
+
+	embedURL := fmt.Sprintf("https://www.youtube.com/embed/%s", videoID)
+	thumbnail := service.EmbedThumbnailSource{
+		Key:      "youtube-" + videoID,
+		ImageURL: "https://i.ytimg.com/vi/" + url.PathEscape(videoID) + "/hqdefault.jpg",
+	}
+	embedValues := url.Values{}
+	embedValues.Set("rel", "0")
+	embedValues.Set("modestbranding", "1")
+	embedValues.Set("playsinline", "1")
+	if start := parseYouTubeStart(u); start > 0 {
+		embedValues.Set("start", strconv.Itoa(start))
+	}
+	return embedTarget{EmbedURL: embedURL + "?" + embedValues.Encode(), Thumbnail: thumbnail}, true
+}
+
+func parseYouTubeStart(u *url.URL) int {
+	if u == nil {
+		return 0
+	}
+	query := u.Query()
+	if value := query.Get("start"); value != "" {
+		return parseYouTubeTime(value)
+	}
+	if value := query.Get("t"); value != "" {
+		return parseYouTubeTime(value)
+	}
+	return 0
+}
+
+func parseYouTubeTime(value string) int {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return 0
+	}
+	if onlyDigits(trimmed) {
+		seconds, err := strconv.Atoi(trimmed)
+		if err == nil && seconds > 0 {
+			return seconds
+		}
+		return 0
+	}
+
+	matches := videoEmbedTimePattern.FindAllStringSubmatch(trimmed, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, match := range matches {
+		value, err := strconv.Atoi(match[1])
+		if err != nil || value <= 0 {
+			continue
+		}
+		switch strings.ToLower(match[2]) {
+		case "h":
+			total += value * 3600
+		case "m":
+			total += value * 60
+		case "s":
+			total += value
+		}
+	}
+
+	return total
+}
+
+func onlyDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+type bilibiliProvider struct{}
+
+// Spec 实现 embedProvider，播放器会尝试跳转顶层页面，因此限制 sandbox。
+func (bilibiliProvider) Spec() embedSpec {
+	return embedSpec{
+		Name:       "bilibili",
+		Title:      "B 站视频播放器",
+		Hosts:      []string{"bilibili.com"},
+		Aspect:     embedAspectLandscape,
+		Sandbox:    "allow-scripts allow-same-origin allow-presentation",
+		SrcPattern: `player\.bilibili\.com/player\.html(?:\?|$)`,
+	}
+}
+
+// Resolve 实现 embedProvider，支持 BV 号、av 号与 ?p= 分P。
+func (bilibiliProvider) Resolve(u *url.URL) (embedTarget, bool) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] != "video" {
+		return embedTarget{}, false
+	}
+
+	rawID := segments[1]
+	if rawID == "" {
+		return embedTarget{}, false
+	}
+
+	page := parsePositiveInt(u.Query().Get("p"), 1)
+
+	values := url.Values{}
+	lowerID := strings.ToLower(rawID)
+	switch {
+	case strings.HasPrefix(lowerID, "bv"):
+		values.Set("bvid", rawID)
+	case strings.HasPrefix(lowerID, "av"):
+		values.Set("aid", strings.TrimPrefix(lowerID, "av"))
+	case onlyDigits(rawID):
+		values.Set("aid", rawID)
+	default:
+		return embedTarget{}, false
+	}
+	// 视频信息接口与播放器使用相同的 bvid/aid 参数
+	thumbnail := service.EmbedThumbnailSource{
+		Key:           "bilibili-" + values.Get("bvid") + values.Get("aid"),
+		MetadataURL:   "https://api.bilibili.com/x/web-interface/view?" + values.Encode(),
+		MetadataField: "data.pic",
+	}
+	values.Set("page", strconv.Itoa(page))
+	values.Set("high_quality", "1")
+	values.Set("danmaku", "0")
+	values.Set("autoplay", "0")
+
+	return embedTarget{EmbedURL: "https://player.bilibili.com/player.html?" + values.Encode(), Thumbnail: thumbnail}, true
+}
+
+var vimeoPathPattern = regexp.MustCompile(`^/(?:video/|channels/[\w-]+/|groups/[\w-]+/videos/|showcase/\d+/video/)?(\d+)(?:/([0-9a-f]+))?/?$`)
+
+type vimeoProvider struct{}
+
+// Spec 实现 embedProvider。
+func (vimeoProvider) Spec() embedSpec {
+	return embedSpec{
+		Name:       "vimeo",
+		Title:      "Vimeo 视频播放器",
+		Hosts:      []string{"vimeo.com"},
+		Aspect:     embedAspectLandscape,
+		SrcPattern: `player\.vimeo\.com/video/\d+`,
+	}
+}
+
+// Resolve 实现 embedProvider，未公开视频链接中的哈希需要透传为 h 参数。
+func (vimeoProvider) Resolve(u *url.URL) (embedTarget, bool) {
+	match := vimeoPathPattern.FindStringSubmatch(u.Path)
+	if match == nil {
+		return embedTarget{}, false
+	}
+	shared := "https://vimeo.com/" + match[1]
+	if match[2] != "" {
+		shared += "/" + match[2]
+	}
+	thumbnail := service.EmbedThumbnailSource{
+		Key:           "vimeo-" + match[1],
+		MetadataURL:   "https://vimeo.com/api/oembed.json?url=" + url.QueryEscape(shared),
+		MetadataField: "thumbnail_url",
+	}
+
+	values := url.Values{}
+	if match[2] != "" {
+		values.Set("h", match[2])
+	} else if hash := u.Query().Get("h"); hash != "" {
+		values.Set("h", hash)
+	}
+	values.Set("dnt", "1")
+	return embedTarget{EmbedURL: "https://player.vimeo.com/video/" + match[1] + "?" + values.Encode(), Thumbnail: thumbnail}, true
+}
+
+var spotifyPathPattern = regexp.MustCompile(`^/(?:intl-[\w-]+/)?(track|album|playlist|episode|show|artist)/(\w+)/?$`)
+
+type spotifyProvider struct{}
+
+// Spec 实现 embedProvider。
+func (spotifyProvider) Spec() embedSpec {
+	return embedSpec{
+		Name:       "spotify",
+		Title:      "Spotify 播放器",
+		Hosts:      []string{"open.spotify.com"},
+		Aspect:     embedAspectPlaylist,
+		SrcPattern: `open\.spotify\.com/embed/`,
+	}
+}
+
+// Resolve 实现 embedProvider，单曲与单集使用紧凑播放器，其余使用列表播放器。
+func (spotifyProvider) Resolve(u *url.URL) (embedTarget, bool) {
+	match := spotifyPathPattern.FindStringSubmatch(u.Path)
+	if match == nil {
+		return embedTarget{}, false
+	}
+	target := embedTarget{
+		EmbedURL: "https://open.spotify.com/embed/" + match[1] + "/" + match[2],
+		Thumbnail: service.EmbedThumbnailSource{
+			Key:           "spotify-" + match[1] + "-" + match[2],
+			MetadataURL:   "https://open.spotify.com/oembed?url=" + url.QueryEscape("https://open.spotify.com/"+match[1]+"/"+match[2]),
+			MetadataField: "thumbnail_url",
+		},
+	}
+	if match[1] == "track" || match[1] == "episode" {
+		target.Aspect = embedAspectAudio
+	}
+	return target, true
+}
+
+// netEaseMusicTypes 对应外链播放器的 type 参数。
+var netEaseMusicTypes = map[string]string{
+	"playlist": "0",
+	"album":    "1",
+	"song":     "2",
+}
+
+type netEaseMusicProvider struct{}
+
+// Spec 实现 embedProvider。
+func (netEaseMusicProvider) Spec() embedSpec {
+	return embedSpec{
+		Name:       "netease",
+		Title:      "网易云音乐播放器",
+		Hosts:      []string{"music.163.com"},
+		Aspect:     embedAspectAudio,
+		SrcPattern: `music\.163\.com/outchain/player\?`,
+	}
+}
+
+// Resolve 实现 embedProvider，网页版的路由写在 # 之后，例如 music.163.com/#/song?id=1。
+func (netEaseMusicProvider) Resolve(u *url.URL) (embedTarget, bool) {
+	route, query := u.Path, u.RawQuery
+	if strings.HasPrefix(u.Fragment, "/") {
+		route, query, _ = strings.Cut(u.Fragment, "?")
+	}
+	route = strings.TrimPrefix(strings.Trim(route, "/"), "m/")
+	kind, ok := netEaseMusicTypes[route]
+	if !ok {
+		return embedTarget{}, false
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil || !onlyDigits(values.Get("id")) {
+		return embedTarget{}, false
+	}
+
+	embedValues := url.Values{}
+	embedValues.Set("type", kind)
+	embedValues.Set("id", values.Get("id"))
+	embedValues.Set("auto", "0")
+	height := "66"
+	aspect := embedAspectAudio
+	if kind != "2" {
+		height = "430"
+		aspect = embedAspectPlaylist
+	}
+	embedValues.Set("height", height)
+	return embedTarget{EmbedURL: "https://music.163.com/outchain/player?" + embedValues.Encode(), Aspect: aspect}, true
+}
+
+var figmaPathPattern = regexp.MustCompile(`^/(?:file|design|proto|board|slides)/[\w-]+`)
+
+type figmaProvider struct{}
+
+// Spec 实现 embedProvider。
+func (figmaProvider) Spec() embedSpec {
+	return embedSpec{
+		Name:       "figma",
+		Title:      "Figma 设计稿",
+		Hosts:      []string{"figma.com"},
+		Aspect:     embedAspectLandscape,
+		SrcPattern: `(?:www\.)?figma\.com/embed\?`,
+	}
+}
+
+// Resolve 实现 embedProvider，Figma 的嵌入地址以完整分享链接作为参数。
+func (figmaProvider) Resolve(u *url.URL) (embedTarget, bool) {
+	if !figmaPathPattern.MatchString(u.Path) {
+		return embedTarget{}, false
+	}
+	shared := *u
+	shared.Scheme = "https"
+	shared.Host = "www.figma.com"
+	values := url.Values{}
+	values.Set("embed_host", "share")
+	values.Set("url", shared.String())
+	return embedTarget{EmbedURL: "https://www.figma.com/embed?" + values.Encode()}, true
+}
@@ -131,7 +131,7 @@ func (a *API) DeleteGalleryImage(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "作品已删除"})
+	c.JSON(http.StatusOK, gin.H{"message": "作品已移入回收站"})
 }
 
 // ShowGallery renders public gallery page.
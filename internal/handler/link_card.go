@@ -0,0 +1,111 @@
+package handler
+
+import (
+	htmlstd "html"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/service"
+)
+
+// linkCardClassPattern 限定链接卡片内部元素的类名。
+var linkCardClassPattern = regexp.MustCompile(`^link-card(?:-link|-body|-title|-description|-site|-image)?$`)
+
+// standaloneLinkURLs 返回正文中需要生成链接卡片的链接：独立成行、以 http(s) 开头且不属于可嵌入平台。
+func standaloneLinkURLs(content string) []string {
+	var urls []string
+	for _, candidate := range findStandaloneURLLines(strings.Split(content, "\n")) {
+		if target, ok := linkCardTarget(candidate.URL); ok {
+			urls = append(urls, target)
+		}
+	}
+	return urls
+}
+
+// linkCardTarget 去掉尖括号并规范化链接，已由嵌入平台处理的链接返回 false。
+func linkCardTarget(raw string) (string, bool) {
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(raw), "<"), ">")
+	lower := strings.ToLower(trimmed)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return "", false
+	}
+	if _, ok := parseVideoEmbed(trimmed); ok {
+		return "", false
+	}
+	return service.NormalizeLinkPreviewURL(trimmed)
+}
+
+// queueRemoteContent 在保存或发布后安排后台抓取链接卡片信息与嵌入内容的缩略图，不阻塞当前请求。
+func (a *API) queueRemoteContent(content string) {
+	if a.linkPreviews != nil {
+		a.linkPreviews.Schedule(standaloneLinkURLs(content))
+	}
+	if a.thumbnails != nil {
+		a.thumbnails.Schedule(embedThumbnailSources(content))
+	}
+}
+
+// applyLinkCards 将已缓存预览的链接行替换为卡片，缺失或过期的缓存交给后台刷新。
+func applyLinkCards(lines []string, candidates []standaloneURLLine, previews *service.LinkPreviewService) {
+	if previews == nil || len(candidates) == 0 {
+		return
+	}
+	targets := make(map[int]string, len(candidates))
+	urls := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if target, ok := linkCardTarget(candidate.URL); ok {
+			targets[candidate.Index] = target
+			urls = append(urls, target)
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+
+	cached, err := previews.Lookup(urls)
+	if err != nil {
+		log.Printf("[link-preview] load previews failed: %v", err)
+		return
+	}
+	var refresh []string
+	for index, target := range targets {
+		preview, ok := cached[target]
+		if !ok || previews.Stale(preview) {
+			refresh = append(refresh, target)
+		}
+		if ok && preview.Usable() {
+			lines[index] = buildLinkCardHTML(preview)
+		}
+	}
+	previews.Schedule(refresh)
+}
+
+// buildLinkCardHTML 输出链接卡片，图片使用本站缓存的地址。
+func buildLinkCardHTML(preview db.LinkPreview) string {
+	var b strings.Builder
+	b.WriteString(`<div class="link-card" data-link-card="true"><a class="link-card-link" href="`)
+	b.WriteString(htmlstd.EscapeString(preview.URL))
+	b.WriteString(`"><span class="link-card-body"><span class="link-card-title">`)
+	b.WriteString(htmlstd.EscapeString(preview.Title))
+	b.WriteString(`</span>`)
+	if preview.Description != "" {
+		b.WriteString(`<span class="link-card-description">`)
+		b.WriteString(htmlstd.EscapeString(preview.Description))
+		b.WriteString(`</span>`)
+	}
+	if preview.SiteName != "" {
+		b.WriteString(`<span class="link-card-site">`)
+		b.WriteString(htmlstd.EscapeString(preview.SiteName))
+		b.WriteString(`</span>`)
+	}
+	b.WriteString(`</span>`)
+	if preview.ImageURL != "" {
+		b.WriteString(`<img class="link-card-image" src="`)
+		b.WriteString(htmlstd.EscapeString(preview.ImageURL))
+		b.WriteString(`" alt="" loading="lazy">`)
+	}
+	b.WriteString(`</a></div>`)
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/service"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type offlineHTTPClient struct{}
+
+func (offlineHTTPClient) Do(*http.Request) (*http.Response, error) {
+	return nil, errors.New("network disabled in tests")
+}
+
+func TestStandaloneLinkURLs_SkipsCodeListsAndEmbeds(t *testing.T) {
+	content := strings.Join([]string{
+		"# 标题",
+		"https://example.com/a",
+		"<https://example.com/b>",
+		"行内链接 https://example.com/inline 不会变成卡片",
+		"- https://example.com/list",
+		"> https://example.com/quote",
+		"```",
+		"https://example.com/code",
+		"```",
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		"example.com/no-scheme",
+	}, "\n")
+
+	got := standaloneLinkURLs(content)
+	want := []string{"https://example.com/a", "https://example.com/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRenderMarkdown_LinkCardsUseCachedPreviews(t *testing.T) {
+	dsn := fmt.Sprintf("file:link-card-%d?mode=memory&cache=shared", time.Now().UnixNano())
+	gdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := gdb.AutoMigrate(&db.LinkPreview{}); err != nil {
+		t.Fatalf("failed to migrate test db: %v", err)
+	}
+	seed := []db.LinkPreview{
+		{URL: "https://example.com/post", Title: "一篇<文章>", Description: "摘要", SiteName: "Example", ImageURL: "/uploads/embeds/link-abc.png", FetchedAt: time.Now()},
+		{URL: "https://example.com/broken", FetchError: "unexpected status 404", FetchedAt: time.Now()},
+	}
+	if err := gdb.Create(&seed).Error; err != nil {
+		t.Fatalf("seed previews: %v", err)
+	}
+
+	previews := service.NewLinkPreviewService(gdb, nil)
+	previews.SetHTTPClient(offlineHTTPClient{})
+	markdown := "https://example.com/post\n\nhttps://example.com/broken\n\nhttps://example.com/unknown"
+
+	rendered, _, err := renderMarkdownWithTOC(markdown, embedRenderOptions{LinkPreviews: previews})
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+	html := string(rendered)
+	if !strings.Contains(html, `<div class="link-card" data-link-card="true"><a class="link-card-link" href="https://example.com/post"`) {
+		t.Fatalf("expected link card for cached preview, got: %s", html)
+	}
+	for _, fragment := range []string{
+		`<span class="link-card-title">一篇&lt;文章&gt;</span>`,
+		`<span class="link-card-description">摘要</span>`,
+		`<span class="link-card-site">Example</span>`,
+		`<img class="link-card-image" src="/uploads/embeds/link-abc.png"`,
+	} {
+		if !strings.Contains(html, fragment) {
+			t.Fatalf("expected %s in card, got: %s", fragment, html)
+		}
+	}
+	if strings.Count(html, "data-link-card") != 1 {
+		t.Fatalf("expected failed and unknown previews to stay plain links, got: %s", html)
+	}
+	if !strings.Contains(html, `href="https://example.com/unknown"`) {
+		t.Fatalf("expected uncached link to remain linkified, got: %s", html)
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// linkCheckGroupView 在检查结果分组上附加后台编辑入口。
+type linkCheckGroupView struct {
+	service.LinkCheckGroup
+	EditURL string `json:"edit_url"`
+}
+
+// GetLinkCheckReport 返回最近一次失效链接检查的结果，按来源分组
+func (a *API) GetLinkCheckReport(c *gin.Context) {
+	report, err := a.linkChecker.LatestReport()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取检查结果失败")
+		return
+	}
+
+	groups := make([]linkCheckGroupView, 0, len(report.Groups))
+	for _, group := range report.Groups {
+		groups = append(groups, linkCheckGroupView{LinkCheckGroup: group, EditURL: linkCheckEditURL(group)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"run":     report.Run,
+		"running": report.Running,
+		"groups":  groups,
+	})
+}
+
+// RunLinkCheck 在后台开始一次失效链接检查
+func (a *API) RunLinkCheck(c *gin.Context) {
+	// 检查耗时较长，不随请求结束而取消
+	run, err := a.linkChecker.Start(context.Background())
+	if err != nil {
+		if errors.Is(err, service.ErrLinkCheckRunning) {
+			respondError(c, http.StatusConflict, "已有检查正在进行")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "启动检查失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "检查已开始", "run": run})
+}
+
+func linkCheckEditURL(group service.LinkCheckGroup) string {
+	switch group.SourceType {
+	case db.LinkCheckSourcePost:
+		return fmt.Sprintf("/admin/posts/%d/edit", group.SourceID)
+	case db.LinkCheckSourcePage:
+		return "/admin/about"
+	case db.LinkCheckSourceGallery:
+		return "/admin/gallery"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"bytes"
+	htmlstd "html"
+	"regexp"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// mathClassPattern 限定数学公式输出的类名。
+var mathClassPattern = regexp.MustCompile(`^math math-(?:inline|display)$`)
+
+var (
+	kindMathBlock  = ast.NewNodeKind("MathBlock")
+	kindMathInline = ast.NewNodeKind("MathInline")
+)
+
+// mathBlock 是 $$ 包裹的独立公式块。
+type mathBlock struct {
+	ast.BaseBlock
+}
+
+// Kind 实现 ast.Node。
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+// IsRaw 实现 ast.Node，公式内容不再做行内解析。
+func (n *mathBlock) IsRaw() bool { return true }
+
+// Dump 实现 ast.Node。
+func (n *mathBlock) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+// mathInline 是段落中的 $...$ 或 $$...$$ 公式。
+type mathInline struct {
+	ast.BaseInline
+	Segment text.Segment
+	Display bool
+}
+
+// Kind 实现 ast.Node。
+func (n *mathInline) Kind() ast.NodeKind { return kindMathInline }
+
+// Dump 实现 ast.Node。
+func (n *mathInline) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+// mathExtension 识别 LaTeX 公式并输出 KaTeX、MathJax 均可识别的 \(...\)、\[...\] 标记。
+//
+// 公式在 goldmark 解析阶段整体取出，内部的 _、* 不会被当作强调；
+// 代码块与行内代码由 goldmark 先行解析，其中的 $ 不受影响。
+type mathExtension struct{}
+
+// Extend 实现 goldmark.Extender。
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 701)),
+		parser.WithInlineParsers(util.Prioritized(&mathInlineParser{}, 501)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&mathRenderer{}, 200)))
+}
+
+type mathBlockParser struct{}
+
+var mathBlockDelimiter = []byte("$$")
+
+// Trigger 实现 parser.BlockParser。
+func (b *mathBlockParser) Trigger() []byte { return []byte{'$'} }
+
+// Open 实现 parser.BlockParser，只接受以 $$ 开头的行。
+func (b *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], mathBlockDelimiter) {
+		return nil, parser.NoChildren
+	}
+
+	node := &mathBlock{}
+	start := pos + len(mathBlockDelimiter)
+	rest := util.TrimRightSpace(line[start:])
+	// 单行写法：$$ E = mc^2 $$
+	if closing := bytes.Index(rest, mathBlockDelimiter); closing >= 0 {
+		if len(bytes.TrimSpace(rest[closing+len(mathBlockDelimiter):])) != 0 {
+			return nil, parser.NoChildren
+		}
+		if closing > 0 {
+			node.Lines().Append(text.NewSegment(segment.Start+start, segment.Start+start+closing))
+		}
+		return node, parser.Close
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		node.Lines().Append(text.NewSegment(segment.Start+start, segment.Stop))
+	}
+	return node, parser.NoChildren
+}
+
+// Continue 实现 parser.BlockParser，遇到以 $$ 结尾的行时结束。
+func (b *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	trimmed := util.TrimRightSpace(line)
+	if bytes.HasSuffix(trimmed, mathBlockDelimiter) {
+		content := bytes.TrimSpace(trimmed[:len(trimmed)-len(mathBlockDelimiter)])
+		if len(content) > 0 {
+			node.Lines().Append(text.NewSegment(segment.Start, segment.Start+len(trimmed)-len(mathBlockDelimiter)))
+		}
+		reader.Advance(segment.Len())
+		return parser.Close
+	}
+	node.Lines().Append(segment)
+	reader.AdvanceToEOL()
+	return parser.Continue | parser.NoChildren
+}
+
+// Close 实现 parser.BlockParser。
+func (b *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+// CanInterruptParagraph 实现 parser.BlockParser。
+func (b *mathBlockParser) CanInterruptParagraph() bool { return true }
+
+// CanAcceptIndentedLine 实现 parser.BlockParser。
+func (b *mathBlockParser) CanAcceptIndentedLine() bool { return false }
+
+type mathInlineParser struct{}
+
+// Trigger 实现 parser.InlineParser。
+func (p *mathInlineParser) Trigger() []byte { return []byte{'$'} }
+
+// Parse 实现 parser.InlineParser。
+//
+// 与 Pandoc 规则一致：开头的 $ 后不能是空白，结尾的 $ 前不能是空白且后面不能紧跟数字，
+// 因此 "$5 和 $10" 这类金额不会被误判为公式。公式内出现反引号时放弃匹配，交给行内代码处理。
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	delimiter := 1
+	if len(line) > 1 && line[1] == '$' {
+		delimiter = 2
+	}
+	if len(line) <= delimiter*2 || util.IsSpace(line[delimiter]) || line[delimiter] == '$' {
+		return nil
+	}
+
+	for i := delimiter; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+			continue
+		case '`', '\n':
+			return nil
+		case '$':
+		default:
+			continue
+		}
+		if delimiter == 2 && (i+1 >= len(line) || line[i+1] != '$') {
+			continue
+		}
+		if delimiter == 1 && util.IsSpace(line[i-1]) {
+			return nil
+		}
+		if delimiter == 1 && i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9' {
+			return nil
+		}
+		node := &mathInline{
+			Segment: text.NewSegment(segment.Start+delimiter, segment.Start+i),
+			Display: delimiter == 2,
+		}
+		block.Advance(i + delimiter)
+		return node
+	}
+	return nil
+}
+
+type mathRenderer struct{}
+
+// RegisterFuncs 实现 renderer.NodeRenderer。
+func (r *mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMathBlock, r.renderBlock)
+	reg.Register(kindMathInline, r.renderInline)
+}
+
+func (r *mathRenderer) renderBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	var tex bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		tex.Write(segment.Value(source))
+	}
+	_, _ = w.WriteString(`<div class="math math-display">\[`)
+	_, _ = w.WriteString(htmlstd.EscapeString(string(bytes.TrimSpace(tex.Bytes()))))
+	_, _ = w.WriteString("\\]</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *mathRenderer) renderInline(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	n := node.(*mathInline)
+	tex := htmlstd.EscapeString(string(bytes.TrimSpace(n.Segment.Value(source))))
+	if n.Display {
+		_, _ = w.WriteString(`<span class="math math-display">\[` + tex + `\]</span>`)
+	} else {
+		_, _ = w.WriteString(`<span class="math math-inline">\(` + tex + `\)</span>`)
+	}
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown_Math(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "inline math keeps underscores and stars",
+			markdown: "损失函数 $L = a_1 * b_2 * c_3$ 收敛",
+			want:     []string{`<span class="math math-inline">\(L = a_1 * b_2 * c_3\)</span>`},
+			notWant:  []string{"<em>"},
+		},
+		{
+			name:     "display block",
+			markdown: "$$\n\\sum_{i=1}^n x_i < \\infty\n$$\n",
+			want:     []string{`<div class="math math-display">\[\sum_{i=1}^n x_i &lt; \infty\]</div>`},
+		},
+		{
+			name:     "single line display",
+			markdown: "$$ E = mc^2 $$",
+			want:     []string{`<div class="math math-display">\[E = mc^2\]</div>`},
+		},
+		{
+			name:     "currency is not math",
+			markdown: "原价 $5，现价 $10",
+			want:     []string{"原价 $5，现价 $10"},
+			notWant:  []string{"math-inline"},
+		},
+		{
+			name:     "code span is untouched",
+			markdown: "`$x_1$` 与 $y$",
+			want:     []string{"<code>$x_1$</code>", `<span class="math math-inline">\(y\)</span>`},
+		},
+		{
+			name:     "fenced code is untouched",
+			markdown: "```\n$$\nx\n$$\n```",
+			notWant:  []string{"math-display"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			htmlContent, _, err := renderMarkdownWithTOC(tt.markdown, embedRenderOptions{})
+			if err != nil {
+				t.Fatalf("render markdown: %v", err)
+			}
+			rendered := string(htmlContent)
+			for _, want := range tt.want {
+				if !strings.Contains(rendered, want) {
+					t.Fatalf("expected %s in output, got:\n%s", want, rendered)
+				}
+			}
+			for _, unwanted := range tt.notWant {
+				if strings.Contains(rendered, unwanted) {
+					t.Fatalf("did not expect %s in output, got:\n%s", unwanted, rendered)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderFeedMarkdown_Math(t *testing.T) {
+	t.Parallel()
+
+	feed, err := renderFeedMarkdown("面积 $\\pi r^2$")
+	if err != nil {
+		t.Fatalf("render feed markdown: %v", err)
+	}
+	if !strings.Contains(string(feed), `<span class="math math-inline">\(\pi r^2\)</span>`) {
+		t.Fatalf("expected math markup in feed output, got %s", feed)
+	}
+}
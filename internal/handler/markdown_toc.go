@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// headingIDPattern 限定标题锚点只包含字母、数字、连字符与下划线，允许中日韩等非 ASCII 文字。
+var headingIDPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// TOCItem 表示文章目录中的一个标题，Children 为其下更深层级的标题。
+type TOCItem struct {
+	Level    int
+	Title    string
+	ID       string
+	Children []*TOCItem
+}
+
+// headingIDs 为标题生成锚点，保留中文等文字并对重复标题追加 -1、-2 后缀。
+// 锚点只由标题文字和出现顺序决定，重新发布后深链接依然有效。
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: make(map[string]bool)}
+}
+
+// Generate 实现 parser.IDs。
+func (h *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := slugifyHeading(string(value))
+	if base == "" {
+		if kind == ast.KindHeading {
+			base = "section"
+		} else {
+			base = "id"
+		}
+	}
+	id := base
+	for i := 1; h.used[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	h.used[id] = true
+	return []byte(id)
+}
+
+// Put 实现 parser.IDs，记录手动指定的锚点以免与自动生成的重复。
+func (h *headingIDs) Put(value []byte) {
+	h.used[string(value)] = true
+}
+
+// slugifyHeading 将标题文字转换为锚点：字母转小写，空白与连字符折叠为单个 -，其余标点丢弃。
+func slugifyHeading(value string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.TrimSpace(value) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_':
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-':
+			pendingDash = true
+		}
+	}
+	return b.String()
+}
+
+// renderMarkdownWithTOC 渲染 Markdown，并返回按层级嵌套的标题目录。
+func renderMarkdownWithTOC(content string, embeds embedRenderOptions) (template.HTML, []*TOCItem, error) {
+	source := []byte(applyVideoEmbeds(content, embeds))
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	if embeds.WikiLinks != nil && strings.Contains(content, "[[") {
+		index, err := embeds.WikiLinks.WikiLinkIndex()
+		if err != nil {
+			return "", nil, err
+		}
+		ctx.Set(wikiLinkIndexKey, index)
+	}
+	doc := markdownEngine.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := markdownEngine.Renderer().Render(&buf, source, doc); err != nil {
+		return "", nil, err
+	}
+	safe := sanitizer.SanitizeBytes(buf.Bytes())
+	return template.HTML(safe), buildTOC(doc, source), nil
+}
+
+// renderFeedMarkdown 渲染订阅正文，代码块使用内联样式着色。
+func renderFeedMarkdown(content string) (template.HTML, error) {
+	var buf bytes.Buffer
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	if err := feedMarkdownEngine.Convert([]byte(applyVideoEmbeds(content, embedRenderOptions{})), &buf, parser.WithContext(ctx)); err != nil {
+		return "", err
+	}
+	return template.HTML(feedSanitizer.SanitizeBytes(buf.Bytes())), nil
+}
+
+// buildTOC 收集文档中的标题，较深的标题挂到前一个较浅标题之下。
+func buildTOC(doc ast.Node, source []byte) []*TOCItem {
+	var roots []*TOCItem
+	var stack []*TOCItem
+
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		heading, ok := node.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		rawID, ok := heading.AttributeString("id")
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		id, _ := rawID.([]byte)
+		title := strings.TrimSpace(headingPlainText(heading, source))
+		if len(id) == 0 || title == "" {
+			return ast.WalkSkipChildren, nil
+		}
+
+		item := &TOCItem{Level: heading.Level, Title: title, ID: string(id)}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= item.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, item)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, item)
+		}
+		stack = append(stack, item)
+		return ast.WalkSkipChildren, nil
+	})
+	return roots
+}
+
+// headingPlainText 提取标题中的纯文本，忽略强调、链接等行内标记。
+func headingPlainText(node ast.Node, source []byte) string {
+	var b strings.Builder
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		case *mathInline:
+			b.Write(n.Segment.Value(source))
+		default:
+			b.WriteString(headingPlainText(n, source))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownWithTOC(t *testing.T) {
+	t.Parallel()
+
+	content := "# 简介\n\n## 安装步骤\n\n### Step **One**\n\n## 安装步骤\n\n## Hello, World!\n\n# 简介\n"
+	htmlContent, toc, err := renderMarkdownWithTOC(content, embedRenderOptions{})
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+
+	rendered := string(htmlContent)
+	for _, want := range []string{
+		`<h1 id="简介">`,
+		`<h2 id="安装步骤">`,
+		`<h3 id="step-one">`,
+		`<h2 id="安装步骤-1">`,
+		`<h2 id="hello-world">`,
+		`<h1 id="简介-1">`,
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("expected %s in rendered html, got:\n%s", want, rendered)
+		}
+	}
+
+	if len(toc) != 2 || toc[0].ID != "简介" || toc[1].ID != "简介-1" {
+		t.Fatalf("unexpected top level toc: %+v", toc)
+	}
+	children := toc[0].Children
+	if len(children) != 3 || children[0].ID != "安装步骤" || children[1].ID != "安装步骤-1" {
+		t.Fatalf("unexpected nested toc: %+v", children)
+	}
+	if len(children[0].Children) != 1 || children[0].Children[0].Title != "Step One" || children[0].Children[0].Level != 3 {
+		t.Fatalf("expected plain text title for nested heading, got %+v", children[0].Children)
+	}
+
+	again, _, err := renderMarkdownWithTOC(content, embedRenderOptions{})
+	if err != nil || again != htmlContent {
+		t.Fatal("expected heading ids to be stable across renders")
+	}
+}
+
+func TestSlugifyHeading(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"Hello, World!":   "hello-world",
+		"  安装 -- 步骤  ":    "安装-步骤",
+		"Go 1.22 新特性":     "go-122-新特性",
+		"snake_case_name": "snake_case_name",
+		"！？":              "",
+		"日本語のテキスト":        "日本語のテキスト",
+	}
+	for input, want := range tests {
+		if got := slugifyHeading(input); got != want {
+			t.Errorf("slugifyHeading(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"bytes"
+	htmlstd "html"
+	"regexp"
+
+	"github.com/commitlog/internal/service"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// wikiLinkClassPattern 限定内部链接输出的类名。
+var wikiLinkClassPattern = regexp.MustCompile(`^wiki-link(?: is-missing)?$`)
+
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+// wikiLinkIndexKey 在解析上下文中保存本次渲染使用的文章索引。
+var wikiLinkIndexKey = parser.NewContextKey()
+
+// wikiLinkSource 按需提供内部链接索引，只有正文包含 [[ 时才会读取。
+type wikiLinkSource interface {
+	WikiLinkIndex() (*service.WikiLinkIndex, error)
+}
+
+// wikiLink 是 [[标题]]、[[post:42]] 或 [[目标|显示文字]] 形式的内部链接。
+type wikiLink struct {
+	ast.BaseInline
+	Label string
+	// Path 是解析到的文章地址，为空表示未找到文章。
+	Path string
+	// Checked 为 false 时本次渲染没有索引，链接按普通文字输出。
+	Checked bool
+}
+
+// Kind 实现 ast.Node。
+func (n *wikiLink) Kind() ast.NodeKind { return kindWikiLink }
+
+// Dump 实现 ast.Node。
+func (n *wikiLink) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+// wikiLinkExtension 在渲染时将内部链接解析为文章地址，未找到的链接带 is-missing 标记。
+type wikiLinkExtension struct{}
+
+// Extend 实现 goldmark.Extender，优先级高于普通链接，避免 [[ 被当作链接文字。
+func (e *wikiLinkExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(&wikiLinkParser{}, 199)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&wikiLinkRenderer{}, 200)))
+}
+
+type wikiLinkParser struct{}
+
+var (
+	wikiLinkOpen  = []byte("[[")
+	wikiLinkClose = []byte("]]")
+)
+
+// Trigger 实现 parser.InlineParser。
+func (p *wikiLinkParser) Trigger() []byte { return []byte{'['} }
+
+// Parse 实现 parser.InlineParser，内部链接必须在同一行内闭合。
+func (p *wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, wikiLinkOpen) {
+		return nil
+	}
+	end := bytes.Index(line[len(wikiLinkOpen):], wikiLinkClose)
+	if end < 0 {
+		return nil
+	}
+	inner := line[len(wikiLinkOpen) : len(wikiLinkOpen)+end]
+	if bytes.ContainsAny(inner, "[\n") {
+		return nil
+	}
+	link, ok := service.ParseWikiLink(string(inner))
+	if !ok {
+		return nil
+	}
+	block.Advance(len(wikiLinkOpen) + end + len(wikiLinkClose))
+
+	node := &wikiLink{Label: link.Label}
+	if index, ok := pc.Get(wikiLinkIndexKey).(*service.WikiLinkIndex); ok && index != nil {
+		node.Checked = true
+		if target, found := index.Resolve(link.Target); found {
+			node.Path = target.Path
+		}
+	}
+	return node
+}
+
+type wikiLinkRenderer struct{}
+
+// RegisterFuncs 实现 renderer.NodeRenderer。
+func (r *wikiLinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindWikiLink, r.render)
+}
+
+func (r *wikiLinkRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	n := node.(*wikiLink)
+	label := htmlstd.EscapeString(n.Label)
+	switch {
+	case n.Path != "":
+		_, _ = w.WriteString(`<a class="wiki-link" href="` + htmlstd.EscapeString(n.Path) + `">` + label + `</a>`)
+	case n.Checked:
+		_, _ = w.WriteString(`<span class="wiki-link is-missing">` + label + `</span>`)
+	default:
+		_, _ = w.WriteString(label)
+	}
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/service"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRenderMarkdown_WikiLinks(t *testing.T) {
+	dsn := fmt.Sprintf("file:wiki-link-%d?mode=memory&cache=shared", time.Now().UnixNano())
+	gdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := gdb.AutoMigrate(&db.User{}, &db.Post{}, &db.PostPublication{}); err != nil {
+		t.Fatalf("failed to migrate test db: %v", err)
+	}
+
+	post := db.Post{Slug: "go-concurrency", Content: "# Go 并发模式\n正文", Status: "published"}
+	if err := gdb.Create(&post).Error; err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	publication := db.PostPublication{PostID: post.ID, Content: post.Content, PublishedAt: time.Now(), Version: 1}
+	if err := gdb.Create(&publication).Error; err != nil {
+		t.Fatalf("create publication: %v", err)
+	}
+	if err := gdb.Model(&post).Update("latest_publication_id", publication.ID).Error; err != nil {
+		t.Fatalf("link publication: %v", err)
+	}
+
+	markdown := fmt.Sprintf("参考 [[go 并发模式]]、[[post:%d|第一篇]] 与 [[尚未发布]]。\n\n`[[代码]]` 和 [普通链接](https://example.com)", post.ID)
+	rendered, _, err := renderMarkdownWithTOC(markdown, embedRenderOptions{WikiLinks: service.NewPostService(gdb)})
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+	html := string(rendered)
+	for _, fragment := range []string{
+		`<a class="wiki-link" href="/posts/go-concurrency" rel="nofollow">go 并发模式</a>`,
+		`<a class="wiki-link" href="/posts/go-concurrency" rel="nofollow">第一篇</a>`,
+		`<span class="wiki-link is-missing">尚未发布</span>`,
+		`<code>[[代码]]</code>`,
+		`<a href="https://example.com" rel="nofollow">普通链接</a>`,
+	} {
+		if !strings.Contains(html, fragment) {
+			t.Fatalf("expected %s, got: %s", fragment, html)
+		}
+	}
+
+	plain, _, err := renderMarkdownWithTOC("[[go 并发模式]]", embedRenderOptions{})
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+	if got := strings.TrimSpace(string(plain)); got != "<p>go 并发模式</p>" {
+		t.Fatalf("expected plain label without an index, got %q", got)
+	}
+}
@@ -60,6 +60,7 @@ func (a *API) UpdateAboutPage(c *gin.Context) {
 		return
 	}
 
+	a.queueRemoteContent(page.Content)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "关于页面已更新",
 		"page": gin.H{
@@ -22,6 +22,7 @@ const defaultUserID = 1
 
 type postPayload struct {
 	Title          string `json:"title"`
+	Slug           string `json:"slug"`
 	Content        string `json:"content"`
 	Summary        string `json:"summary"`
 	Visibility     string `json:"visibility"`
@@ -30,6 +31,7 @@ type postPayload struct {
 	CoverWidth     int    `json:"cover_width"`
 	CoverHeight    int    `json:"cover_height"`
 	DraftSessionID string `json:"draft_session_id"`
+	Password       string `json:"password"`
 }
 
 type postPreviewPayload struct {
@@ -46,6 +48,7 @@ type postPreviewPayload struct {
 func (p postPayload) toInput(userID uint) service.PostInput {
 	return service.PostInput{
 		Title:          p.Title,
+		Slug:           p.Slug,
 		Content:        p.Content,
 		Summary:        p.Summary,
 		Visibility:     p.Visibility,
@@ -55,6 +58,7 @@ func (p postPayload) toInput(userID uint) service.PostInput {
 		CoverWidth:     p.CoverWidth,
 		CoverHeight:    p.CoverHeight,
 		DraftSessionID: p.DraftSessionID,
+		Password:       p.Password,
 	}
 }
 
@@ -132,6 +136,10 @@ func (a *API) CreatePost(c *gin.Context) {
 			respondError(c, http.StatusBadRequest, "部分标签不存在")
 		case errors.Is(err, service.ErrVisibilityInvalid):
 			respondError(c, http.StatusBadRequest, "文章可见度不合法")
+		case errors.Is(err, service.ErrSlugInvalid):
+			respondError(c, http.StatusBadRequest, "文章链接不合法")
+		case errors.Is(err, service.ErrSlugTaken):
+			respondError(c, http.StatusConflict, "文章链接已被占用")
 		case errors.Is(err, service.ErrCoverRequired):
 			respondError(c, http.StatusBadRequest, "请上传文章封面")
 		case errors.Is(err, service.ErrCoverInvalid):
@@ -142,6 +150,7 @@ func (a *API) CreatePost(c *gin.Context) {
 		return
 	}
 
+	a.queueRemoteContent(post.Content)
 	c.JSON(http.StatusOK, gin.H{"message": "文章创建成功", "post": post})
 }
 
@@ -167,6 +176,10 @@ func (a *API) UpdatePost(c *gin.Context) {
 			respondError(c, http.StatusBadRequest, "部分标签不存在")
 		case errors.Is(err, service.ErrVisibilityInvalid):
 			respondError(c, http.StatusBadRequest, "文章可见度不合法")
+		case errors.Is(err, service.ErrSlugInvalid):
+			respondError(c, http.StatusBadRequest, "文章链接不合法")
+		case errors.Is(err, service.ErrSlugTaken):
+			respondError(c, http.StatusConflict, "文章链接已被占用")
 		case errors.Is(err, service.ErrCoverRequired):
 			respondError(c, http.StatusBadRequest, "请上传文章封面")
 		case errors.Is(err, service.ErrCoverInvalid):
@@ -177,6 +190,7 @@ func (a *API) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	a.queueRemoteContent(post.Content)
 	c.JSON(http.StatusOK, gin.H{"message": "草稿更新成功", "post": post})
 }
 
@@ -222,6 +236,21 @@ func (a *API) PublishPost(c *gin.Context) {
 		}
 	}
 
+	// 未来的发布时间转为定时发布，由后台调度器到期后执行
+	if desiredPublishedAt != nil && desiredPublishedAt.After(time.Now()) {
+		post, scheduleErr := a.posts.SchedulePublish(id, *desiredPublishedAt, time.Now())
+		if scheduleErr != nil {
+			respondScheduleError(c, scheduleErr)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "文章已设置为定时发布",
+			"post":      post,
+			"scheduled": true,
+		})
+		return
+	}
+
 	publication, err := a.posts.Publish(id, a.currentUserID(c), desiredPublishedAt)
 	if err != nil {
 		switch {
@@ -237,6 +266,9 @@ func (a *API) PublishPost(c *gin.Context) {
 		case errors.Is(err, service.ErrInvalidPublishState):
 			respondError(c, http.StatusBadRequest, "请完善标题与正文内容后再发布")
 			return
+		case errors.Is(err, service.ErrPostPasswordRequired):
+			respondError(c, http.StatusBadRequest, "请为受密码保护的文章设置访问密码")
+			return
 		default:
 			respondError(c, http.StatusInternalServerError, "发布文章失败")
 			return
@@ -255,6 +287,7 @@ func (a *API) PublishPost(c *gin.Context) {
 		c.Error(refreshErr)
 	}
 
+	a.queueRemoteContent(publication.Content)
 	response := gin.H{
 		"message":     "文章发布成功",
 		"publication": publication,
@@ -263,7 +296,31 @@ func (a *API) PublishPost(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// DeletePost 删除文章
+// WithdrawPost 下线已发布文章，保留发布历史以便之后重新发布
+func (a *API) WithdrawPost(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return
+	}
+
+	post, err := a.posts.Withdraw(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPostNotFound):
+			respondError(c, http.StatusNotFound, "文章不存在")
+		case errors.Is(err, service.ErrPostNotPublished):
+			respondError(c, http.StatusBadRequest, "仅已发布的文章可以下线")
+		default:
+			respondError(c, http.StatusInternalServerError, "下线文章失败")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "文章已下线", "post": post})
+}
+
+// DeletePost 将文章移入回收站
 func (a *API) DeletePost(c *gin.Context) {
 	id, err := parseUintParam(c, "id")
 	if err != nil {
@@ -280,7 +337,7 @@ func (a *API) DeletePost(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "文章删除成功"})
+	c.JSON(http.StatusOK, gin.H{"message": "文章已移入回收站"})
 }
 
 // GeneratePostSummary 使用已配置的 AI 服务生成文章摘要，返回预览内容供人工确认。
@@ -334,6 +391,44 @@ func (a *API) GeneratePostSummary(c *gin.Context) {
 	})
 }
 
+// CheckWikiLinks 解析正文中的内部链接，供编辑器提示无法找到对应文章的链接。
+func (a *API) CheckWikiLinks(c *gin.Context) {
+	var payload struct {
+		Content string `json:"content"`
+	}
+	if !bindJSON(c, &payload, "请求参数不合法") {
+		return
+	}
+
+	links := service.ParseWikiLinks(payload.Content)
+	results := make([]gin.H, 0, len(links))
+	unresolved := make([]string, 0)
+	if len(links) > 0 {
+		index, err := a.posts.WikiLinkIndex()
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "检查内部链接失败")
+			return
+		}
+		seen := make(map[string]bool)
+		for _, link := range links {
+			target, ok := index.Resolve(link.Target)
+			results = append(results, gin.H{
+				"target":   link.Target,
+				"label":    link.Label,
+				"resolved": ok,
+				"title":    target.Title,
+				"url":      target.Path,
+			})
+			if !ok && !seen[link.Target] {
+				seen[link.Target] = true
+				unresolved = append(unresolved, link.Target)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"links": results, "unresolved": unresolved})
+}
+
 // OptimizePostContent 使用 AI 对文章正文进行全文优化，返回优化后的 Markdown。
 func (a *API) OptimizePostContent(c *gin.Context) {
 	if a.optimizer == nil {
@@ -575,6 +670,8 @@ func (a *API) ShowPostList(c *gin.Context) {
 		"totalPages":     list.TotalPages,
 		"publishedCount": list.PublishedCount,
 		"draftCount":     list.DraftCount,
+		"scheduledCount": list.ScheduledCount,
+		"withdrawnCount": list.WithdrawnCount,
 		"pages":          pages,
 		"queryParams":    queryParams,
 		"postStats":      statsMap,
@@ -662,9 +759,15 @@ func (a *API) PreviewPost(c *gin.Context) {
 		Tags:        tags,
 	}
 	preview.PopulateDerivedFields()
+
+	a.renderPostPreview(c, preview, "/admin/posts/preview")
+}
+
+// renderPostPreview 以前台文章详情布局渲染预览内容，并始终标记 noindex。
+func (a *API) renderPostPreview(c *gin.Context, preview *db.PostPublication, canonicalPath string) {
 	preview = clonePublicationForView(preview)
 
-	htmlContent, err := renderMarkdown(preview.Content)
+	htmlContent, _, err := renderMarkdownWithTOC(preview.Content, a.embedOptions(c))
 	if err != nil {
 		a.renderHTML(c, http.StatusInternalServerError, "post_detail.html", gin.H{
 			"title":     "文章预览",
@@ -682,7 +785,6 @@ func (a *API) PreviewPost(c *gin.Context) {
 	site := a.siteSettings(c)
 	description := buildPublicationDescription(preview)
 	tagNames := collectTagNames(preview.Tags)
-	canonicalPath := "/admin/posts/preview"
 	canonicalURL := a.absoluteURL(c, canonicalPath)
 
 	metaImage := ""
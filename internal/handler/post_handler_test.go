@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -98,7 +99,7 @@ func setupTestDB(t *testing.T) (*API, func()) {
 		t.Fatalf("failed to open test database: %v", err)
 	}
 
-	if err := gdb.AutoMigrate(&db.User{}, &db.PostTemplate{}, &db.Post{}, &db.PostDraftVersion{}, &db.PostPublication{}, &db.Tag{}, &db.Page{}, &db.ProfileContact{}, &db.SystemSetting{}); err != nil {
+	if err := gdb.AutoMigrate(&db.User{}, &db.PostTemplate{}, &db.Post{}, &db.PostDraftVersion{}, &db.PostPublication{}, &db.PostSlugRedirect{}, &db.PostPreviewToken{}, &db.Series{}, &db.SeriesPost{}, &db.Tag{}, &db.Page{}, &db.ProfileContact{}, &db.SystemSetting{}, &db.LinkPreview{}, &db.PostLink{}, &db.RelatedPost{}); err != nil {
 		t.Fatalf("failed to migrate test database: %v", err)
 	}
 
@@ -385,6 +386,175 @@ func TestPublishPostDoesNotAutoGenerateSummary(t *testing.T) {
 	}
 }
 
+func TestPublishPostWithFutureTimeSchedules(t *testing.T) {
+	api, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	post := db.Post{
+		Content:     "# 定时发布\n正文",
+		Status:      "draft",
+		UserID:      1,
+		CoverURL:    "https://images.unsplash.com/photo-1500530855697-b586d89ba3ee",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	}
+	if err := db.DB.Create(&post).Error; err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	publishAt := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+	body, _ := json.Marshal(map[string]any{"published_at": publishAt})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/admin/api/posts/%d/publish", post.ID), bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprintf("%d", post.ID)}}
+
+	api.PublishPost(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stored db.Post
+	if err := db.DB.First(&stored, post.ID).Error; err != nil {
+		t.Fatalf("failed to reload post: %v", err)
+	}
+	if stored.Status != "scheduled" || stored.ScheduledAt == nil {
+		t.Fatalf("expected post to be scheduled, got %q (%v)", stored.Status, stored.ScheduledAt)
+	}
+	if stored.LatestPublicationID != nil {
+		t.Fatalf("expected no publication before schedule time")
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/admin/api/posts/%d/schedule", post.ID), nil)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprintf("%d", post.ID)}}
+
+	api.CancelScheduledPost(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected cancel status 200, got %d", w.Code)
+	}
+	var cancelled db.Post
+	if err := db.DB.First(&cancelled, post.ID).Error; err != nil {
+		t.Fatalf("failed to reload post: %v", err)
+	}
+	if cancelled.Status != "draft" || cancelled.ScheduledAt != nil {
+		t.Fatalf("expected schedule to be cancelled, got %q (%v)", cancelled.Status, cancelled.ScheduledAt)
+	}
+}
+
+func TestRepublishPublicationVersionResetsDraft(t *testing.T) {
+	api, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	post := db.Post{
+		Content:     "# 回滚测试\n第一版",
+		Status:      "draft",
+		UserID:      1,
+		CoverURL:    "https://images.unsplash.com/photo-1500530855697-b586d89ba3ee",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	}
+	if err := db.DB.Create(&post).Error; err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	if _, err := api.posts.Publish(post.ID, 1, nil); err != nil {
+		t.Fatalf("failed to publish post: %v", err)
+	}
+	if err := db.DB.Model(&db.Post{}).Where("id = ?", post.ID).Update("content", "# 回滚测试\n改坏了").Error; err != nil {
+		t.Fatalf("failed to edit draft: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/admin/api/posts/%d/publications/1/republish", post.ID), bytes.NewReader([]byte(`{"reset_draft":true}`)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprintf("%d", post.ID)}, {Key: "version", Value: "1"}}
+
+	api.RepublishPublicationVersion(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stored db.Post
+	if err := db.DB.First(&stored, post.ID).Error; err != nil {
+		t.Fatalf("failed to reload post: %v", err)
+	}
+	if stored.Content != "# 回滚测试\n第一版" {
+		t.Fatalf("expected draft to be reset, got %q", stored.Content)
+	}
+	if stored.PublicationCount != 2 {
+		t.Fatalf("expected a new publication version, got %d", stored.PublicationCount)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/admin/api/posts/%d/publications/7", post.ID), nil)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprintf("%d", post.ID)}, {Key: "version", Value: "7"}}
+
+	api.GetPublicationVersion(c)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown version, got %d", w.Code)
+	}
+}
+
+func TestDiffPostVersionsReturnsHunksAndHTML(t *testing.T) {
+	api, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	post := db.Post{
+		Content:     "# 对比测试\n旧的段落",
+		Status:      "draft",
+		UserID:      1,
+		CoverURL:    "https://images.unsplash.com/photo-1500530855697-b586d89ba3ee",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	}
+	if err := db.DB.Create(&post).Error; err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	if _, err := api.posts.Publish(post.ID, 1, nil); err != nil {
+		t.Fatalf("failed to publish post: %v", err)
+	}
+	if err := db.DB.Model(&db.Post{}).Where("id = ?", post.ID).Update("content", "# 对比测试\n新的段落").Error; err != nil {
+		t.Fatalf("failed to edit draft: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/admin/api/posts/%d/diff?from=publication&to=current", post.ID), nil)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprintf("%d", post.ID)}}
+
+	api.DiffPostVersions(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Diff struct {
+			Hunks     []map[string]any `json:"hunks"`
+			Additions int              `json:"additions"`
+			Deletions int              `json:"deletions"`
+		} `json:"diff"`
+		HTML string `json:"html"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Diff.Hunks) != 1 || resp.Diff.Additions != 1 || resp.Diff.Deletions != 1 {
+		t.Fatalf("unexpected diff summary: %+v", resp.Diff)
+	}
+	if !strings.Contains(resp.HTML, "<del>旧</del>") || !strings.Contains(resp.HTML, "<ins>新</ins>") {
+		t.Fatalf("expected CJK word level html, got %s", resp.HTML)
+	}
+}
+
 func TestListDraftVersions(t *testing.T) {
 	api, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -764,10 +934,15 @@ func TestDeletePost(t *testing.T) {
 	}
 
 	var count int64
-	db.DB.Unscoped().Model(&db.Post{}).Where("id = ?", post.ID).Count(&count)
+	db.DB.Model(&db.Post{}).Where("id = ?", post.ID).Count(&count)
 	if count != 0 {
 		t.Fatalf("expected post to be deleted, still found %d records", count)
 	}
+
+	db.DB.Unscoped().Model(&db.Post{}).Where("id = ? AND deleted_at IS NOT NULL", post.ID).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected post to be kept in trash, found %d records", count)
+	}
 }
 
 func TestGeneratePostSummarySuccess(t *testing.T) {
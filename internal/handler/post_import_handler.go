@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportArchiveUploadBytes = 200 << 20 // 200MB
+
+// ImportPosts 接收 Hugo/Hexo/Jekyll 站点的 zip 包并导入其中的文章
+func (a *API) ImportPosts(c *gin.Context) {
+	file, err := c.FormFile("archive")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "请上传 zip 格式的文章压缩包")
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".zip") {
+		respondError(c, http.StatusBadRequest, "仅支持 zip 格式的压缩包")
+		return
+	}
+	if file.Size > maxImportArchiveUploadBytes {
+		respondError(c, http.StatusBadRequest, "压缩包不能超过 200MB")
+		return
+	}
+
+	archive, err := file.Open()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "读取压缩包失败")
+		return
+	}
+	defer archive.Close()
+
+	result, err := a.importer.ImportArchive(archive, file.Size, a.currentUserID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrImportArchiveInvalid) {
+			respondError(c, http.StatusBadRequest, "压缩包格式无效")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "导入文章失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("已导入 %d 篇文章，%d 篇失败", len(result.Imported), len(result.Failed)),
+		"result":  result,
+	})
+}
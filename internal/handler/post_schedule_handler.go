@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type postSchedulePayload struct {
+	ScheduledAt string `json:"scheduled_at"`
+}
+
+// ListScheduledPosts 返回所有待执行的定时发布文章
+func (a *API) ListScheduledPosts(c *gin.Context) {
+	posts, err := a.posts.ListScheduled()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取定时发布列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"posts": posts})
+}
+
+// SchedulePost 设置文章的定时发布时间
+func (a *API) SchedulePost(c *gin.Context) {
+	a.handleSchedule(c, a.posts.SchedulePublish, "已设置定时发布")
+}
+
+// ReschedulePost 调整已有定时发布的时间
+func (a *API) ReschedulePost(c *gin.Context) {
+	a.handleSchedule(c, a.posts.Reschedule, "定时发布时间已更新")
+}
+
+// CancelScheduledPost 取消文章的定时发布
+func (a *API) CancelScheduledPost(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return
+	}
+
+	post, err := a.posts.CancelSchedule(id)
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已取消定时发布", "post": post})
+}
+
+func (a *API) handleSchedule(c *gin.Context, apply func(uint, time.Time, time.Time) (*db.Post, error), message string) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return
+	}
+
+	var payload postSchedulePayload
+	if !bindJSON(c, &payload, "请求参数不合法") {
+		return
+	}
+
+	scheduledAt, err := time.Parse(time.RFC3339, strings.TrimSpace(payload.ScheduledAt))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "定时发布时间格式错误，请使用 RFC3339 格式")
+		return
+	}
+
+	post, err := apply(id, scheduledAt, time.Now())
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "post": post})
+}
+
+func respondScheduleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		respondError(c, http.StatusNotFound, "文章不存在")
+	case errors.Is(err, service.ErrPostNotScheduled):
+		respondError(c, http.StatusNotFound, "文章没有待执行的定时发布")
+	case errors.Is(err, service.ErrScheduleInPast):
+		respondError(c, http.StatusBadRequest, "定时发布时间必须晚于当前时间")
+	case errors.Is(err, service.ErrCoverRequired):
+		respondError(c, http.StatusBadRequest, "请上传文章封面后再发布")
+	case errors.Is(err, service.ErrCoverInvalid):
+		respondError(c, http.StatusBadRequest, "封面尺寸无效，请重新裁剪")
+	case errors.Is(err, service.ErrInvalidPublishState):
+		respondError(c, http.StatusBadRequest, "请完善标题与正文内容后再发布")
+	case errors.Is(err, service.ErrPostPasswordRequired):
+		respondError(c, http.StatusBadRequest, "请为受密码保护的文章设置访问密码")
+	default:
+		respondError(c, http.StatusInternalServerError, "定时发布操作失败")
+	}
+}
@@ -204,7 +204,7 @@ func (a *API) DeletePostTemplate(c *gin.Context) {
 		respondError(c, http.StatusInternalServerError, "删除模板失败")
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "模板删除成功"})
+	c.JSON(http.StatusOK, gin.H{"message": "模板已移入回收站"})
 }
 
 // CreatePostFromTemplate 根据模板创建文章草稿。
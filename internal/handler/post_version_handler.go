@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type republishPayload struct {
+	ResetDraft bool `json:"reset_draft"`
+}
+
+// ListPublications 获取文章的全部发布版本
+func (a *API) ListPublications(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return
+	}
+
+	publications, err := a.posts.ListPublications(id)
+	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			respondError(c, http.StatusNotFound, "文章不存在")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "获取发布版本失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"publications": publications})
+}
+
+// GetPublicationVersion 获取文章指定版本的发布快照
+func (a *API) GetPublicationVersion(c *gin.Context) {
+	id, version, ok := parsePublicationVersionParams(c)
+	if !ok {
+		return
+	}
+
+	publication, err := a.posts.GetPublicationVersion(id, version)
+	if err != nil {
+		respondPublicationVersionError(c, err, "获取发布版本失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"publication": publication})
+}
+
+// RepublishPublicationVersion 以历史版本内容重新发布文章
+func (a *API) RepublishPublicationVersion(c *gin.Context) {
+	id, version, ok := parsePublicationVersionParams(c)
+	if !ok {
+		return
+	}
+
+	var payload republishPayload
+	if c.Request.ContentLength > 0 && !bindJSON(c, &payload, "请求参数不合法") {
+		return
+	}
+
+	publication, err := a.posts.RepublishVersion(id, version, a.currentUserID(c), payload.ResetDraft)
+	if err != nil {
+		respondPublicationVersionError(c, err, "重新发布失败")
+		return
+	}
+
+	post, err := a.posts.Get(id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "发布完成但刷新文章信息失败，请手动刷新页面")
+		return
+	}
+
+	a.queueRemoteContent(publication.Content)
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "已重新发布历史版本",
+		"publication": publication,
+		"post":        post,
+	})
+}
+
+func parsePublicationVersionParams(c *gin.Context) (uint, int, bool) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		respondError(c, http.StatusBadRequest, "无效的版本号")
+		return 0, 0, false
+	}
+	return id, version, true
+}
+
+func respondPublicationVersionError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		respondError(c, http.StatusNotFound, "文章不存在")
+	case errors.Is(err, service.ErrPublicationNotFound):
+		respondError(c, http.StatusNotFound, "发布版本不存在")
+	case errors.Is(err, service.ErrTagNotFound):
+		respondError(c, http.StatusBadRequest, "部分标签不存在")
+	default:
+		respondError(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// DiffPostVersions 比较文章当前草稿、草稿版本与发布版本之间的差异
+func (a *API) DiffPostVersions(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return
+	}
+
+	from := strings.TrimSpace(c.DefaultQuery("from", "publication"))
+	to := strings.TrimSpace(c.DefaultQuery("to", "current"))
+	context := service.DefaultDiffContext
+	if raw := strings.TrimSpace(c.Query("context")); raw != "" {
+		if parsed, parseErr := strconv.Atoi(raw); parseErr == nil && parsed >= 0 {
+			context = parsed
+		}
+	}
+
+	diff, err := a.diffs.ComparePost(id, from, to, context)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDiffRefInvalid):
+			respondError(c, http.StatusBadRequest, "对比版本参数不合法")
+		case errors.Is(err, service.ErrDraftVersionNotFound):
+			respondError(c, http.StatusNotFound, "草稿版本不存在")
+		default:
+			respondPublicationVersionError(c, err, "生成版本对比失败")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"diff": diff,
+		"html": service.RenderDiffHTML(diff.Hunks),
+	})
+}
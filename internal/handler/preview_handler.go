@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type previewLinkPayload struct {
+	ExpiresInHours int    `json:"expires_in_hours"`
+	Note           string `json:"note"`
+}
+
+// previewLinkView 在预览凭证之外附带当前状态，完整链接只在创建时返回
+type previewLinkView struct {
+	db.PostPreviewToken
+	URL    string `json:"url,omitempty"`
+	Active bool   `json:"active"`
+}
+
+func (a *API) buildPreviewLinkView(c *gin.Context, token db.PostPreviewToken, now time.Time) previewLinkView {
+	view := previewLinkView{
+		PostPreviewToken: token,
+		Active:           token.Active(now),
+	}
+	if path := token.PublicPath(); path != "" {
+		view.URL = a.absoluteURL(c, path)
+	}
+	return view
+}
+
+// ListPreviewLinks 返回文章的全部预览链接
+func (a *API) ListPreviewLinks(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return
+	}
+
+	tokens, err := a.previews.List(id)
+	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			respondError(c, http.StatusNotFound, "文章不存在")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "获取预览链接失败")
+		return
+	}
+
+	now := time.Now()
+	views := make([]previewLinkView, 0, len(tokens))
+	for _, token := range tokens {
+		views = append(views, a.buildPreviewLinkView(c, token, now))
+	}
+	c.JSON(http.StatusOK, gin.H{"previews": views})
+}
+
+// CreatePreviewLink 为文章生成可分享的草稿预览链接
+func (a *API) CreatePreviewLink(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return
+	}
+
+	var payload previewLinkPayload
+	if c.Request.ContentLength > 0 && !bindJSON(c, &payload, "无效的请求参数") {
+		return
+	}
+
+	token, err := a.previews.Create(id, service.PreviewTokenInput{
+		TTL:  time.Duration(payload.ExpiresInHours) * time.Hour,
+		Note: payload.Note,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPostNotFound):
+			respondError(c, http.StatusNotFound, "文章不存在")
+		case errors.Is(err, service.ErrPreviewTTLInvalid):
+			respondError(c, http.StatusBadRequest, "预览链接有效期需在 30 天以内")
+		default:
+			respondError(c, http.StatusInternalServerError, "创建预览链接失败")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "预览链接已创建",
+		"preview": a.buildPreviewLinkView(c, *token, time.Now()),
+	})
+}
+
+// RevokePreviewLink 撤销预览链接，已分享的地址随即失效
+func (a *API) RevokePreviewLink(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的文章ID")
+		return
+	}
+	previewID, err := parseUintParam(c, "previewID")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的预览链接ID")
+		return
+	}
+
+	token, err := a.previews.Revoke(id, previewID)
+	if err != nil {
+		if errors.Is(err, service.ErrPreviewTokenNotFound) {
+			respondError(c, http.StatusNotFound, "预览链接不存在")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "撤销预览链接失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "预览链接已撤销",
+		"preview": a.buildPreviewLinkView(c, *token, time.Now()),
+	})
+}
+
+// ShowSharedPreview 通过预览凭证渲染文章当前草稿，无需登录
+func (a *API) ShowSharedPreview(c *gin.Context) {
+	token := c.Param("token")
+	post, err := a.previews.Resolve(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPreviewTokenNotFound):
+			c.AbortWithStatus(http.StatusNotFound)
+		case errors.Is(err, service.ErrPreviewTokenExpired):
+			a.renderHTML(c, http.StatusGone, "error.html", gin.H{
+				"title":         "410 " + http.StatusText(http.StatusGone),
+				"status":        http.StatusGone,
+				"statusText":    http.StatusText(http.StatusGone),
+				"headline":      "预览链接已失效",
+				"description":   "该预览链接已过期或被作者撤销，请联系作者获取新的链接。",
+				"primaryAction": gin.H{"Label": "返回首页", "Href": "/"},
+				"noindex":       true,
+				"year":          time.Now().Year(),
+			})
+			c.Abort()
+		default:
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	publishedAt := post.PublishedAt
+	if publishedAt.IsZero() {
+		publishedAt = time.Now()
+	}
+	preview := &db.PostPublication{
+		Model: gorm.Model{
+			CreatedAt: post.CreatedAt,
+			UpdatedAt: post.UpdatedAt,
+		},
+		PostID:      post.ID,
+		PostSlug:    post.Slug,
+		Content:     post.Content,
+		Summary:     post.Summary,
+		ReadingTime: post.ReadingTime,
+		CoverURL:    post.CoverURL,
+		CoverWidth:  post.CoverWidth,
+		CoverHeight: post.CoverHeight,
+		UserID:      post.UserID,
+		User:        post.User,
+		PublishedAt: publishedAt,
+		Tags:        post.Tags,
+	}
+	preview.PopulateDerivedFields()
+
+	// 预览地址本身即凭证，禁止索引并避免通过 Referer 泄露给外链站点
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Referrer-Policy", "no-referrer")
+	a.renderPostPreview(c, preview, "/preview/"+token)
+}
@@ -21,21 +21,28 @@ import (
 	"github.com/google/uuid"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
 )
 
 var (
-	markdownEngine = goldmark.New(
-		goldmark.WithExtensions(extension.GFM, extension.Linkify, extension.Table),
-		goldmark.WithRendererOptions(html.WithHardWraps(), html.WithXHTML(), html.WithUnsafe()),
-	)
+	markdownEngine = newMarkdownEngine(&codeHighlighter{})
 	sanitizer      = buildContentSanitizer()
-	htmlTagPattern = regexp.MustCompile(`<[^>]+>`)
+	// feedMarkdownEngine 为订阅输出内联代码配色，图表以文字代替
+	feedMarkdownEngine = newMarkdownEngine(&codeHighlighter{inlineStyles: true, diagramFallback: true})
+	// plainMarkdownEngine 用于提取纯文本摘要与搜索片段
+	plainMarkdownEngine = newMarkdownEngine(&codeHighlighter{diagramFallback: true})
+	feedSanitizer       = buildFeedSanitizer()
+	htmlTagPattern      = regexp.MustCompile(`<[^>]+>`)
 )
 
 const (
 	visitorCookieName   = "cl_visitor_id"
 	visitorCookieMaxAge = 365 * 24 * 60 * 60
+
+	postUnlockCookiePrefix = "cl_post_unlock_"
+	// postUnlockCookiePath 不随 slug 变化，Cookie 以文章 ID 命名区分各篇文章
+	postUnlockCookiePath = "/posts"
 )
 
 type tagStat struct {
@@ -44,6 +51,12 @@ type tagStat struct {
 	Count       int
 }
 
+// searchFilterChip 是首页展示的一个搜索条件，点击后移除该条件。
+type searchFilterChip struct {
+	Label     string
+	RemoveURL string
+}
+
 type searchSuggestion struct {
 	Title   template.HTML
 	Snippet template.HTML
@@ -103,6 +116,8 @@ func (a *API) ShowHome(c *gin.Context) {
 		canonical = "/"
 	}
 
+	searchFilters := buildSearchFilterChips(service.ParseSearchQuery(search, false), tags)
+
 	payload := gin.H{
 		"title":       "首页",
 		"search":      search,
@@ -127,6 +142,9 @@ func (a *API) ShowHome(c *gin.Context) {
 	if canonical != "" {
 		payload["canonical"] = canonical
 	}
+	if len(searchFilters) > 0 {
+		payload["searchFilters"] = searchFilters
+	}
 
 	a.renderHTML(c, http.StatusOK, "home.html", payload)
 }
@@ -154,13 +172,19 @@ func (a *API) SearchSuggestions(c *gin.Context) {
 		return
 	}
 
+	// 高亮与截取片段只使用关键词，不包含 tag: 等筛选条件
+	keyword := service.ParseSearchQuery(search, false).Text()
 	suggestions := make([]searchSuggestion, 0, len(publications.Publications))
 	for _, publication := range publications.Publications {
-		snippet := buildSearchSnippet(&publication, search, 120)
+		// 全文索引可用时直接使用 FTS 截取的片段，否则按关键词截取正文
+		snippet := highlightSearchSnippet(publication.SearchSnippet)
+		if publication.SearchSnippet == "" {
+			snippet = highlightMatches(buildSearchSnippet(&publication, keyword, 120), keyword)
+		}
 		suggestions = append(suggestions, searchSuggestion{
-			Title:   highlightMatches(publication.Title, search),
-			Snippet: highlightMatches(snippet, search),
-			URL:     fmt.Sprintf("/posts/%d", publication.PostID),
+			Title:   highlightMatches(publication.Title, keyword),
+			Snippet: snippet,
+			URL:     publication.PublicPath(),
 		})
 	}
 
@@ -222,14 +246,22 @@ func (a *API) LoadMorePosts(c *gin.Context) {
 
 // ShowPostDetail renders specific post with markdown content.
 func (a *API) ShowPostDetail(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	ref, err := a.posts.ResolvePostRef(c.Param("slug"))
 	if err != nil {
-		c.AbortWithStatus(http.StatusNotFound)
+		if errors.Is(err, service.ErrPostNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	publication, err := a.posts.LatestPublication(uint(id))
+	publication, err := a.posts.PublicPublication(ref.PostID)
 	if err != nil {
+		if errors.Is(err, service.ErrPostWithdrawn) {
+			a.renderWithdrawnPost(c)
+			return
+		}
 		if errors.Is(err, service.ErrPublicationNotFound) {
 			c.AbortWithStatus(http.StatusNotFound)
 			return
@@ -238,8 +270,23 @@ func (a *API) ShowPostDetail(c *gin.Context) {
 		return
 	}
 
+	// 数字 ID 与历史 slug 永久重定向到当前规范地址
+	if !ref.Canonical {
+		target := publication.PublicPath()
+		if rawQuery := c.Request.URL.RawQuery; rawQuery != "" {
+			target += "?" + rawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, target)
+		return
+	}
+
 	postID := publication.PostID
 
+	if publication.IsProtected() && !a.postUnlocked(c, postID) {
+		a.renderLockedPost(c, http.StatusOK, publication, "")
+		return
+	}
+
 	visitorID := a.ensureVisitorID(c)
 
 	var (
@@ -260,7 +307,7 @@ func (a *API) ShowPostDetail(c *gin.Context) {
 
 	publication = clonePublicationForView(publication)
 
-	htmlContent, err := renderMarkdown(publication.Content)
+	htmlContent, toc, err := renderMarkdownWithTOC(publication.Content, a.embedOptions(c))
 	if err != nil {
 		a.renderHTML(c, http.StatusInternalServerError, "post_detail.html", gin.H{
 			"title":    "文章详情",
@@ -273,7 +320,7 @@ func (a *API) ShowPostDetail(c *gin.Context) {
 	site := a.siteSettings(c)
 	description := buildPublicationDescription(publication)
 	tagNames := collectTagNames(publication.Tags)
-	canonicalPath := fmt.Sprintf("/posts/%d", publication.PostID)
+	canonicalPath := publication.PublicPath()
 	canonicalURL := a.absoluteURL(c, canonicalPath)
 
 	metaImage := ""
@@ -303,6 +350,7 @@ func (a *API) ShowPostDetail(c *gin.Context) {
 		"title":           publication.Title,
 		"post":            publication,
 		"content":         htmlContent,
+		"toc":             toc,
 		"contacts":        contacts,
 		"pageViews":       pageViews,
 		"uniqueVisitors":  uniqueVisitors,
@@ -312,7 +360,25 @@ func (a *API) ShowPostDetail(c *gin.Context) {
 		"metaModifiedAt":  modifiedAt,
 		"canonical":       canonicalPath,
 	}
-	if db.NormalizePostVisibility(publication.Visibility) == db.PostVisibilityUnlisted {
+	if a.series != nil {
+		if nav, navErr := a.series.NavigationForPost(publication.PostID); navErr == nil && nav != nil {
+			payload["seriesNav"] = nav
+		} else if navErr != nil {
+			c.Error(navErr)
+		}
+	}
+	if backlinks, backlinkErr := a.posts.ListBacklinks(publication.PostID); backlinkErr == nil && len(backlinks) > 0 {
+		payload["backlinks"] = backlinks
+	} else if backlinkErr != nil {
+		c.Error(backlinkErr)
+	}
+	if related, relatedErr := a.relatedPublications(publication.PostID); relatedErr == nil && len(related) > 0 {
+		payload["relatedPosts"] = related
+	} else if relatedErr != nil {
+		c.Error(relatedErr)
+	}
+	switch db.NormalizePostVisibility(publication.Visibility) {
+	case db.PostVisibilityUnlisted, db.PostVisibilityProtected:
 		payload["noindex"] = true
 	}
 	if description != "" {
@@ -331,6 +397,99 @@ func (a *API) ShowPostDetail(c *gin.Context) {
 	a.renderHTML(c, http.StatusOK, "post_detail.html", payload)
 }
 
+// UnlockPost 校验受保护文章的访问密码，通过后写入仅作用于该文章的签名 Cookie。
+func (a *API) UnlockPost(c *gin.Context) {
+	ref, err := a.posts.ResolvePostRef(c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	publication, err := a.posts.PublicPublication(ref.PostID)
+	if err != nil {
+		if errors.Is(err, service.ErrPostWithdrawn) {
+			a.renderWithdrawnPost(c)
+			return
+		}
+		if errors.Is(err, service.ErrPublicationNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	canonicalPath := publication.PublicPath()
+	if !publication.IsProtected() {
+		c.Redirect(http.StatusSeeOther, canonicalPath)
+		return
+	}
+
+	token, err := a.posts.UnlockProtected(publication.PostID, c.ClientIP(), c.PostForm("password"))
+	if err != nil {
+		if errors.Is(err, service.ErrPostUnlockThrottled) {
+			a.renderLockedPost(c, http.StatusTooManyRequests, publication, "尝试次数过多，请稍后再试")
+			return
+		}
+		if errors.Is(err, service.ErrPostPasswordIncorrect) || errors.Is(err, service.ErrPostPasswordRequired) {
+			a.renderLockedPost(c, http.StatusUnauthorized, publication, "密码不正确，请重试")
+			return
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     postUnlockCookieName(publication.PostID),
+		Value:    token,
+		Path:     postUnlockCookiePath,
+		HttpOnly: true,
+		Secure:   strings.EqualFold(a.detectScheme(c), "https"),
+		MaxAge:   int(service.PostUnlockTTL / time.Second),
+		Expires:  time.Now().Add(service.PostUnlockTTL),
+		SameSite: http.SameSiteLaxMode,
+	})
+	c.Redirect(http.StatusSeeOther, canonicalPath)
+}
+
+func postUnlockCookieName(postID uint) string {
+	return postUnlockCookiePrefix + strconv.FormatUint(uint64(postID), 10)
+}
+
+// postUnlocked 判断访客是否持有该文章的有效解锁 Cookie
+func (a *API) postUnlocked(c *gin.Context, postID uint) bool {
+	token, err := c.Cookie(postUnlockCookieName(postID))
+	if err != nil {
+		return false
+	}
+	return a.posts.VerifyUnlockToken(postID, token)
+}
+
+// renderLockedPost 渲染受保护文章的解锁表单，只展示标题与摘要
+func (a *API) renderLockedPost(c *gin.Context, status int, publication *db.PostPublication, errMessage string) {
+	payload := gin.H{
+		"title":     publication.Title,
+		"post":      publication,
+		"canonical": publication.PublicPath(),
+		"noindex":   true,
+		"year":      time.Now().Year(),
+		"contacts":  a.visibleContacts(c),
+	}
+	if a.staticExport {
+		payload["staticExport"] = true
+	} else {
+		payload["unlockURL"] = publication.PublicPath() + "/unlock"
+	}
+	if errMessage != "" {
+		payload["error"] = errMessage
+	}
+	a.renderHTML(c, status, "post_locked.html", payload)
+}
+
 // ShowTagArchive lists tags and related published post counts.
 func (a *API) ShowTagArchive(c *gin.Context) {
 	stats := a.buildTagStats()
@@ -412,7 +571,7 @@ func (a *API) ShowAbout(c *gin.Context) {
 		return
 	}
 
-	htmlContent, err := renderMarkdown(page.Content)
+	htmlContent, _, err := renderMarkdownWithTOC(page.Content, a.embedOptions(c))
 	if err != nil {
 		htmlContent = template.HTML("<p class=\"text-sm text-slate-600\">内容暂时无法展示。</p>")
 	}
@@ -444,6 +603,7 @@ func (a *API) ShowRobots(c *gin.Context) {
 		"User-agent: *",
 		"Allow: /",
 		"Disallow: /admin/",
+		"Disallow: /preview/",
 	}
 
 	uploadPath := strings.TrimSpace(a.uploadURL)
@@ -488,6 +648,9 @@ func (a *API) ShowSitemap(c *gin.Context) {
 	}
 
 	for _, publication := range publications {
+		if publication.IsProtected() {
+			continue
+		}
 		lastMod := publication.UpdatedAt
 		if lastMod.IsZero() {
 			lastMod = publication.PublishedAt
@@ -496,13 +659,26 @@ func (a *API) ShowSitemap(c *gin.Context) {
 			lastMod = publication.CreatedAt
 		}
 		entries = append(entries, sitemapEntry{
-			Loc:        a.absoluteURL(c, fmt.Sprintf("/posts/%d", publication.PostID)),
+			Loc:        a.absoluteURL(c, publication.PublicPath()),
 			LastMod:    lastMod.UTC().Format(time.RFC3339),
 			ChangeFreq: "weekly",
 			Priority:   "0.7",
 		})
 	}
 
+	if seriesList, seriesErr := a.series.ListPublished(); seriesErr == nil {
+		for _, series := range seriesList {
+			entries = append(entries, sitemapEntry{
+				Loc:        a.absoluteURL(c, series.PublicPath()),
+				LastMod:    series.UpdatedAt.UTC().Format(time.RFC3339),
+				ChangeFreq: "weekly",
+				Priority:   "0.6",
+			})
+		}
+	} else {
+		c.Error(seriesErr)
+	}
+
 	var builder strings.Builder
 	builder.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
 	builder.WriteString("<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n")
@@ -558,7 +734,7 @@ func (a *API) ShowRSS(c *gin.Context) {
 	builder.WriteString("  <generator>CommitLog</generator>\n")
 
 	for _, publication := range publications {
-		itemLink := a.absoluteURL(c, fmt.Sprintf("/posts/%d", publication.PostID))
+		itemLink := a.absoluteURL(c, publication.PublicPath())
 		summary := buildPublicationDescription(&publication)
 		pubDate := publication.PublishedAt
 		if pubDate.IsZero() {
@@ -576,9 +752,17 @@ func (a *API) ShowRSS(c *gin.Context) {
 		if summary != "" {
 			builder.WriteString(fmt.Sprintf("    <description>%s</description>\n", htmlstd.EscapeString(summary)))
 		}
+		if publication.IsProtected() {
+			// 受密码保护的文章只输出标题与摘要，不在订阅中暴露正文
+			if !pubDate.IsZero() {
+				builder.WriteString(fmt.Sprintf("    <pubDate>%s</pubDate>\n", pubDate.UTC().Format(time.RFC1123Z)))
+			}
+			builder.WriteString("  </item>\n")
+			continue
+		}
 		contentSource := stripLeadingTitle(publication.Title, publication.Content)
 		contentEncoded := ""
-		if htmlContent, err := renderMarkdown(contentSource); err == nil {
+		if htmlContent, err := renderFeedMarkdown(contentSource); err == nil {
 			contentEncoded = strings.TrimSpace(string(htmlContent))
 		}
 		if contentEncoded == "" {
@@ -669,14 +853,22 @@ func stripLeadingTitle(title, content string) string {
 	return strings.Join(lines[index:], "\n")
 }
 
-func renderMarkdown(content string) (template.HTML, error) {
-	var buf bytes.Buffer
-	normalized := applyVideoEmbeds(content)
-	if err := markdownEngine.Convert([]byte(normalized), &buf); err != nil {
-		return "", err
+func newMarkdownEngine(highlighter *codeHighlighter) goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(extension.GFM, extension.Linkify, extension.Table, &mathExtension{}, &wikiLinkExtension{}, highlighter),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(html.WithHardWraps(), html.WithXHTML(), html.WithUnsafe()),
+	)
+}
+
+// embedOptions 按系统设置决定正文嵌入是否使用点击加载的轻量模式，并附带链接卡片缓存与内部链接解析。
+func (a *API) embedOptions(c *gin.Context) embedRenderOptions {
+	options := embedRenderOptions{LinkPreviews: a.linkPreviews, WikiLinks: a.posts}
+	if a.siteSettings(c).LiteEmbeds {
+		options.Lite = true
+		options.Thumbnails = a.thumbnails
 	}
-	safe := sanitizer.SanitizeBytes(buf.Bytes())
-	return template.HTML(safe), nil
+	return options
 }
 
 func (a *API) visibleContacts(c *gin.Context) []db.ProfileContact {
@@ -705,7 +897,7 @@ func (a *API) buildTagStats() []tagStat {
 }
 
 func buildSearchSnippet(publication *db.PostPublication, keyword string, limit int) string {
-	if publication == nil {
+	if publication == nil || publication.IsProtected() {
 		return ""
 	}
 
@@ -785,7 +977,7 @@ func snippetAroundKeyword(text, keyword string, limit int) string {
 
 func markdownToPlainText(content string) string {
 	var buf bytes.Buffer
-	if err := markdownEngine.Convert([]byte(content), &buf); err != nil {
+	if err := plainMarkdownEngine.Convert([]byte(content), &buf); err != nil {
 		return strings.TrimSpace(content)
 	}
 	safe := sanitizer.Sanitize(buf.String())
@@ -794,6 +986,9 @@ func markdownToPlainText(content string) string {
 	return strings.Join(strings.Fields(unescaped), " ")
 }
 
+// searchMarkOpenTag 是搜索结果中高亮命中文字的标签。
+const searchMarkOpenTag = `<mark class="rounded bg-amber-200/80 px-1 text-slate-900 dark:bg-amber-400/30 dark:text-amber-100">`
+
 func highlightMatches(text, keyword string) template.HTML {
 	escaped := htmlstd.EscapeString(text)
 	trimmedKeyword := strings.TrimSpace(keyword)
@@ -807,11 +1002,19 @@ func highlightMatches(text, keyword string) template.HTML {
 	}
 
 	highlighted := pattern.ReplaceAllStringFunc(escaped, func(match string) string {
-		return `<mark class="rounded bg-amber-200/80 px-1 text-slate-900 dark:bg-amber-400/30 dark:text-amber-100">` + match + `</mark>`
+		return searchMarkOpenTag + match + `</mark>`
 	})
 	return template.HTML(highlighted)
 }
 
+// highlightSearchSnippet 将全文检索片段中的命中标记转换为高亮标签，其余内容转义输出。
+func highlightSearchSnippet(snippet string) template.HTML {
+	escaped := htmlstd.EscapeString(strings.TrimSpace(snippet))
+	escaped = strings.ReplaceAll(escaped, service.SearchHighlightStart, searchMarkOpenTag)
+	escaped = strings.ReplaceAll(escaped, service.SearchHighlightEnd, `</mark>`)
+	return template.HTML(escaped)
+}
+
 func truncateRunes(text string, limit int) string {
 	trimmed := strings.TrimSpace(text)
 	if limit <= 0 {
@@ -842,6 +1045,9 @@ func buildPublicationDescription(publication *db.PostPublication) string {
 	if summary := strings.TrimSpace(publication.Summary); summary != "" {
 		return truncateRunes(summary, 160)
 	}
+	if publication.IsProtected() {
+		return ""
+	}
 	return truncateRunes(markdownToPlainText(publication.Content), 160)
 }
 
@@ -916,8 +1122,10 @@ func buildPublicationJSONLD(publication *db.PostPublication, canonicalURL, siteN
 		data["dateModified"] = updated.UTC().Format(time.RFC3339)
 	}
 
-	if body := markdownToPlainText(publication.Content); body != "" {
-		data["articleBody"] = body
+	if !publication.IsProtected() {
+		if body := markdownToPlainText(publication.Content); body != "" {
+			data["articleBody"] = body
+		}
 	}
 
 	encoded, err := json.Marshal(data)
@@ -944,6 +1152,36 @@ func buildQueryParams(search string, tags []string) string {
 	return "&" + encoded
 }
 
+// buildSearchFilterChips 将搜索语句中的筛选条件转换为可单独移除的标签，普通关键词不单独展示。
+func buildSearchFilterChips(query service.SearchQuery, tags []string) []searchFilterChip {
+	chips := make([]searchFilterChip, 0, len(query.Clauses))
+	for i, clause := range query.Clauses {
+		var label string
+		switch clause.Kind {
+		case service.SearchClausePhrase:
+			label = "短语：“" + clause.Value + "”"
+		case service.SearchClauseExclude:
+			label = "排除：" + clause.Value
+		case service.SearchClauseTag:
+			label = "标签：" + clause.Value
+		case service.SearchClauseBefore:
+			label = "早于 " + clause.Value
+		case service.SearchClauseAfter:
+			label = "晚于 " + clause.Value
+		case service.SearchClauseYear:
+			label = clause.Value + " 年"
+		default:
+			continue
+		}
+		removeURL := "/"
+		if params := buildQueryParams(query.Without(i), tags); params != "" {
+			removeURL = "/?" + strings.TrimPrefix(params, "&")
+		}
+		chips = append(chips, searchFilterChip{Label: label, RemoveURL: removeURL})
+	}
+	return chips
+}
+
 func parsePositiveInt(value string, fallback int) int {
 	num, err := strconv.Atoi(value)
 	if err != nil || num <= 0 {
@@ -951,3 +1189,17 @@ func parsePositiveInt(value string, fallback int) int {
 	}
 	return num
 }
+
+// renderWithdrawnPost 以 410 告知访客与爬虫文章已被作者下线
+func (a *API) renderWithdrawnPost(c *gin.Context) {
+	a.renderHTML(c, http.StatusGone, "error.html", gin.H{
+		"title":         "410 " + http.StatusText(http.StatusGone),
+		"status":        http.StatusGone,
+		"statusText":    http.StatusText(http.StatusGone),
+		"headline":      "文章已下线",
+		"description":   "作者已撤回这篇文章，它不再公开提供访问。",
+		"primaryAction": gin.H{"Label": "返回首页", "Href": "/"},
+		"year":          time.Now().Year(),
+	})
+	c.Abort()
+}
@@ -1,9 +1,11 @@
 package handler_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -11,9 +13,11 @@ import (
 	"time"
 
 	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/handler"
 	"github.com/commitlog/internal/router"
 	"github.com/commitlog/internal/service"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -39,6 +43,10 @@ func setupPublicTestDB(t *testing.T) func() {
 		&db.Post{},
 		&db.PostDraftVersion{},
 		&db.PostPublication{},
+		&db.PostSlugRedirect{},
+		&db.PostPreviewToken{},
+		&db.Series{},
+		&db.SeriesPost{},
 		&db.Tag{},
 		&db.Page{},
 		&db.ProfileContact{},
@@ -47,6 +55,9 @@ func setupPublicTestDB(t *testing.T) func() {
 		&db.SiteHourlySnapshot{},
 		&db.SiteHourlyVisitor{},
 		&db.SystemSetting{},
+		&db.LinkPreview{},
+		&db.PostLink{},
+		&db.RelatedPost{},
 	); err != nil {
 		t.Fatalf("failed to migrate database: %v", err)
 	}
@@ -307,6 +318,29 @@ func TestSearchSuggestionsExcludeUnlistedPosts(t *testing.T) {
 	}
 }
 
+func TestShowPostDetailRendersHeadingAnchors(t *testing.T) {
+	cleanup := setupPublicTestDB(t)
+	defer cleanup()
+
+	post := seedPublishedPost(t, "Anchors", "# Anchors\n\n## 安装步骤\n正文\n\n### 配置 **选项**\n")
+
+	r := router.SetupRouter("test-secret", "web/static/uploads", "/static/uploads", "")
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/posts/"+strconv.Itoa(int(post.ID)), nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `<h2 id="安装步骤">`) {
+		t.Fatalf("expected heading to keep its anchor after sanitizing, got:\n%s", body)
+	}
+	if !strings.Contains(body, `data-toc-target="配置-选项"`) {
+		t.Fatal("expected server rendered table of contents")
+	}
+}
+
 func TestShowPostDetailRejectsDraft(t *testing.T) {
 	cleanup := setupPublicTestDB(t)
 	defer cleanup()
@@ -323,6 +357,173 @@ func TestShowPostDetailRejectsDraft(t *testing.T) {
 	}
 }
 
+func TestWithdrawnPostReturnsGoneAndLeavesFeeds(t *testing.T) {
+	cleanup := setupPublicTestDB(t)
+	defer cleanup()
+
+	kept := seedPublishedPost(t, "Kept Engineering", "# Kept Engineering\n内容")
+	withdrawn := seedPublishedPost(t, "Withdrawn Engineering", "# Withdrawn Engineering\n内容")
+	if err := db.DB.Model(&db.Post{}).Where("id = ?", withdrawn.ID).Update("status", "withdrawn").Error; err != nil {
+		t.Fatalf("failed to withdraw post: %v", err)
+	}
+
+	r := router.SetupRouter("test-secret", "web/static/uploads", "/static/uploads", "")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/posts/"+strconv.Itoa(int(withdrawn.ID)), nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected 410 for withdrawn post, got %d", w.Code)
+	}
+
+	keptURL := fmt.Sprintf("/posts/%d", kept.ID)
+	withdrawnURL := fmt.Sprintf("/posts/%d", withdrawn.ID)
+	for _, path := range []string{"/rss.xml", "/sitemap.xml", "/search/suggestions?search=Engineering"} {
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, path, nil)
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200 for %s, got %d", path, w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, keptURL) {
+			t.Fatalf("expected published post in %s", path)
+		}
+		if strings.Contains(body, withdrawnURL) {
+			t.Fatalf("expected withdrawn post to be excluded from %s", path)
+		}
+	}
+}
+
+func TestProtectedPostRequiresUnlockAndHidesContent(t *testing.T) {
+	cleanup := setupPublicTestDB(t)
+	defer cleanup()
+
+	post := seedPublishedPost(t, "Locked Engineering", "# Locked Engineering\n机密正文内容")
+	hash, err := bcrypt.GenerateFromPassword([]byte("letmein"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := db.DB.Model(&db.Post{}).Where("id = ?", post.ID).
+		Updates(map[string]any{"visibility": db.PostVisibilityProtected, "password_hash": string(hash)}).Error; err != nil {
+		t.Fatalf("failed to protect post: %v", err)
+	}
+	if err := db.DB.Model(&db.PostPublication{}).Where("post_id = ?", post.ID).
+		Update("visibility", db.PostVisibilityProtected).Error; err != nil {
+		t.Fatalf("failed to protect publication: %v", err)
+	}
+
+	r := router.SetupRouter("test-secret", "web/static/uploads", "/static/uploads", "")
+	postURL := fmt.Sprintf("/posts/%d", post.ID)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, postURL, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected locked page to render, got %d", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "机密正文内容") || !strings.Contains(body, postURL+"/unlock") {
+		t.Fatalf("expected unlock form without content")
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, postURL+"/unlock", strings.NewReader("password=wrong"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for wrong password, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, postURL+"/unlock", strings.NewReader("password=letmein"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect after unlock, got %d", w.Code)
+	}
+	var unlockCookie *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if strings.HasPrefix(cookie.Name, "cl_post_unlock_") {
+			unlockCookie = cookie
+		}
+	}
+	// Cookie 以文章 ID 命名，路径不随 slug 变化
+	if unlockCookie == nil || unlockCookie.Path != "/posts" || !unlockCookie.HttpOnly || !strings.HasSuffix(unlockCookie.Name, "_"+strconv.Itoa(int(post.ID))) {
+		t.Fatalf("expected unlock cookie keyed by post id, got %+v", unlockCookie)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, postURL, nil)
+	req.AddCookie(unlockCookie)
+	r.ServeHTTP(w, req)
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "机密正文内容") {
+		t.Fatalf("expected unlocked content, got %d", w.Code)
+	}
+	if strings.Contains(body, "articleBody") {
+		t.Fatalf("expected JSON-LD to omit article body for protected post")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rss.xml", nil))
+	if body := w.Body.String(); !strings.Contains(body, postURL) || strings.Contains(body, "机密正文内容") {
+		t.Fatalf("expected RSS to list protected post without content")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	if strings.Contains(w.Body.String(), postURL) {
+		t.Fatalf("expected protected post to be excluded from sitemap")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := w.Body.String(); !strings.Contains(body, "Locked Engineering") || !strings.Contains(body, "🔒") {
+		t.Fatalf("expected protected post in home list with lock badge")
+	}
+}
+
+func TestSharedPreviewRendersDraftWithNoindex(t *testing.T) {
+	cleanup := setupPublicTestDB(t)
+	defer cleanup()
+
+	draft := seedDraftPost(t, "Shared Draft")
+	previews := service.NewPreviewService(db.DB)
+	token, err := previews.Create(draft.ID, service.PreviewTokenInput{})
+	if err != nil {
+		t.Fatalf("failed to create preview token: %v", err)
+	}
+
+	r := router.SetupRouter("test-secret", "web/static/uploads", "/static/uploads", "")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, token.PublicPath(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected preview to render, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "草稿内容") {
+		t.Fatalf("expected draft content in preview")
+	}
+	if !strings.Contains(body, `name="robots" content="noindex,follow"`) || w.Header().Get("X-Robots-Tag") == "" {
+		t.Fatalf("expected preview to be excluded from indexing")
+	}
+
+	if _, err := previews.Revoke(draft.ID, token.ID); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, token.PublicPath(), nil))
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected revoked preview to return 410, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/preview/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown preview to return 404, got %d", w.Code)
+	}
+}
+
 func TestShowPostDetailAllowsUnlistedAndSetsNoindex(t *testing.T) {
 	cleanup := setupPublicTestDB(t)
 	defer cleanup()
@@ -348,6 +549,160 @@ func TestShowPostDetailAllowsUnlistedAndSetsNoindex(t *testing.T) {
 	}
 }
 
+func TestShowPostDetailRedirectsToCanonicalSlug(t *testing.T) {
+	cleanup := setupPublicTestDB(t)
+	defer cleanup()
+
+	post := seedPublishedPost(t, "Slug Detail", "# Slug Detail\n正文")
+	if err := db.DB.Model(&post).Update("slug", "slug-detail").Error; err != nil {
+		t.Fatalf("failed to set slug: %v", err)
+	}
+	if err := db.DB.Create(&db.PostSlugRedirect{PostID: post.ID, Slug: "old-slug"}).Error; err != nil {
+		t.Fatalf("failed to seed redirect: %v", err)
+	}
+
+	r := router.SetupRouter("test-secret", "web/static/uploads", "/static/uploads", "")
+	for _, path := range []string{"/posts/" + strconv.Itoa(int(post.ID)), "/posts/old-slug"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path+"?ref=rss", nil)
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusMovedPermanently {
+			t.Fatalf("expected 301 for %s, got %d", path, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != "/posts/slug-detail?ref=rss" {
+			t.Fatalf("expected redirect to canonical slug for %s, got %q", path, location)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/posts/slug-detail", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for canonical slug, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `/posts/slug-detail" />`) {
+		t.Fatalf("expected canonical link to use slug")
+	}
+}
+
+func TestShowSeriesAndPostDetailNavigation(t *testing.T) {
+	cleanup := setupPublicTestDB(t)
+	defer cleanup()
+
+	first := seedPublishedPost(t, "Series Part One", "# Series Part One\n正文")
+	draft := seedDraftPost(t, "Series Draft Part")
+	second := seedPublishedPost(t, "Series Part Two", "# Series Part Two\n正文")
+
+	series := db.Series{Title: "Handler Series", Slug: "handler-series"}
+	if err := db.DB.Create(&series).Error; err != nil {
+		t.Fatalf("failed to create series: %v", err)
+	}
+	for idx, postID := range []uint{first.ID, draft.ID, second.ID} {
+		if err := db.DB.Create(&db.SeriesPost{SeriesID: series.ID, PostID: postID, Position: idx}).Error; err != nil {
+			t.Fatalf("failed to add series member: %v", err)
+		}
+	}
+
+	r := router.SetupRouter("test-secret", "web/static/uploads", "/static/uploads", "")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/series/handler-series", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected series page status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Series Part One") || !strings.Contains(body, "Series Part Two") {
+		t.Fatalf("expected series page to list published parts")
+	}
+	if strings.Contains(body, "Series Draft Part") {
+		t.Fatalf("expected series page to skip unpublished parts")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/"+strconv.Itoa(int(second.ID)), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected post detail status 200, got %d", w.Code)
+	}
+	body = w.Body.String()
+	if !strings.Contains(body, "第 2 篇，共 2 篇") {
+		t.Fatalf("expected series position in post detail")
+	}
+	if !strings.Contains(body, `href="/posts/`+strconv.Itoa(int(first.ID))+`"`) {
+		t.Fatalf("expected previous link to first part")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/series/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown series, got %d", w.Code)
+	}
+}
+
+func TestShowPostDetailListsRelatedPosts(t *testing.T) {
+	cleanup := setupPublicTestDB(t)
+	defer cleanup()
+
+	source := seedPublishedPost(t, "Go 并发编程", "# Go 并发编程\n使用 goroutine 与 channel 编写并发程序。")
+	related := seedPublishedPost(t, "Channel 并发模式", "# Channel 并发模式\n通过 goroutine 与 channel 实现并发模式。")
+	seedPublishedPost(t, "周末烘焙记录", "# 周末烘焙记录\n面包、黄油与面粉的比例。")
+
+	r := router.SetupRouter("test-secret", "web/static/uploads", "/static/uploads", "")
+
+	// 页面渲染只读取已保存的推荐，不会自行计算
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/"+strconv.Itoa(int(source.ID)), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected post detail status 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "相关文章") {
+		t.Fatalf("expected no related posts before the background refresh")
+	}
+
+	if !service.NewRelatedPostRefresher(handler.NewRelatedPostService(db.DB), 0).RunOnce() {
+		t.Fatalf("expected related posts to be computed")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/"+strconv.Itoa(int(source.ID)), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected post detail status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "相关文章") || !strings.Contains(body, `href="/posts/`+strconv.Itoa(int(related.ID))+`"`) {
+		t.Fatalf("expected related post section in post detail")
+	}
+	if strings.Contains(body, "周末烘焙记录") {
+		t.Fatalf("expected unrelated post to be left out")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/"+strconv.Itoa(int(source.ID))+"/related", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected related posts status 200, got %d", w.Code)
+	}
+	var payload struct {
+		Items []struct {
+			PostID uint   `json:"post_id"`
+			Title  string `json:"title"`
+			URL    string `json:"url"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode related posts: %v", err)
+	}
+	if len(payload.Items) != 1 || payload.Items[0].PostID != related.ID || payload.Items[0].Title != "Channel 并发模式" {
+		t.Fatalf("unexpected related posts %+v", payload.Items)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/missing/related", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown post, got %d", w.Code)
+	}
+}
+
 func TestShowAboutFallback(t *testing.T) {
 	cleanup := setupPublicTestDB(t)
 	defer cleanup()
@@ -471,6 +826,55 @@ func TestShowTagArchiveRendersTags(t *testing.T) {
 	}
 }
 
+func TestShowHomeEchoesSearchFiltersAsChips(t *testing.T) {
+	cleanup := setupPublicTestDB(t)
+	defer cleanup()
+
+	tag := db.Tag{Name: "Go"}
+	if err := db.DB.Create(&tag).Error; err != nil {
+		t.Fatalf("failed to seed tag: %v", err)
+	}
+	svc := service.NewPostService(db.DB)
+	tagged, err := svc.Create(service.PostInput{
+		Content:     "# 并发模式\n内容",
+		TagIDs:      []uint{tag.ID},
+		UserID:      1,
+		CoverURL:    "https://example.com/a.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create tagged post: %v", err)
+	}
+	if _, err := svc.Publish(tagged.ID, 1, nil); err != nil {
+		t.Fatalf("publish tagged post: %v", err)
+	}
+	untagged := seedPublishedPost(t, "并发入门", "# 并发入门\n内容")
+
+	r := router.SetupRouter("test-secret", "web/static/uploads", "/static/uploads", "")
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/?search="+url.QueryEscape("并发 tag:go"), nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "标签：go") {
+		t.Fatalf("expected tag filter chip in home page")
+	}
+	removeURL := `href="/?search=` + url.QueryEscape("并发") + `"`
+	if !strings.Contains(body, removeURL) {
+		t.Fatalf("expected chip to link to query without the tag filter, want %s", removeURL)
+	}
+	if !strings.Contains(body, "并发模式") {
+		t.Fatalf("expected tagged post in filtered results")
+	}
+	if strings.Contains(body, untagged.Title) {
+		t.Fatalf("expected tag filter to exclude untagged post")
+	}
+}
+
 func TestShowHomeTagOptionsFollowConfiguredOrder(t *testing.T) {
 	cleanup := setupPublicTestDB(t)
 	defer cleanup()
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// relatedPostItem 是相关推荐在 JSON 接口中的表示。
+type relatedPostItem struct {
+	PostID      uint      `json:"post_id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Summary     string    `json:"summary"`
+	CoverURL    string    `json:"cover_url"`
+	PublishedAt time.Time `json:"published_at"`
+	Tags        []string  `json:"tags"`
+}
+
+// ListRelatedPosts 以 JSON 返回文章的相关推荐
+func (a *API) ListRelatedPosts(c *gin.Context) {
+	ref, err := a.posts.ResolvePostRef(c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			respondError(c, http.StatusNotFound, "文章不存在")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "获取相关文章失败")
+		return
+	}
+	if _, err := a.posts.PublicPublication(ref.PostID); err != nil {
+		if errors.Is(err, service.ErrPostWithdrawn) || errors.Is(err, service.ErrPublicationNotFound) {
+			respondError(c, http.StatusNotFound, "文章不存在")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "获取相关文章失败")
+		return
+	}
+
+	publications, err := a.relatedPublications(ref.PostID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取相关文章失败")
+		return
+	}
+
+	items := make([]relatedPostItem, 0, len(publications))
+	for i := range publications {
+		publication := &publications[i]
+		items = append(items, relatedPostItem{
+			PostID:      publication.PostID,
+			Title:       publication.Title,
+			URL:         publication.PublicPath(),
+			Summary:     buildPublicationDescription(publication),
+			CoverURL:    publication.CoverURL,
+			PublishedAt: publication.PublishedAt,
+			Tags:        collectTagNames(publication.Tags),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// NewRelatedPostService 创建按前台摘要同一套 Markdown 纯文本提取计算的相关推荐服务。
+// 后台刷新任务与静态站点生成也通过它创建，保证各处计算结果一致。
+func NewRelatedPostService(gdb *gorm.DB) *service.RelatedPostService {
+	return service.NewRelatedPostService(gdb, markdownToPlainText)
+}
+
+// relatedPublications 返回文章已保存的相关推荐，推荐由后台任务在已发布文章变化后重新计算。
+func (a *API) relatedPublications(postID uint) ([]db.PostPublication, error) {
+	return a.related.List(postID, service.RelatedPostLimit)
+}
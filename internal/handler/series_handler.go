@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type seriesPayload struct {
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	CoverURL    string `json:"cover_url"`
+	CoverWidth  int    `json:"cover_width"`
+	CoverHeight int    `json:"cover_height"`
+	PostIDs     []uint `json:"post_ids"`
+}
+
+func (p seriesPayload) toInput() service.SeriesInput {
+	return service.SeriesInput{
+		Title:       p.Title,
+		Slug:        p.Slug,
+		Description: p.Description,
+		CoverURL:    p.CoverURL,
+		CoverWidth:  p.CoverWidth,
+		CoverHeight: p.CoverHeight,
+		PostIDs:     p.PostIDs,
+	}
+}
+
+// ListSeries 获取系列列表
+func (a *API) ListSeries(c *gin.Context) {
+	series, err := a.series.List()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取系列列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// GetSeries 获取系列详情及有序成员
+func (a *API) GetSeries(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的系列ID")
+		return
+	}
+
+	series, err := a.series.Get(id)
+	if err != nil {
+		if errors.Is(err, service.ErrSeriesNotFound) {
+			respondError(c, http.StatusNotFound, "系列不存在")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "获取系列失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// CreateSeries 创建系列
+func (a *API) CreateSeries(c *gin.Context) {
+	var payload seriesPayload
+	if !bindJSON(c, &payload, "请求参数不合法") {
+		return
+	}
+
+	series, err := a.series.Create(payload.toInput())
+	if err != nil {
+		respondSeriesError(c, err, "创建系列失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "系列创建成功", "series": series})
+}
+
+// UpdateSeries 更新系列信息与文章顺序
+func (a *API) UpdateSeries(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的系列ID")
+		return
+	}
+
+	var payload seriesPayload
+	if !bindJSON(c, &payload, "请求参数不合法") {
+		return
+	}
+
+	series, err := a.series.Update(id, payload.toInput())
+	if err != nil {
+		respondSeriesError(c, err, "更新系列失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "系列更新成功", "series": series})
+}
+
+// DeleteSeries 删除系列，文章本身保留
+func (a *API) DeleteSeries(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的系列ID")
+		return
+	}
+
+	if err := a.series.Delete(id); err != nil {
+		respondSeriesError(c, err, "删除系列失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "系列删除成功"})
+}
+
+func respondSeriesError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrSeriesNotFound):
+		respondError(c, http.StatusNotFound, "系列不存在")
+	case errors.Is(err, service.ErrSeriesTitleRequired):
+		respondError(c, http.StatusBadRequest, "系列标题不能为空")
+	case errors.Is(err, service.ErrSlugInvalid):
+		respondError(c, http.StatusBadRequest, "系列链接不合法")
+	case errors.Is(err, service.ErrSeriesSlugTaken):
+		respondError(c, http.StatusConflict, "系列链接已被占用")
+	case errors.Is(err, service.ErrPostNotFound):
+		respondError(c, http.StatusBadRequest, "部分文章不存在")
+	case errors.Is(err, service.ErrSeriesPostDuplicate):
+		respondError(c, http.StatusBadRequest, "同一篇文章不能重复加入系列")
+	case errors.Is(err, service.ErrSeriesPostConflict):
+		respondError(c, http.StatusConflict, "部分文章已属于其他系列")
+	default:
+		respondError(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// ShowSeries 渲染前台系列页，按顺序列出已发布的文章
+func (a *API) ShowSeries(c *gin.Context) {
+	published, err := a.series.GetPublishedBySlug(c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, service.ErrSeriesNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	series := published.Series
+	description := strings.TrimSpace(series.Description)
+	if description == "" {
+		description = fmt.Sprintf("系列「%s」共 %d 篇文章。", series.Title, len(published.Publications))
+	}
+
+	payload := gin.H{
+		"title":           series.Title,
+		"series":          series,
+		"posts":           published.Publications,
+		"year":            time.Now().Year(),
+		"canonical":       series.PublicPath(),
+		"metaType":        "website",
+		"metaDescription": description,
+	}
+	if cover := strings.TrimSpace(series.CoverURL); cover != "" {
+		payload["metaImage"] = a.absoluteURL(c, cover)
+	}
+
+	a.renderHTML(c, http.StatusOK, "series.html", payload)
+}
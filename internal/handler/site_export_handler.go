@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportSite 以 zip 流的形式导出整站的 Markdown 内容与引用到的上传文件
+func (a *API) ExportSite(c *gin.Context) {
+	filename := fmt.Sprintf("commitlog-export-%s.zip", time.Now().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+
+	// 响应头已写出，导出中途失败只能记录日志并中断连接
+	if err := a.exporter.Export(c.Writer); err != nil {
+		log.Printf("export site: %v", err)
+		c.Abort()
+	}
+}
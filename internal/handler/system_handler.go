@@ -60,6 +60,7 @@ type systemSettingsRequest struct {
 	AISummaryPrompt  string              `json:"aiSummaryPrompt"`
 	AIRewritePrompt  string              `json:"aiRewritePrompt"`
 	GalleryEnabled   *bool               `json:"galleryEnabled"`
+	LiteEmbeds       *bool               `json:"liteEmbeds"`
 	NavButtons       []service.NavButton `json:"navButtons"`
 }
 
@@ -117,6 +118,7 @@ func (r systemSettingsRequest) toInput() service.SystemSettingsInput {
 		AISummaryPrompt:  r.AISummaryPrompt,
 		AIRewritePrompt:  r.AIRewritePrompt,
 		GalleryEnabled:   r.GalleryEnabled,
+		LiteEmbeds:       r.LiteEmbeds,
 		NavButtons:       r.NavButtons,
 	}
 }
@@ -140,6 +142,7 @@ func systemSettingsPayload(settings service.SystemSettings) gin.H {
 		"aiSummaryPrompt":  settings.AISummaryPrompt,
 		"aiRewritePrompt":  settings.AIRewritePrompt,
 		"galleryEnabled":   settings.GalleryEnabled,
+		"liteEmbeds":       settings.LiteEmbeds,
 		"navButtons":       settings.NavButtons,
 	}
 }
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ShowTrash 渲染回收站管理页面
+func (a *API) ShowTrash(c *gin.Context) {
+	items, err := a.trash.List("")
+	if err != nil {
+		a.renderHTML(c, http.StatusInternalServerError, "trash.html", gin.H{
+			"title": "回收站",
+			"error": "加载回收站失败",
+		})
+		return
+	}
+
+	a.renderHTML(c, http.StatusOK, "trash.html", gin.H{
+		"title": "回收站",
+		"items": items,
+	})
+}
+
+// ListTrash 返回回收站中的记录，可通过 kind 过滤类型
+func (a *API) ListTrash(c *gin.Context) {
+	items, err := a.trash.List(c.Query("kind"))
+	if err != nil {
+		if errors.Is(err, service.ErrTrashKindInvalid) {
+			respondError(c, http.StatusBadRequest, "无效的回收站类型")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "获取回收站失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// RestoreTrashItem 将记录从回收站恢复
+func (a *API) RestoreTrashItem(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的记录ID")
+		return
+	}
+
+	if err := a.trash.Restore(c.Param("kind"), id); err != nil {
+		respondTrashError(c, err, "恢复失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已恢复"})
+}
+
+// PurgeTrashItem 彻底删除回收站中的记录
+func (a *API) PurgeTrashItem(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的记录ID")
+		return
+	}
+
+	if err := a.trash.Purge(c.Param("kind"), id); err != nil {
+		respondTrashError(c, err, "彻底删除失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已彻底删除"})
+}
+
+func respondTrashError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrTrashKindInvalid):
+		respondError(c, http.StatusBadRequest, "无效的回收站类型")
+	case errors.Is(err, service.ErrTrashItemNotFound):
+		respondError(c, http.StatusNotFound, "回收站中不存在该记录")
+	default:
+		respondError(c, http.StatusInternalServerError, fallback)
+	}
+}
@@ -5,22 +5,20 @@ import (
 	htmlstd "html"
 	"net/url"
 	"regexp"
-	"strconv"
 	"strings"
 
+	"github.com/commitlog/internal/service"
 	"github.com/microcosm-cc/bluemonday"
 )
 
-const (
-	videoAspectLandscape = "16:9"
-)
-
 var (
 	videoEmbedLinePattern = regexp.MustCompile(`^\s*<?((?:https?://)?[^\s]+)>?\s*$`)
-	videoEmbedSrcPattern  = regexp.MustCompile(
-		`^https://(?:www\.)?(?:youtube\.com/embed/|youtube-nocookie\.com/embed/|player\.bilibili\.com/player\.html(?:\?|$)|open\.douyin\.com/player/video(?:\?|$)|www\.iesdouyin\.com/share/video/|www\.douyin\.com/video/|v\.douyin\.com/)`,
-	)
+	videoEmbedSrcPattern  = embedProviders.srcPattern()
 	videoEmbedTimePattern = regexp.MustCompile(`(?i)(\d+)(h|m|s)`) // for YouTube t=1h2m3s
+	// embedLinkClassPattern 限定无法嵌入的平台输出的链接卡片类名。
+	embedLinkClassPattern = regexp.MustCompile(`^embed-link$`)
+	// liteEmbedClassPattern 限定轻量嵌入占位内部元素的类名。
+	liteEmbedClassPattern = regexp.MustCompile(`^video-embed-(?:play|thumb|title)$`)
 )
 
 func buildContentSanitizer() *bluemonday.Policy {
@@ -29,6 +27,32 @@ func buildContentSanitizer() *bluemonday.Policy {
 	policy.AllowAttrs("class", "data-video-embed", "data-video-platform", "data-video-aspect", "data-video-source").OnElements("div")
 	policy.AllowAttrs("src").Matching(videoEmbedSrcPattern).OnElements("iframe")
 	policy.AllowAttrs("title", "allow", "allowfullscreen", "frameborder", "loading", "referrerpolicy", "sandbox").OnElements("iframe")
+	policy.AllowAttrs("class").Matching(embedLinkClassPattern).OnElements("p")
+	policy.AllowAttrs("data-video-platform").OnElements("p")
+	// 轻量嵌入的 iframe 地址由前端读取，必须与 iframe src 使用同一份白名单
+	policy.AllowAttrs("data-embed-src").Matching(videoEmbedSrcPattern).OnElements("div")
+	policy.AllowAttrs("data-embed-title", "data-embed-sandbox").OnElements("div")
+	policy.AllowElements("button")
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^button$`)).OnElements("button")
+	policy.AllowAttrs("data-embed-play", "aria-label").OnElements("button")
+	policy.AllowAttrs("class").Matching(liteEmbedClassPattern).OnElements("button", "img", "span")
+	policy.AllowAttrs("loading").Matching(regexp.MustCompile(`^lazy$`)).OnElements("img")
+	// 标题锚点可能包含中文，UGC 默认规则只放行 ASCII id
+	policy.AllowAttrs("id").Matching(headingIDPattern).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	// 服务端代码高亮输出的类名
+	policy.AllowElements("figcaption")
+	policy.AllowAttrs("class").Matching(codeHighlightClassPattern).OnElements("figure", "figcaption", "pre", "span")
+	policy.AllowAttrs("class").Matching(codeLanguageClassPattern).OnElements("code")
+	// 图表容器保留源码，由前端渲染
+	policy.AllowAttrs("class").Matching(diagramClassPattern).OnElements("figure", "figcaption", "pre")
+	policy.AllowAttrs("data-diagram").Matching(diagramTypePattern).OnElements("figure")
+	// 链接卡片
+	policy.AllowAttrs("data-link-card").OnElements("div")
+	policy.AllowAttrs("class").Matching(linkCardClassPattern).OnElements("a", "span", "img")
+	// 内部链接
+	policy.AllowAttrs("class").Matching(wikiLinkClassPattern).OnElements("a", "span")
+	// 数学公式交由前端 KaTeX 渲染
+	policy.AllowAttrs("class").Matching(mathClassPattern).OnElements("span")
 	return policy
 }
 
@@ -37,14 +61,57 @@ type videoEmbed struct {
 	Source   string
 	EmbedURL string
 	Aspect   string
+	// LinkOnly 为 true 时平台不提供 iframe，渲染为指向原链接的卡片。
+	LinkOnly  bool
+	Thumbnail service.EmbedThumbnailSource
 }
 
-func applyVideoEmbeds(markdown string) string {
+// embedRenderOptions 控制正文中嵌入内容的输出方式。
+type embedRenderOptions struct {
+	// Lite 为 true 时输出缩略图与播放按钮，读者点击后才创建 iframe。
+	Lite bool
+	// Thumbnails 提供本地缓存的缩略图，未缓存时不输出缩略图；下载在保存或发布时进行。
+	Thumbnails *service.EmbedThumbnailCache
+	// LinkPreviews 提供已缓存的链接卡片信息，为空时独立成行的普通链接保持原样。
+	LinkPreviews *service.LinkPreviewService
+	// WikiLinks 为空时内部链接按普通文字输出。
+	WikiLinks wikiLinkSource
+}
+
+func applyVideoEmbeds(markdown string, options embedRenderOptions) string {
 	if strings.TrimSpace(markdown) == "" {
 		return markdown
 	}
 
 	lines := strings.Split(markdown, "\n")
+	var cardLines []standaloneURLLine
+	for _, candidate := range findStandaloneURLLines(lines) {
+		embed, ok := parseVideoEmbed(candidate.URL)
+		if !ok {
+			cardLines = append(cardLines, candidate)
+			continue
+		}
+
+		if options.Lite && !embed.LinkOnly {
+			lines[candidate.Index] = buildLiteEmbedHTML(embed, options.thumbnailURL(embed.Thumbnail))
+			continue
+		}
+		lines[candidate.Index] = buildVideoEmbedHTML(embed)
+	}
+	applyLinkCards(lines, cardLines, options.LinkPreviews)
+
+	return strings.Join(lines, "\n")
+}
+
+// standaloneURLLine 是独立成行的链接及其所在行号。
+type standaloneURLLine struct {
+	Index int
+	URL   string
+}
+
+// findStandaloneURLLines 找出代码块、引用与列表之外独立成行的链接。
+func findStandaloneURLLines(lines []string) []standaloneURLLine {
+	var found []standaloneURLLine
 	inFence := false
 	fenceMarker := ""
 
@@ -75,16 +142,9 @@ func applyVideoEmbeds(markdown string) string {
 		if !ok {
 			continue
 		}
-
-		embed, ok := parseVideoEmbed(urlValue)
-		if !ok {
-			continue
-		}
-
-		lines[i] = buildVideoEmbedHTML(embed)
+		found = append(found, standaloneURLLine{Index: i, URL: urlValue})
 	}
-
-	return strings.Join(lines, "\n")
+	return found
 }
 
 func detectFenceMarker(line string) string {
@@ -150,13 +210,7 @@ func parseVideoEmbed(raw string) (videoEmbed, bool) {
 		return videoEmbed{}, false
 	}
 
-	if embed, ok := parseYouTubeEmbed(parsed, trimmed); ok {
-		return embed, true
-	}
-	if embed, ok := parseBilibiliEmbed(parsed, trimmed); ok {
-		return embed, true
-	}
-	return videoEmbed{}, false
+	return embedProviders.lookup(parsed, trimmed)
 }
 
 func normalizeVideoURL(raw string) string {
@@ -167,182 +221,13 @@ func normalizeVideoURL(raw string) string {
 	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
 		return raw
 	}
-	knownPrefixes := []string{
-		"douyin.com/",
-		"www.douyin.com/",
-		"open.douyin.com/",
-		"iesdouyin.com/",
-		"www.iesdouyin.com/",
-		"v.douyin.com/",
-		"bilibili.com/",
-		"www.bilibili.com/",
-		"youtube.com/",
-		"www.youtube.com/",
-		"youtu.be/",
-	}
-	for _, prefix := range knownPrefixes {
-		if strings.HasPrefix(lower, prefix) {
-			return "https://" + raw
-		}
+	host, _, found := strings.Cut(lower, "/")
+	if found && embedProviders.knownHost(host) {
+		return "https://" + raw
 	}
 	return raw
 }
 
-func parseYouTubeEmbed(u *url.URL, source string) (videoEmbed, bool) {
-	host := strings.ToLower(u.Hostname())
-	var videoID string
-
-	switch {
-	case host == "youtu.be":
-		videoID = strings.Trim(strings.TrimPrefix(u.Path, "/"), "/")
-		if strings.Contains(videoID, "/") {
-			videoID = strings.Split(videoID, "/")[0]
-		}
-	case isHostOrSubdomain(host, "youtube.com"):
-		path := strings.Trim(u.Path, "/")
-		if path == "watch" {
-			videoID = u.Query().Get("v")
-		} else if strings.HasPrefix(path, "shorts/") {
-			videoID = strings.TrimPrefix(path, "shorts/")
-		} else if strings.HasPrefix(path, "embed/") {
-			videoID = strings.TrimPrefix(path, "embed/")
-		} else if strings.HasPrefix(path, "live/") {
-			videoID = strings.TrimPrefix(path, "live/")
-		}
-		if strings.Contains(videoID, "/") {
-			videoID = strings.Split(videoID, "/")[0]
-		}
-	default:
-		return videoEmbed{}, false
-	}
-
-	if videoID == "" {
-		return videoEmbed{}, false
-	}
-
-	embedURL := fmt.Sprintf("https://www.youtube.com/embed/%s", videoID)
-	embedValues := url.Values{}
-	embedValues.Set("rel", "0")
-	embedValues.Set("modestbranding", "1")
-	embedValues.Set("playsinline", "1")
-	if start := parseYouTubeStart(u); start > 0 {
-		embedValues.Set("start", strconv.Itoa(start))
-	}
-	embedURL = embedURL + "?" + embedValues.Encode()
-
-	return videoEmbed{
-		Platform: "youtube",
-		Source:   source,
-		EmbedURL: embedURL,
-		Aspect:   videoAspectLandscape,
-	}, true
-}
-
-func parseYouTubeStart(u *url.URL) int {
-	if u == nil {
-		return 0
-	}
-	query := u.Query()
-	if value := query.Get("start"); value != "" {
-		return parseYouTubeTime(value)
-	}
-	if value := query.Get("t"); value != "" {
-		return parseYouTubeTime(value)
-	}
-	return 0
-}
-
-func parseYouTubeTime(value string) int {
-	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
-		return 0
-	}
-	if onlyDigits(trimmed) {
-		seconds, err := strconv.Atoi(trimmed)
-		if err == nil && seconds > 0 {
-			return seconds
-		}
-		return 0
-	}
-
-	matches := videoEmbedTimePattern.FindAllStringSubmatch(trimmed, -1)
-	if len(matches) == 0 {
-		return 0
-	}
-
-	total := 0
-	for _, match := range matches {
-		value, err := strconv.Atoi(match[1])
-		if err != nil || value <= 0 {
-			continue
-		}
-		switch strings.ToLower(match[2]) {
-		case "h":
-			total += value * 3600
-		case "m":
-			total += value * 60
-		case "s":
-			total += value
-		}
-	}
-
-	return total
-}
-
-func onlyDigits(value string) bool {
-	for _, r := range value {
-		if r < '0' || r > '9' {
-			return false
-		}
-	}
-	return value != ""
-}
-
-func parseBilibiliEmbed(u *url.URL, source string) (videoEmbed, bool) {
-	host := strings.ToLower(u.Hostname())
-	if !isHostOrSubdomain(host, "bilibili.com") {
-		return videoEmbed{}, false
-	}
-
-	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
-	if len(segments) < 2 || segments[0] != "video" {
-		return videoEmbed{}, false
-	}
-
-	rawID := segments[1]
-	if rawID == "" {
-		return videoEmbed{}, false
-	}
-
-	page := parsePositiveInt(u.Query().Get("p"), 1)
-
-	values := url.Values{}
-	lowerID := strings.ToLower(rawID)
-	switch {
-	case strings.HasPrefix(lowerID, "bv"):
-		values.Set("bvid", rawID)
-	case strings.HasPrefix(lowerID, "av"):
-		values.Set("aid", strings.TrimPrefix(lowerID, "av"))
-	case onlyDigits(rawID):
-		values.Set("aid", rawID)
-	default:
-		return videoEmbed{}, false
-	}
-	values.Set("page", strconv.Itoa(page))
-	values.Set("high_quality", "1")
-	values.Set("danmaku", "0")
-	values.Set("autoplay", "0")
-
-	embedURL := "https://player.bilibili.com/player.html?" + values.Encode()
-
-	return videoEmbed{
-		Platform: "bilibili",
-		Source:   source,
-		EmbedURL: embedURL,
-		Aspect:   videoAspectLandscape,
-	}, true
-}
-
 func buildVideoEmbedHTML(embed videoEmbed) string {
 	platform := htmlstd.EscapeString(embed.Platform)
 	aspect := htmlstd.EscapeString(embed.Aspect)
@@ -351,6 +236,16 @@ func buildVideoEmbedHTML(embed videoEmbed) string {
 	title := htmlstd.EscapeString(videoEmbedTitle(embed.Platform))
 	sandboxAttr := videoEmbedSandboxAttribute(embed.Platform)
 
+	if embed.LinkOnly {
+		return fmt.Sprintf(
+			`<p class="embed-link" data-video-platform="%s"><a href="%s">%s：%s</a></p>`,
+			platform,
+			source,
+			title,
+			source,
+		)
+	}
+
 	return fmt.Sprintf(
 		`<div class="video-embed is-loading" data-video-embed="true" data-video-platform="%s" data-video-aspect="%s" data-video-source="%s">`+
 			`<iframe src="%s" title="%s" loading="lazy" allow="%s" allowfullscreen frameborder="0" referrerpolicy="strict-origin-when-cross-origin"%s></iframe>`+
@@ -365,17 +260,60 @@ func buildVideoEmbedHTML(embed videoEmbed) string {
 	)
 }
 
+// buildLiteEmbedHTML 输出点击加载的占位：缩略图、标题与播放按钮。
+// iframe 地址放在 data-embed-src 中，由前端在点击后创建，此前不会请求第三方。
+func buildLiteEmbedHTML(embed videoEmbed, thumbnailURL string) string {
+	title := htmlstd.EscapeString(videoEmbedTitle(embed.Platform))
+	sandboxAttr := ""
+	if spec, ok := embedProviders.spec(embed.Platform); ok && spec.Sandbox != "" {
+		sandboxAttr = ` data-embed-sandbox="` + htmlstd.EscapeString(spec.Sandbox) + `"`
+	}
+	thumbnail := ""
+	if thumbnailURL != "" {
+		thumbnail = `<img class="video-embed-thumb" src="` + htmlstd.EscapeString(thumbnailURL) + `" alt="" loading="lazy">`
+	}
+
+	return fmt.Sprintf(
+		`<div class="video-embed is-lite is-ready" data-video-embed="true" data-video-platform="%s" data-video-aspect="%s" data-video-source="%s" data-embed-src="%s" data-embed-title="%s"%s>`+
+			`<button type="button" class="video-embed-play" data-embed-play="true" aria-label="播放：%s">%s<span class="video-embed-title">%s</span></button>`+
+			`</div>`,
+		htmlstd.EscapeString(embed.Platform),
+		htmlstd.EscapeString(embed.Aspect),
+		htmlstd.EscapeString(embed.Source),
+		htmlstd.EscapeString(embed.EmbedURL),
+		title,
+		sandboxAttr,
+		title,
+		thumbnail,
+		title,
+	)
+}
+
+// thumbnailURL 返回已缓存的缩略图地址，未缓存时返回空字符串，渲染页面不会发起下载。
+func (o embedRenderOptions) thumbnailURL(source service.EmbedThumbnailSource) string {
+	if o.Thumbnails == nil || source.Key == "" {
+		return ""
+	}
+	cached, _ := o.Thumbnails.Lookup(source.Key)
+	return cached
+}
+
+// embedThumbnailSources 返回正文中可嵌入内容的缩略图来源，用于保存或发布后下载。
+func embedThumbnailSources(content string) []service.EmbedThumbnailSource {
+	var sources []service.EmbedThumbnailSource
+	for _, candidate := range findStandaloneURLLines(strings.Split(content, "\n")) {
+		if embed, ok := parseVideoEmbed(candidate.URL); ok && !embed.LinkOnly && embed.Thumbnail.Key != "" {
+			sources = append(sources, embed.Thumbnail)
+		}
+	}
+	return sources
+}
+
 func videoEmbedTitle(platform string) string {
-	switch platform {
-	case "youtube":
-		return "YouTube 视频播放器"
-	case "bilibili":
-		return "B 站视频播放器"
-	case "douyin":
-		return "抖音视频播放器"
-	default:
-		return "视频播放器"
+	if spec, ok := embedProviders.spec(platform); ok && spec.Title != "" {
+		return spec.Title
 	}
+	return "视频播放器"
 }
 
 func videoEmbedAllowAttribute() string {
@@ -383,8 +321,8 @@ func videoEmbedAllowAttribute() string {
 }
 
 func videoEmbedSandboxAttribute(platform string) string {
-	if platform == "bilibili" {
-		return ` sandbox="allow-scripts allow-same-origin allow-presentation"`
+	if spec, ok := embedProviders.spec(platform); ok && spec.Sandbox != "" {
+		return ` sandbox="` + htmlstd.EscapeString(spec.Sandbox) + `"`
 	}
 	return ""
 }
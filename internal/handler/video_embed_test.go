@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/commitlog/internal/service"
 )
 
 var iframeAllowAutoplayPattern = regexp.MustCompile(`allow="[^"]*autoplay`)
@@ -32,6 +36,55 @@ func TestRenderMarkdown_VideoEmbeds(t *testing.T) {
 			wantVendor: "bilibili",
 			wantAspect: "16:9",
 		},
+		{
+			name:       "vimeo",
+			markdown:   "https://vimeo.com/76979871/8272103f6e",
+			wantSrc:    "https://player.vimeo.com/video/76979871?dnt=1&amp;h=8272103f6e",
+			wantVendor: "vimeo",
+			wantAspect: "16:9",
+		},
+		{
+			name:       "codepen",
+			markdown:   "https://codepen.io/chriscoyier/pen/PNaGbb",
+			wantSrc:    "https://codepen.io/chriscoyier/embed/PNaGbb?default-tab=result",
+			wantVendor: "codepen",
+			wantAspect: "4:3",
+		},
+		{
+			name:       "gist",
+			markdown:   "https://gist.github.com/octocat/6cad326836d38bd3a7ae",
+			wantSrc:    "https://gist.github.com/octocat/6cad326836d38bd3a7ae.pibb",
+			wantVendor: "gist",
+			wantAspect: "4:3",
+		},
+		{
+			name:       "spotify-track",
+			markdown:   "https://open.spotify.com/intl-zh/track/4uLU6hMCjMI75M1A2tKUQC?si=abc",
+			wantSrc:    "https://open.spotify.com/embed/track/4uLU6hMCjMI75M1A2tKUQC",
+			wantVendor: "spotify",
+			wantAspect: "audio",
+		},
+		{
+			name:       "spotify-playlist",
+			markdown:   "https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M",
+			wantSrc:    "https://open.spotify.com/embed/playlist/37i9dQZF1DXcBWIGoYBM5M",
+			wantVendor: "spotify",
+			wantAspect: "playlist",
+		},
+		{
+			name:       "netease-song",
+			markdown:   "https://music.163.com/#/song?id=1901371647",
+			wantSrc:    "https://music.163.com/outchain/player?auto=0&amp;height=66&amp;id=1901371647&amp;type=2",
+			wantVendor: "netease",
+			wantAspect: "audio",
+		},
+		{
+			name:       "figma",
+			markdown:   "https://www.figma.com/design/abc123XYZ/Landing?node-id=0-1",
+			wantSrc:    "https://www.figma.com/embed?embed_host=share&amp;url=https%3A%2F%2Fwww.figma.com%2Fdesign%2Fabc123XYZ%2FLanding%3Fnode-id%3D0-1",
+			wantVendor: "figma",
+			wantAspect: "16:9",
+		},
 	}
 
 	for _, tt := range tests {
@@ -39,7 +92,7 @@ func TestRenderMarkdown_VideoEmbeds(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			rendered, err := renderMarkdown(tt.markdown)
+			rendered, _, err := renderMarkdownWithTOC(tt.markdown, embedRenderOptions{})
 			if err != nil {
 				t.Fatalf("render markdown: %v", err)
 			}
@@ -96,7 +149,7 @@ func TestRenderMarkdown_SkipsDouyinEmbed(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			rendered, err := renderMarkdown(tt.markdown)
+			rendered, _, err := renderMarkdownWithTOC(tt.markdown, embedRenderOptions{})
 			if err != nil {
 				t.Fatalf("render markdown: %v", err)
 			}
@@ -112,11 +165,67 @@ func TestRenderMarkdown_SkipsDouyinEmbed(t *testing.T) {
 	}
 }
 
+func TestRenderMarkdown_ShareLinkProvidersRenderCards(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		markdown   string
+		wantVendor string
+	}{
+		{markdown: "https://www.douyin.com/video/7234567890123456789", wantVendor: "douyin"},
+		{markdown: "https://www.xiaohongshu.com/explore/64b8f3c2000000001203e1f2", wantVendor: "xiaohongshu"},
+		{markdown: "xhslink.com/a/AbCdEf", wantVendor: "xiaohongshu"},
+	}
+
+	for _, tt := range tests {
+		rendered, _, err := renderMarkdownWithTOC(tt.markdown, embedRenderOptions{})
+		if err != nil {
+			t.Fatalf("render markdown: %v", err)
+		}
+
+		html := string(rendered)
+		if strings.Contains(html, "<iframe") {
+			t.Fatalf("expected share link to stay out of iframes, got: %s", html)
+		}
+		if !strings.Contains(html, `<p class="embed-link" data-video-platform="`+tt.wantVendor+`">`) {
+			t.Fatalf("expected %s link card, got: %s", tt.wantVendor, html)
+		}
+	}
+}
+
+func TestEmbedRegistry_SanitizerOnlyAllowsRegisteredPlayers(t *testing.T) {
+	t.Parallel()
+
+	allowed := []string{
+		"https://www.youtube.com/embed/dQw4w9WgXcQ",
+		"https://player.vimeo.com/video/76979871",
+		"https://open.spotify.com/embed/track/4uLU6hMCjMI75M1A2tKUQC",
+		"https://music.163.com/outchain/player?type=2&id=1",
+	}
+	for _, src := range allowed {
+		if !videoEmbedSrcPattern.MatchString(src) {
+			t.Fatalf("expected %s to be allowed", src)
+		}
+	}
+
+	rejected := []string{
+		"https://example.com/embed",
+		"https://vimeo.com.evil.example/video/1",
+		"https://open.douyin.com/player/video?vid=7602245594001771802",
+		"https://www.xiaohongshu.com/explore/64b8f3c2000000001203e1f2",
+	}
+	for _, src := range rejected {
+		if videoEmbedSrcPattern.MatchString(src) {
+			t.Fatalf("expected %s to be rejected", src)
+		}
+	}
+}
+
 func TestRenderMarkdown_SkipsVideoEmbedInsideCodeFence(t *testing.T) {
 	t.Parallel()
 
 	markdown := "```\nhttps://www.youtube.com/watch?v=dQw4w9WgXcQ\n```"
-	rendered, err := renderMarkdown(markdown)
+	rendered, _, err := renderMarkdownWithTOC(markdown, embedRenderOptions{})
 	if err != nil {
 		t.Fatalf("render markdown: %v", err)
 	}
@@ -131,7 +240,7 @@ func TestRenderMarkdown_YouTubeEmbedHasDisplayParams(t *testing.T) {
 	t.Parallel()
 
 	markdown := "<https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=62s>"
-	rendered, err := renderMarkdown(markdown)
+	rendered, _, err := renderMarkdownWithTOC(markdown, embedRenderOptions{})
 	if err != nil {
 		t.Fatalf("render markdown: %v", err)
 	}
@@ -155,7 +264,7 @@ func TestRenderMarkdown_BilibiliEmbedBlocksTopLevelNavigation(t *testing.T) {
 	t.Parallel()
 
 	markdown := "https://www.bilibili.com/video/BV1x5411c7mD"
-	rendered, err := renderMarkdown(markdown)
+	rendered, _, err := renderMarkdownWithTOC(markdown, embedRenderOptions{})
 	if err != nil {
 		t.Fatalf("render markdown: %v", err)
 	}
@@ -170,7 +279,7 @@ func TestRenderMarkdown_SkipsInlineVideoURL(t *testing.T) {
 	t.Parallel()
 
 	markdown := "观看链接：https://www.youtube.com/watch?v=dQw4w9WgXcQ"
-	rendered, err := renderMarkdown(markdown)
+	rendered, _, err := renderMarkdownWithTOC(markdown, embedRenderOptions{})
 	if err != nil {
 		t.Fatalf("render markdown: %v", err)
 	}
@@ -204,7 +313,7 @@ func TestRenderMarkdown_RejectsLookalikeVideoDomains(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rendered, err := renderMarkdown(tt.markdown)
+			rendered, _, err := renderMarkdownWithTOC(tt.markdown, embedRenderOptions{})
 			if err != nil {
 				t.Fatalf("render markdown: %v", err)
 			}
@@ -216,3 +325,46 @@ func TestRenderMarkdown_RejectsLookalikeVideoDomains(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderMarkdown_LiteEmbedsUseCachedThumbnail(t *testing.T) {
+	uploadDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(uploadDir, "embeds"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(uploadDir, "embeds", "youtube-dQw4w9WgXcQ.jpg"), []byte("jpeg"), 0o644); err != nil {
+		t.Fatalf("write thumbnail: %v", err)
+	}
+	options := embedRenderOptions{Lite: true, Thumbnails: service.NewEmbedThumbnailCache(uploadDir, "/uploads")}
+
+	markdown := "https://www.youtube.com/watch?v=dQw4w9WgXcQ\n\n" +
+		`<div class="video-embed" data-embed-src="https://evil.example/embed"></div>`
+	rendered, _, err := renderMarkdownWithTOC(markdown, options)
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+
+	html := string(rendered)
+	if strings.Contains(html, "<iframe") {
+		t.Fatalf("expected lite embed to defer the iframe, got: %s", html)
+	}
+	if !strings.Contains(html, `data-embed-src="https://www.youtube.com/embed/dQw4w9WgXcQ?`) {
+		t.Fatalf("expected iframe src to be kept for the click handler, got: %s", html)
+	}
+	if !strings.Contains(html, `<img class="video-embed-thumb" src="/uploads/embeds/youtube-dQw4w9WgXcQ.jpg"`) {
+		t.Fatalf("expected local thumbnail, got: %s", html)
+	}
+	if !strings.Contains(html, `<button type="button" class="video-embed-play" data-embed-play="true"`) {
+		t.Fatalf("expected play button, got: %s", html)
+	}
+	if strings.Contains(html, "evil.example") {
+		t.Fatalf("expected unknown lite embed src to be stripped, got: %s", html)
+	}
+}
+
+func TestEmbedThumbnailSources(t *testing.T) {
+	content := "https://www.youtube.com/watch?v=dQw4w9WgXcQ\n\n行内 https://www.youtube.com/watch?v=inline0000 链接\n\nhttps://example.com/article\n"
+	sources := embedThumbnailSources(content)
+	if len(sources) != 1 || sources[0].Key != "youtube-dQw4w9WgXcQ" {
+		t.Fatalf("expected only the standalone video thumbnail, got %+v", sources)
+	}
+}
@@ -243,16 +243,18 @@ func (r *templateRegistry) Instance(name string, data interface{}) render.Render
 func SetupRouter(sessionSecret, uploadDir, uploadURLPath, siteBaseURL string) *gin.Engine {
 	r := gin.New()
 
+	trimmedSecret := strings.TrimSpace(sessionSecret)
+	if trimmedSecret == "" {
+		trimmedSecret = "commitlog-dev-secret"
+	}
+
 	handlers := handler.NewAPI(db.DB, uploadDir, uploadURLPath, siteBaseURL)
+	handlers.SetSessionSecret(trimmedSecret)
 
 	r.Use(gin.Logger())
 	r.Use(recoveryWithHandler(handlers))
 
 	// 配置会话中间件
-	trimmedSecret := strings.TrimSpace(sessionSecret)
-	if trimmedSecret == "" {
-		trimmedSecret = "commitlog-dev-secret"
-	}
 	store := cookie.NewStore([]byte(trimmedSecret))
 	r.Use(sessions.Sessions("commitlog_session", store))
 
@@ -280,18 +282,8 @@ func SetupRouter(sessionSecret, uploadDir, uploadURLPath, siteBaseURL string) *g
 		}
 	}
 
-	r.GET("/robots.txt", handlers.ShowRobots)
-	r.GET("/sitemap.xml", handlers.ShowSitemap)
-	r.GET("/rss.xml", handlers.ShowRSS)
 	// 公共站点路由
-	r.GET("/", handlers.ShowHome)
-	r.GET("/search/suggestions", handlers.SearchSuggestions)
-	r.GET("/posts/more", handlers.LoadMorePosts)
-	r.GET("/posts/:id", handlers.ShowPostDetail)
-	r.GET("/tags", handlers.ShowTagArchive)
-	r.GET("/about", handlers.ShowAbout)
-	r.GET("/gallery", handlers.ShowGallery)
-	r.GET("/gallery/more", handlers.LoadMoreGallery)
+	registerPublicRoutes(r, handlers)
 
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -319,6 +311,7 @@ func SetupRouter(sessionSecret, uploadDir, uploadURLPath, siteBaseURL string) *g
 			auth.GET("/post-templates", handlers.ShowPostTemplateManagement)
 			auth.POST("/posts/preview", handlers.PreviewPost)
 			auth.GET("/gallery", handlers.ShowGalleryManagement)
+			auth.GET("/trash", handlers.ShowTrash)
 			auth.GET("/tags", handlers.ShowTagManagement)
 			auth.GET("/about", handlers.ShowAboutEditor)
 			auth.GET("/profile/contacts", handlers.ShowProfileContacts)
@@ -330,20 +323,48 @@ func SetupRouter(sessionSecret, uploadDir, uploadURLPath, siteBaseURL string) *g
 				api.GET("/posts", handlers.GetPosts)
 				api.GET("/posts/:id", handlers.GetPost)
 				api.GET("/posts/:id/draft-versions", handlers.ListDraftVersions)
+				api.GET("/posts/:id/diff", handlers.DiffPostVersions)
+				api.GET("/posts/:id/publications", handlers.ListPublications)
+				api.GET("/posts/:id/publications/:version", handlers.GetPublicationVersion)
+				api.GET("/posts/:id/previews", handlers.ListPreviewLinks)
+				api.POST("/posts/:id/previews", handlers.CreatePreviewLink)
+				api.DELETE("/posts/:id/previews/:previewID", handlers.RevokePreviewLink)
+				api.POST("/posts/:id/publications/:version/republish", handlers.RepublishPublicationVersion)
 				api.POST("/posts", handlers.CreatePost)
 				api.POST("/posts/from-template", handlers.CreatePostFromTemplate)
+				api.POST("/posts/import", handlers.ImportPosts)
 				api.POST("/posts/summary", handlers.GeneratePostSummary)
 				api.POST("/posts/optimize", handlers.OptimizePostContent)
 				api.POST("/posts/chat", handlers.RewritePostSelection)
+				api.POST("/posts/wiki-links", handlers.CheckWikiLinks)
+				api.GET("/posts/scheduled", handlers.ListScheduledPosts)
 				api.POST("/posts/:id/publish", handlers.PublishPost)
+				api.POST("/posts/:id/withdraw", handlers.WithdrawPost)
+				api.POST("/posts/:id/schedule", handlers.SchedulePost)
+				api.PUT("/posts/:id/schedule", handlers.ReschedulePost)
+				api.DELETE("/posts/:id/schedule", handlers.CancelScheduledPost)
 				api.PUT("/posts/:id", handlers.UpdatePost)
 				api.DELETE("/posts/:id", handlers.DeletePost)
 
+				api.GET("/export", handlers.ExportSite)
+				api.GET("/backup", handlers.DownloadBackup)
+				api.GET("/link-check", handlers.GetLinkCheckReport)
+				api.POST("/link-check", handlers.RunLinkCheck)
+
 				api.GET("/gallery", handlers.ListGalleryImages)
 				api.POST("/gallery", handlers.CreateGalleryImage)
 				api.PUT("/gallery/:id", handlers.UpdateGalleryImage)
 				api.DELETE("/gallery/:id", handlers.DeleteGalleryImage)
 
+				api.GET("/trash", handlers.ListTrash)
+				api.POST("/trash/:kind/:id/restore", handlers.RestoreTrashItem)
+				api.DELETE("/trash/:kind/:id", handlers.PurgeTrashItem)
+
+				api.GET("/series", handlers.ListSeries)
+				api.GET("/series/:id", handlers.GetSeries)
+				api.POST("/series", handlers.CreateSeries)
+				api.PUT("/series/:id", handlers.UpdateSeries)
+				api.DELETE("/series/:id", handlers.DeleteSeries)
 				api.GET("/tags", handlers.GetTags)
 				api.POST("/tags", handlers.CreateTag)
 				api.PUT("/tags/order", handlers.ReorderTags)
@@ -370,7 +391,52 @@ func SetupRouter(sessionSecret, uploadDir, uploadURLPath, siteBaseURL string) *g
 		}
 	}
 
-	r.NoRoute(func(c *gin.Context) {
+	r.NoRoute(notFoundHandler(handlers))
+
+	return r
+}
+
+// SetupStaticRouter 配置只包含公共页面的 Gin 引擎，供静态站点生成在进程内渲染页面，不记录访问统计
+func SetupStaticRouter(uploadDir, uploadURLPath, siteBaseURL string) *gin.Engine {
+	r := gin.New()
+
+	handlers := handler.NewAPI(db.DB, uploadDir, uploadURLPath, siteBaseURL)
+	handlers.DisableAnalytics()
+	handlers.EnableStaticExport()
+
+	r.Use(recoveryWithHandler(handlers))
+
+	templates := newTemplateRegistry()
+	templates.LoadTemplates("web/template")
+	r.HTMLRender = templates
+
+	registerPublicRoutes(r, handlers)
+	r.NoRoute(notFoundHandler(handlers))
+
+	return r
+}
+
+// registerPublicRoutes 注册前台公共站点路由
+func registerPublicRoutes(r *gin.Engine, handlers *handler.API) {
+	r.GET("/robots.txt", handlers.ShowRobots)
+	r.GET("/sitemap.xml", handlers.ShowSitemap)
+	r.GET("/rss.xml", handlers.ShowRSS)
+	r.GET("/", handlers.ShowHome)
+	r.GET("/search/suggestions", handlers.SearchSuggestions)
+	r.GET("/posts/more", handlers.LoadMorePosts)
+	r.GET("/posts/:slug", handlers.ShowPostDetail)
+	r.POST("/posts/:slug/unlock", handlers.UnlockPost)
+	r.GET("/posts/:slug/related", handlers.ListRelatedPosts)
+	r.GET("/preview/:token", handlers.ShowSharedPreview)
+	r.GET("/series/:slug", handlers.ShowSeries)
+	r.GET("/tags", handlers.ShowTagArchive)
+	r.GET("/about", handlers.ShowAbout)
+	r.GET("/gallery", handlers.ShowGallery)
+	r.GET("/gallery/more", handlers.LoadMoreGallery)
+}
+
+func notFoundHandler(handlers *handler.API) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		if prefersJSON(c) {
 			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "资源不存在"})
 			return
@@ -383,9 +449,7 @@ func SetupRouter(sessionSecret, uploadDir, uploadURLPath, siteBaseURL string) *g
 		}
 
 		renderErrorPage(c, handlers, http.StatusNotFound, "页面走丢了", "我们没有找到你想访问的内容，试试回到首页或浏览其他栏目。", &errorAction{Label: "返回首页", Href: "/"}, &errorAction{Label: "查看全部标签", Href: "/tags"})
-	})
-
-	return r
+	}
 }
 
 func recoveryWithHandler(handlers *handler.API) gin.HandlerFunc {
@@ -213,7 +213,7 @@ func (s *AnalyticsService) Overview(limit int) (SiteOverview, error) {
 	titleExpr := derivedTitleQueryExpr("p")
 	if err := s.db.Table("post_statistics ps").
 		Select(fmt.Sprintf("ps.post_id, %s AS title, ps.page_views, ps.unique_visitors", titleExpr)).
-		Joins("JOIN posts p ON p.id = ps.post_id").
+		Joins("JOIN posts p ON p.id = ps.post_id AND p.deleted_at IS NULL").
 		Order("ps.page_views DESC").
 		Limit(limit).
 		Scan(&topPosts).Error; err != nil {
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// RestoreResult 记录恢复结果以及被替换下来的原文件位置，便于人工回滚。
+type RestoreResult struct {
+	Manifest *BackupManifest
+	// PreviousDatabase 与 PreviousUploads 为空表示恢复前不存在对应文件
+	PreviousDatabase string
+	PreviousUploads  string
+}
+
+// RestoreBackup 校验备份包的清单、校验和与数据库结构，全部通过后再替换数据库文件和上传目录。
+// 恢复期间服务必须处于停止状态，数据库文件被运行中的服务锁定时返回 db.ErrDatabaseInUse；
+// 原文件会以 .pre-restore-<时间> 后缀保留，替换中途失败时移回原位。
+func RestoreBackup(bundle io.Reader, databasePath, uploadDir string) (*RestoreResult, error) {
+	databasePath = strings.TrimSpace(databasePath)
+	uploadDir = strings.TrimSpace(uploadDir)
+	if databasePath == "" || uploadDir == "" {
+		return nil, errors.New("database path and upload directory are required")
+	}
+	if err := os.MkdirAll(filepath.Dir(databasePath), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Clean(uploadDir)), 0o755); err != nil {
+		return nil, err
+	}
+
+	// 暂存目录与目标位于同一目录下，保证最终替换只是一次 rename
+	staging, err := os.MkdirTemp(filepath.Dir(databasePath), ".restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, checksums, err := unpackBackupBundle(bundle, staging)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyBackupManifest(manifest, checksums); err != nil {
+		return nil, err
+	}
+
+	stagedDatabase := filepath.Join(staging, backupDatabaseName)
+	if err := validateBackupDatabase(stagedDatabase); err != nil {
+		return nil, err
+	}
+
+	uploadStaging, err := os.MkdirTemp(filepath.Dir(filepath.Clean(uploadDir)), ".restore-uploads-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(uploadStaging)
+	stagedUploads := filepath.Join(uploadStaging, "uploads")
+	if err := extractUploadsArchive(filepath.Join(staging, backupUploadsName), stagedUploads); err != nil {
+		return nil, err
+	}
+
+	// 服务运行时持有数据库文件锁，获取不到说明服务尚未停止
+	if _, err := os.Stat(databasePath); err == nil {
+		lock, err := db.LockFile(databasePath)
+		if err != nil {
+			return nil, fmt.Errorf("stop the server before restoring: %w", err)
+		}
+		defer lock.Release()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	suffix := ".pre-restore-" + time.Now().UTC().Format("20060102-150405")
+	result := &RestoreResult{Manifest: manifest}
+	result.PreviousDatabase, result.PreviousUploads, err = swapRestoredFiles(stagedDatabase, databasePath, stagedUploads, uploadDir, suffix)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// movedAside 记录被 moveAside 移走的原文件，用于替换失败时移回。
+type movedAside struct {
+	target string
+	moved  string
+}
+
+// swapRestoredFiles 用暂存的数据库与上传目录替换现有文件，返回原文件被移到的位置。
+// 任一步失败都会删除已放入的新数据库，并把移走的原文件按相反顺序移回。
+func swapRestoredFiles(stagedDatabase, databasePath, stagedUploads, uploadDir, suffix string) (string, string, error) {
+	var moved []movedAside
+	swapped := false
+	rollback := func() {
+		if swapped {
+			_ = os.Remove(databasePath)
+		}
+		for i := len(moved) - 1; i >= 0; i-- {
+			_ = os.Rename(moved[i].moved, moved[i].target)
+		}
+	}
+	moveTarget := func(target string) (string, error) {
+		previous, err := moveAside(target, suffix)
+		if err != nil {
+			rollback()
+			return "", err
+		}
+		if previous != "" {
+			moved = append(moved, movedAside{target: target, moved: previous})
+		}
+		return previous, nil
+	}
+
+	previousDatabase, err := moveTarget(databasePath)
+	if err != nil {
+		return "", "", err
+	}
+	// WAL 与共享内存文件属于旧数据库，必须一并移走
+	for _, sidecar := range []string{"-wal", "-shm"} {
+		if _, err := moveTarget(databasePath + sidecar); err != nil {
+			return "", "", err
+		}
+	}
+	if err := os.Rename(stagedDatabase, databasePath); err != nil {
+		rollback()
+		return "", "", fmt.Errorf("swap database: %w", err)
+	}
+	swapped = true
+
+	previousUploads, err := moveTarget(uploadDir)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.Rename(stagedUploads, uploadDir); err != nil {
+		rollback()
+		return "", "", fmt.Errorf("swap uploads: %w", err)
+	}
+	return previousDatabase, previousUploads, nil
+}
+
+// unpackBackupBundle 将备份包解到暂存目录，同时计算各文件的 SHA-256。
+func unpackBackupBundle(bundle io.Reader, staging string) (*BackupManifest, map[string]string, error) {
+	allowed := map[string]bool{
+		backupManifestName:  true,
+		backupChecksumsName: true,
+		backupDatabaseName:  true,
+		backupUploadsName:   true,
+	}
+	checksums := make(map[string]string, len(allowed))
+
+	reader := tar.NewReader(bundle)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+		}
+		if header.Typeflag != tar.TypeReg || !allowed[header.Name] {
+			return nil, nil, fmt.Errorf("%w: unexpected entry %q", ErrBackupInvalid, header.Name)
+		}
+
+		out, err := os.Create(filepath.Join(staging, header.Name))
+		if err != nil {
+			return nil, nil, err
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(out, hash), reader); err != nil {
+			out.Close()
+			return nil, nil, fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+		}
+		if err := out.Close(); err != nil {
+			return nil, nil, err
+		}
+		checksums[header.Name] = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	data, err := os.ReadFile(filepath.Join(staging, backupManifestName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: manifest missing", ErrBackupInvalid)
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+	}
+	return &manifest, checksums, nil
+}
+
+func verifyBackupManifest(manifest *BackupManifest, checksums map[string]string) error {
+	if manifest.FormatVersion != BackupFormatVersion {
+		return fmt.Errorf("%w: unsupported format version %d", ErrBackupInvalid, manifest.FormatVersion)
+	}
+	if manifest.SchemaVersion > db.SchemaVersion {
+		return fmt.Errorf("%w: backup schema %d, current schema %d", ErrBackupSchemaTooNew, manifest.SchemaVersion, db.SchemaVersion)
+	}
+
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, file := range manifest.Files {
+		listed[file.Name] = true
+		actual, ok := checksums[file.Name]
+		if !ok {
+			return fmt.Errorf("%w: %s missing", ErrBackupInvalid, file.Name)
+		}
+		if !strings.EqualFold(actual, file.SHA256) {
+			return fmt.Errorf("%w: %s", ErrBackupChecksum, file.Name)
+		}
+	}
+	for _, required := range []string{backupDatabaseName, backupUploadsName} {
+		if !listed[required] {
+			return fmt.Errorf("%w: %s not listed in manifest", ErrBackupInvalid, required)
+		}
+	}
+	return nil
+}
+
+// validateBackupDatabase 检查快照完整性，并在暂存副本上执行迁移，确认能升级到当前结构。
+func validateBackupDatabase(path string) error {
+	gdb, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBackupSchemaIncompatible, err)
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	var integrity string
+	if err := gdb.Raw("PRAGMA integrity_check").Scan(&integrity).Error; err != nil || integrity != "ok" {
+		return fmt.Errorf("%w: integrity check failed: %s %v", ErrBackupSchemaIncompatible, integrity, err)
+	}
+
+	migrator := gdb.Migrator()
+	for _, model := range []interface{}{&db.User{}, &db.Post{}, &db.PostPublication{}} {
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("%w: required tables missing", ErrBackupSchemaIncompatible)
+		}
+	}
+	if err := db.Migrate(gdb); err != nil {
+		return fmt.Errorf("%w: %v", ErrBackupSchemaIncompatible, err)
+	}
+	return nil
+}
+
+// extractUploadsArchive 解压上传文件归档，拒绝越出目标目录的条目。
+func extractUploadsArchive(archivePath, target string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return err
+	}
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		rel := path.Clean(header.Name)
+		if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+			return fmt.Errorf("%w: unsafe upload path %q", ErrBackupInvalid, header.Name)
+		}
+
+		dest := filepath.Join(target, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, reader); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		if !header.ModTime.IsZero() {
+			_ = os.Chtimes(dest, header.ModTime, header.ModTime)
+		}
+	}
+}
+
+// moveAside 将已存在的文件或目录重命名为带后缀的备份名，返回新路径；不存在时返回空字符串。
+func moveAside(target, suffix string) (string, error) {
+	if _, err := os.Lstat(target); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	moved := target + suffix
+	if err := os.Rename(target, moved); err != nil {
+		return "", err
+	}
+	return moved, nil
+}
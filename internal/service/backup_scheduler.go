@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultBackupInterval 是定时备份的默认间隔。
+const DefaultBackupInterval = 24 * time.Hour
+
+// backupCheckInterval 是检查是否需要备份的轮询间隔，重启后也能按上次备份时间续上节奏。
+const backupCheckInterval = 10 * time.Minute
+
+// BackupScheduler 在后台按间隔将备份写入本地目录，并只保留最近的若干份。
+type BackupScheduler struct {
+	backups  *BackupService
+	dir      string
+	keep     int
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewBackupScheduler 创建定时备份任务，interval 非正数时使用默认间隔。
+func NewBackupScheduler(backups *BackupService, dir string, keep int, interval time.Duration) *BackupScheduler {
+	if interval <= 0 {
+		interval = DefaultBackupInterval
+	}
+	return &BackupScheduler{backups: backups, dir: dir, keep: keep, interval: interval, now: time.Now}
+}
+
+// Run 持续检查并执行备份直到 ctx 被取消，启动时先检查一次。
+func (s *BackupScheduler) Run(ctx context.Context) {
+	s.RunOnce()
+
+	ticker := time.NewTicker(backupCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunOnce()
+		}
+	}
+}
+
+// RunOnce 在距上次备份超过间隔时生成新备份，返回是否执行了备份。
+func (s *BackupScheduler) RunOnce() bool {
+	latest, err := LatestBackupTime(s.dir)
+	if err != nil {
+		log.Printf("[backup] read backup directory failed: %v", err)
+		return false
+	}
+	if !latest.IsZero() && s.now().Sub(latest) < s.interval {
+		return false
+	}
+
+	path, err := s.backups.WriteToDir(s.dir, s.keep)
+	if err != nil {
+		log.Printf("[backup] write backup failed: %v", err)
+		return false
+	}
+	log.Printf("[backup] wrote %s", path)
+	return true
+}
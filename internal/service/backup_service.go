@@ -0,0 +1,314 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+const (
+	// BackupFormatVersion 是备份包结构的版本号
+	BackupFormatVersion = 1
+
+	backupManifestName  = "manifest.json"
+	backupChecksumsName = "SHA256SUMS"
+	backupDatabaseName  = "commitlog.db"
+	backupUploadsName   = "uploads.tar.gz"
+	backupFilePrefix    = "commitlog-backup-"
+	backupFileSuffix    = ".tar"
+)
+
+var (
+	ErrBackupInvalid            = errors.New("backup bundle is invalid")
+	ErrBackupChecksum           = errors.New("backup checksum mismatch")
+	ErrBackupSchemaTooNew       = errors.New("backup schema is newer than this build")
+	ErrBackupSchemaIncompatible = errors.New("backup database schema is incompatible")
+	ErrBackupDirNotSpecified    = errors.New("backup directory is not specified")
+)
+
+// BackupManifest 描述备份包中的文件及生成时的结构版本。
+type BackupManifest struct {
+	FormatVersion int          `json:"format_version"`
+	SchemaVersion int          `json:"schema_version"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Files         []BackupFile `json:"files"`
+}
+
+// BackupFile 记录备份包内单个文件的大小与校验和。
+type BackupFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Entries 为上传文件归档中包含的文件数量
+	Entries int `json:"entries,omitempty"`
+}
+
+// BackupService 在不停机的情况下生成数据库与上传文件的一致性备份。
+//
+// 备份包是一个 tar 文件，依次包含 manifest.json、SHA256SUMS、
+// 通过 VACUUM INTO 生成的数据库快照 commitlog.db 以及上传目录归档 uploads.tar.gz。
+type BackupService struct {
+	db        *gorm.DB
+	uploadDir string
+	now       func() time.Time
+}
+
+// NewBackupService 创建备份服务。
+func NewBackupService(gdb *gorm.DB, uploadDir string) *BackupService {
+	return &BackupService{db: gdb, uploadDir: uploadDir, now: time.Now}
+}
+
+// BackupFileName 返回指定时间生成的备份文件名。
+func BackupFileName(at time.Time) string {
+	return backupFilePrefix + at.UTC().Format("20060102-150405") + backupFileSuffix
+}
+
+// Write 生成备份并以 tar 流写入 w。
+func (s *BackupService) Write(w io.Writer) (*BackupManifest, error) {
+	staging, err := os.MkdirTemp("", "commitlog-backup-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest := &BackupManifest{
+		FormatVersion: BackupFormatVersion,
+		SchemaVersion: db.SchemaVersion,
+		CreatedAt:     s.now().UTC(),
+	}
+
+	databasePath := filepath.Join(staging, backupDatabaseName)
+	// VACUUM INTO 在读事务中生成一致的数据库副本，不阻塞写入
+	if err := s.db.Exec("VACUUM INTO ?", databasePath).Error; err != nil {
+		return nil, fmt.Errorf("snapshot database: %w", err)
+	}
+	databaseFile, err := describeBackupFile(databasePath, backupDatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadsPath := filepath.Join(staging, backupUploadsName)
+	entries, err := archiveUploads(s.uploadDir, uploadsPath)
+	if err != nil {
+		return nil, fmt.Errorf("archive uploads: %w", err)
+	}
+	uploadsFile, err := describeBackupFile(uploadsPath, backupUploadsName)
+	if err != nil {
+		return nil, err
+	}
+	uploadsFile.Entries = entries
+	manifest.Files = []BackupFile{*databaseFile, *uploadsFile}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	var checksums strings.Builder
+	for _, file := range manifest.Files {
+		fmt.Fprintf(&checksums, "%s  %s\n", file.SHA256, file.Name)
+	}
+
+	bundle := tar.NewWriter(w)
+	if err := writeTarBytes(bundle, backupManifestName, manifestData, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := writeTarBytes(bundle, backupChecksumsName, []byte(checksums.String()), manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{backupDatabaseName, backupUploadsName} {
+		if err := writeTarFile(bundle, filepath.Join(staging, name), name); err != nil {
+			return nil, err
+		}
+	}
+	if err := bundle.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// WriteToDir 在 dir 中生成一份备份，并只保留最新的 keep 份；keep 非正数时不清理。
+func (s *BackupService) WriteToDir(dir string, keep int) (string, error) {
+	if strings.TrimSpace(dir) == "" {
+		return "", ErrBackupDirNotSpecified
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(dir, BackupFileName(s.now()))
+	temp, err := os.CreateTemp(dir, ".backup-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := s.Write(temp); err != nil {
+		temp.Close()
+		return "", err
+	}
+	if err := temp.Close(); err != nil {
+		return "", err
+	}
+	// 写完后再重命名，避免留下不完整的备份文件
+	if err := os.Rename(temp.Name(), target); err != nil {
+		return "", err
+	}
+
+	if keep > 0 {
+		if err := pruneBackups(dir, keep); err != nil {
+			return target, err
+		}
+	}
+	return target, nil
+}
+
+// LatestBackupTime 返回目录中最新备份的生成时间，没有备份时返回零值。
+func LatestBackupTime(dir string) (time.Time, error) {
+	names, err := listBackupFiles(dir)
+	if err != nil || len(names) == 0 {
+		return time.Time{}, err
+	}
+	latest := names[len(names)-1]
+	stamp := strings.TrimSuffix(strings.TrimPrefix(latest, backupFilePrefix), backupFileSuffix)
+	return time.ParseInLocation("20060102-150405", stamp, time.UTC)
+}
+
+// listBackupFiles 按时间升序返回目录中的备份文件名，文件名中的 UTC 时间戳可直接按字典序排序。
+func listBackupFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func pruneBackups(dir string, keep int) error {
+	names, err := listBackupFiles(dir)
+	if err != nil {
+		return err
+	}
+	for len(names) > keep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// archiveUploads 将上传目录打包为 tar.gz，目录不存在时生成空归档。
+func archiveUploads(uploadDir, target string) (int, error) {
+	out, err := os.Create(target)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	archive := tar.NewWriter(gz)
+	entries := 0
+
+	root := strings.TrimSpace(uploadDir)
+	if root != "" {
+		if _, statErr := os.Stat(root); statErr == nil {
+			err = filepath.WalkDir(root, func(current string, entry os.DirEntry, walkErr error) error {
+				if walkErr != nil {
+					return walkErr
+				}
+				if !entry.Type().IsRegular() {
+					return nil
+				}
+				rel, relErr := filepath.Rel(root, current)
+				if relErr != nil {
+					return relErr
+				}
+				entries++
+				return writeTarFile(archive, current, filepath.ToSlash(rel))
+			})
+			if err != nil {
+				return 0, err
+			}
+		} else if !errors.Is(statErr, os.ErrNotExist) {
+			return 0, statErr
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	return entries, out.Close()
+}
+
+func describeBackupFile(path, name string) (*BackupFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+	return &BackupFile{Name: name, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func writeTarBytes(archive *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(data)
+	return err
+}
+
+func writeTarFile(archive *tar.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(archive, file)
+	return err
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// rewriteBackupEntry 复制备份包并用 mutate 修改指定条目的内容。
+func rewriteBackupEntry(t *testing.T, bundle []byte, name string, mutate func([]byte) []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	writer := tar.NewWriter(&out)
+	reader := tar.NewReader(bytes.NewReader(bundle))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read bundle: %v", err)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("read entry: %v", err)
+		}
+		if header.Name == name {
+			data = mutate(data)
+			header.Size = int64(len(data))
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if _, err := writer.Write(data); err != nil {
+			t.Fatalf("write entry: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close bundle: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestBackupService_WriteAndRestore(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	posts := NewPostService(gdb)
+	uploadDir := t.TempDir()
+	writeUploadFile(t, uploadDir, "cover.jpg")
+	if err := os.MkdirAll(filepath.Join(uploadDir, "2025"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeUploadFile(t, filepath.Join(uploadDir, "2025"), "inline.png")
+
+	user := db.User{Username: "backup-author"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	post, err := posts.Create(PostInput{Content: "# 备份文章\n正文", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	var bundle bytes.Buffer
+	manifest, err := NewBackupService(gdb, uploadDir).Write(&bundle)
+	if err != nil {
+		t.Fatalf("write backup: %v", err)
+	}
+	if manifest.SchemaVersion != db.SchemaVersion || len(manifest.Files) != 2 || manifest.Files[1].Entries != 2 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	restoreRoot := t.TempDir()
+	databasePath := filepath.Join(restoreRoot, "commitlog.db")
+	restoreUploads := filepath.Join(restoreRoot, "uploads")
+	if err := os.WriteFile(databasePath, []byte("old database"), 0o644); err != nil {
+		t.Fatalf("write old database: %v", err)
+	}
+	if err := os.MkdirAll(restoreUploads, 0o755); err != nil {
+		t.Fatalf("mkdir uploads: %v", err)
+	}
+	writeUploadFile(t, restoreUploads, "old.jpg")
+
+	// 校验失败时不能动到现有文件
+	tampered := rewriteBackupEntry(t, bundle.Bytes(), backupDatabaseName, func(data []byte) []byte {
+		data[len(data)-1] ^= 0xff
+		return data
+	})
+	if _, err := RestoreBackup(bytes.NewReader(tampered), databasePath, restoreUploads); !errors.Is(err, ErrBackupChecksum) {
+		t.Fatalf("expected ErrBackupChecksum, got %v", err)
+	}
+	tooNew := rewriteBackupEntry(t, bundle.Bytes(), backupManifestName, func(data []byte) []byte {
+		var m BackupManifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatalf("decode manifest: %v", err)
+		}
+		m.SchemaVersion = db.SchemaVersion + 1
+		encoded, _ := json.Marshal(m)
+		return encoded
+	})
+	if _, err := RestoreBackup(bytes.NewReader(tooNew), databasePath, restoreUploads); !errors.Is(err, ErrBackupSchemaTooNew) {
+		t.Fatalf("expected ErrBackupSchemaTooNew, got %v", err)
+	}
+	if data, _ := os.ReadFile(databasePath); string(data) != "old database" {
+		t.Fatal("expected failed restore to keep the existing database")
+	}
+
+	// 运行中的服务持有数据库文件锁，此时必须拒绝恢复
+	lock, err := db.LockFile(databasePath)
+	if err != nil {
+		t.Fatalf("lock database: %v", err)
+	}
+	if _, err := RestoreBackup(bytes.NewReader(bundle.Bytes()), databasePath, restoreUploads); !errors.Is(err, db.ErrDatabaseInUse) {
+		t.Fatalf("expected ErrDatabaseInUse, got %v", err)
+	}
+	lock.Release()
+
+	result, err := RestoreBackup(bytes.NewReader(bundle.Bytes()), databasePath, restoreUploads)
+	if err != nil {
+		t.Fatalf("restore backup: %v", err)
+	}
+	if data, _ := os.ReadFile(result.PreviousDatabase); string(data) != "old database" {
+		t.Fatalf("expected previous database to be kept at %s", result.PreviousDatabase)
+	}
+	if _, err := os.Stat(filepath.Join(result.PreviousUploads, "old.jpg")); err != nil {
+		t.Fatalf("expected previous uploads to be kept: %v", err)
+	}
+	for _, name := range []string{"cover.jpg", "2025/inline.png"} {
+		if _, err := os.Stat(filepath.Join(restoreUploads, filepath.FromSlash(name))); err != nil {
+			t.Fatalf("expected %s to be restored: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(restoreUploads, "old.jpg")); !os.IsNotExist(err) {
+		t.Fatalf("expected uploads directory to be replaced, got %v", err)
+	}
+
+	restored, err := gorm.Open(sqlite.Open(databasePath), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open restored database: %v", err)
+	}
+	sqlDB, _ := restored.DB()
+	defer sqlDB.Close()
+	var restoredPost db.Post
+	if err := restored.First(&restoredPost, post.ID).Error; err != nil {
+		t.Fatalf("expected post in restored database: %v", err)
+	}
+	if restoredPost.Title != "备份文章" {
+		t.Fatalf("unexpected restored post: %+v", restoredPost)
+	}
+}
+
+func TestSwapRestoredFiles_RollsBackWhenUploadsSwapFails(t *testing.T) {
+	root := t.TempDir()
+	databasePath := filepath.Join(root, "commitlog.db")
+	uploadDir := filepath.Join(root, "uploads")
+	for name, content := range map[string]string{
+		databasePath:          "old database",
+		databasePath + "-wal": "old wal",
+	} {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := os.MkdirAll(uploadDir, 0o755); err != nil {
+		t.Fatalf("mkdir uploads: %v", err)
+	}
+	writeUploadFile(t, uploadDir, "old.jpg")
+
+	stagedDatabase := filepath.Join(root, "staged.db")
+	if err := os.WriteFile(stagedDatabase, []byte("new database"), 0o644); err != nil {
+		t.Fatalf("write staged database: %v", err)
+	}
+	// 暂存的上传目录不存在，替换上传目录时失败
+	missingUploads := filepath.Join(root, "missing-uploads")
+	if _, _, err := swapRestoredFiles(stagedDatabase, databasePath, missingUploads, uploadDir, ".pre-restore-test"); err == nil {
+		t.Fatal("expected uploads swap to fail")
+	}
+
+	if data, _ := os.ReadFile(databasePath); string(data) != "old database" {
+		t.Fatalf("expected previous database to be moved back, got %q", data)
+	}
+	if data, _ := os.ReadFile(databasePath + "-wal"); string(data) != "old wal" {
+		t.Fatalf("expected previous wal to be moved back, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(uploadDir, "old.jpg")); err != nil {
+		t.Fatalf("expected previous uploads to be moved back: %v", err)
+	}
+	if _, err := os.Stat(databasePath + ".pre-restore-test"); !os.IsNotExist(err) {
+		t.Fatalf("expected no leftover aside copy, got %v", err)
+	}
+}
+
+func TestBackupService_WriteToDirKeepsLatest(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	backups := NewBackupService(gdb, t.TempDir())
+	dir := t.TempDir()
+
+	start := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		at := start.Add(time.Duration(i) * 24 * time.Hour)
+		backups.now = func() time.Time { return at }
+		if _, err := backups.WriteToDir(dir, 2); err != nil {
+			t.Fatalf("write backup %d: %v", i, err)
+		}
+	}
+
+	names, err := listBackupFiles(dir)
+	if err != nil {
+		t.Fatalf("list backups: %v", err)
+	}
+	if len(names) != 2 || names[0] != BackupFileName(start.Add(24*time.Hour)) {
+		t.Fatalf("expected the two latest backups to remain, got %v", names)
+	}
+
+	scheduler := NewBackupScheduler(backups, dir, 2, 24*time.Hour)
+	scheduler.now = func() time.Time { return start.Add(50 * time.Hour) }
+	if scheduler.RunOnce() {
+		t.Fatal("expected scheduler to skip while the latest backup is fresh")
+	}
+	scheduler.now = func() time.Time { return start.Add(72 * time.Hour) }
+	backups.now = scheduler.now
+	if !scheduler.RunOnce() {
+		t.Fatal("expected scheduler to write a backup once the interval has passed")
+	}
+}
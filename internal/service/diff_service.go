@@ -0,0 +1,538 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDiffRefInvalid       = errors.New("diff reference is invalid")
+	ErrDraftVersionNotFound = errors.New("draft version not found")
+)
+
+// DefaultDiffContext 是每个差异块前后保留的上下文行数。
+const DefaultDiffContext = 3
+
+// DiffOp 表示差异中的操作类型。
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffSegment 是行内按词比较得到的片段。
+type DiffSegment struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLine 表示差异块中的一行，修改行会附带词级片段。
+type DiffLine struct {
+	Op       DiffOp        `json:"op"`
+	OldLine  int           `json:"old_line,omitempty"`
+	NewLine  int           `json:"new_line,omitempty"`
+	Text     string        `json:"text"`
+	Segments []DiffSegment `json:"segments,omitempty"`
+}
+
+// DiffHunk 对应统一 diff 格式中的一个 @@ 块。
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// Header 返回统一 diff 格式的块头。
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// DiffSide 描述参与比较的一侧内容来源。
+type DiffSide struct {
+	Ref     string `json:"ref"`
+	Label   string `json:"label"`
+	Title   string `json:"title"`
+	Content string `json:"-"`
+}
+
+// PostDiff 汇总两个文章版本之间的差异。
+type PostDiff struct {
+	From      DiffSide   `json:"from"`
+	To        DiffSide   `json:"to"`
+	Hunks     []DiffHunk `json:"hunks"`
+	Additions int        `json:"additions"`
+	Deletions int        `json:"deletions"`
+}
+
+// DiffService 比较文章当前草稿、草稿版本与发布版本之间的差异。
+type DiffService struct {
+	db *gorm.DB
+}
+
+// NewDiffService creates a DiffService instance.
+func NewDiffService(gdb *gorm.DB) *DiffService {
+	return &DiffService{db: gdb}
+}
+
+// ComparePost 比较文章的两个版本。
+// 引用格式：current 表示当前草稿，draft:N 表示草稿版本 N，
+// publication 表示最新发布版本，publication:N 表示发布版本 N。
+func (s *DiffService) ComparePost(postID uint, fromRef, toRef string, context int) (*PostDiff, error) {
+	var post db.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	from, err := s.resolveSide(&post, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.resolveSide(&post, toRef)
+	if err != nil {
+		return nil, err
+	}
+
+	hunks := DiffText(from.Content, to.Content, context)
+	result := &PostDiff{From: from, To: to, Hunks: hunks}
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case DiffInsert:
+				result.Additions++
+			case DiffDelete:
+				result.Deletions++
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *DiffService) resolveSide(post *db.Post, ref string) (DiffSide, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(ref))
+	kind, rawVersion, hasVersion := strings.Cut(trimmed, ":")
+
+	switch kind {
+	case "current", "":
+		if hasVersion {
+			return DiffSide{}, ErrDiffRefInvalid
+		}
+		return DiffSide{Ref: "current", Label: "当前草稿", Title: post.Title, Content: post.Content}, nil
+	case "draft":
+		version, err := strconv.Atoi(rawVersion)
+		if !hasVersion || err != nil || version <= 0 {
+			return DiffSide{}, ErrDiffRefInvalid
+		}
+		var draft db.PostDraftVersion
+		if err := s.db.Where("post_id = ? AND version = ?", post.ID, version).
+			Order("id desc").
+			First(&draft).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return DiffSide{}, ErrDraftVersionNotFound
+			}
+			return DiffSide{}, err
+		}
+		return DiffSide{
+			Ref:     fmt.Sprintf("draft:%d", version),
+			Label:   fmt.Sprintf("草稿版本 %d", version),
+			Title:   draft.Title,
+			Content: draft.Content,
+		}, nil
+	case "publication":
+		query := s.db.Where("post_id = ?", post.ID)
+		if hasVersion && rawVersion != "latest" {
+			version, err := strconv.Atoi(rawVersion)
+			if err != nil || version <= 0 {
+				return DiffSide{}, ErrDiffRefInvalid
+			}
+			query = query.Where("version = ?", version)
+		}
+		var publication db.PostPublication
+		if err := query.Order("version desc, id desc").First(&publication).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return DiffSide{}, ErrPublicationNotFound
+			}
+			return DiffSide{}, err
+		}
+		return DiffSide{
+			Ref:     fmt.Sprintf("publication:%d", publication.Version),
+			Label:   fmt.Sprintf("发布版本 %d", publication.Version),
+			Title:   publication.Title,
+			Content: publication.Content,
+		}, nil
+	default:
+		return DiffSide{}, ErrDiffRefInvalid
+	}
+}
+
+// DiffText 计算两段文本的行级差异，并为成对的修改行补充词级差异。
+// context 为负数时使用 DefaultDiffContext。
+func DiffText(oldText, newText string, context int) []DiffHunk {
+	if context < 0 {
+		context = DefaultDiffContext
+	}
+
+	oldLines := splitDiffLines(oldText)
+	newLines := splitDiffLines(newText)
+	edits := diffTokens(oldLines, newLines)
+
+	lines := make([]DiffLine, 0, len(edits))
+	oldNo, newNo := 0, 0
+	for _, edit := range edits {
+		line := DiffLine{Op: edit.op, Text: edit.text}
+		switch edit.op {
+		case DiffEqual:
+			oldNo++
+			newNo++
+			line.OldLine, line.NewLine = oldNo, newNo
+		case DiffDelete:
+			oldNo++
+			line.OldLine = oldNo
+		case DiffInsert:
+			newNo++
+			line.NewLine = newNo
+		}
+		lines = append(lines, line)
+	}
+
+	attachWordSegments(lines)
+	return groupHunks(lines, context)
+}
+
+// DiffWords 按词比较两行文本，中日韩文字逐字切分。
+func DiffWords(oldText, newText string) []DiffSegment {
+	edits := diffTokens(tokenizeWords(oldText), tokenizeWords(newText))
+	segments := make([]DiffSegment, 0, len(edits))
+	for _, edit := range edits {
+		if n := len(segments); n > 0 && segments[n-1].Op == edit.op {
+			segments[n-1].Text += edit.text
+			continue
+		}
+		segments = append(segments, DiffSegment{Op: edit.op, Text: edit.text})
+	}
+	return segments
+}
+
+// RenderDiffHTML 将差异块渲染为带行号的 HTML 片段，所有文本均已转义。
+func RenderDiffHTML(hunks []DiffHunk) string {
+	var builder strings.Builder
+	builder.WriteString(`<div class="diff">`)
+	if len(hunks) == 0 {
+		builder.WriteString(`<div class="diff-empty">两个版本内容一致</div>`)
+	}
+	for _, hunk := range hunks {
+		builder.WriteString(`<div class="diff-hunk"><div class="diff-hunk-header">`)
+		builder.WriteString(html.EscapeString(hunk.Header()))
+		builder.WriteString(`</div>`)
+		for _, line := range hunk.Lines {
+			builder.WriteString(`<div class="diff-line diff-`)
+			builder.WriteString(string(line.Op))
+			builder.WriteString(`"><span class="diff-line-number">`)
+			if line.OldLine > 0 {
+				builder.WriteString(strconv.Itoa(line.OldLine))
+			}
+			builder.WriteString(`</span><span class="diff-line-number">`)
+			if line.NewLine > 0 {
+				builder.WriteString(strconv.Itoa(line.NewLine))
+			}
+			builder.WriteString(`</span><span class="diff-line-content">`)
+			if len(line.Segments) == 0 {
+				builder.WriteString(html.EscapeString(line.Text))
+			}
+			for _, segment := range line.Segments {
+				text := html.EscapeString(segment.Text)
+				switch segment.Op {
+				case DiffInsert:
+					builder.WriteString("<ins>" + text + "</ins>")
+				case DiffDelete:
+					builder.WriteString("<del>" + text + "</del>")
+				default:
+					builder.WriteString(text)
+				}
+			}
+			builder.WriteString(`</span></div>`)
+		}
+		builder.WriteString(`</div>`)
+	}
+	builder.WriteString(`</div>`)
+	return builder.String()
+}
+
+type diffEdit struct {
+	op   DiffOp
+	text string
+}
+
+// diffTokens 使用 Myers 算法计算最短编辑脚本。
+func diffTokens(a, b []string) []diffEdit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]diffEdit, 0, len(a)+len(b))
+	for _, token := range a[:prefix] {
+		edits = append(edits, diffEdit{op: DiffEqual, text: token})
+	}
+	edits = append(edits, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, token := range a[len(a)-suffix:] {
+		edits = append(edits, diffEdit{op: DiffEqual, text: token})
+	}
+	return edits
+}
+
+// maxDiffEditDistance 是 Myers 算法搜索的最大编辑距离。回溯需要保存每一轮的状态，
+// 内存随编辑距离平方增长，超过上限的差异（如整篇重写）按整段删除再插入处理。
+const maxDiffEditDistance = 1000
+
+func myersDiff(a, b []string) []diffEdit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	limit := n + m
+	v := make([]int, 2*limit+2)
+	// trace[d] 保存第 d 轮开始前 [-d, d] 范围内的 v，用于回溯
+	trace := make([][]int, 0, limit+1)
+
+	found := -1
+	for d := 0; d <= limit && d <= maxDiffEditDistance && found < 0; d++ {
+		trace = append(trace, append([]int(nil), v[limit-d:limit+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[limit+k-1] < v[limit+k+1]) {
+				x = v[limit+k+1]
+			} else {
+				x = v[limit+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[limit+k] = x
+			if x >= n && y >= m {
+				found = d
+				break
+			}
+		}
+	}
+	if found < 0 {
+		return replaceDiffBlock(a, b)
+	}
+
+	reversed := make([]diffEdit, 0, n+m)
+	x, y := n, m
+	for d := found; d > 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffEdit{op: DiffEqual, text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, diffEdit{op: DiffInsert, text: b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, diffEdit{op: DiffDelete, text: a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, diffEdit{op: DiffEqual, text: a[x-1]})
+		x--
+		y--
+	}
+
+	edits := make([]diffEdit, len(reversed))
+	for i, edit := range reversed {
+		edits[len(reversed)-1-i] = edit
+	}
+	return edits
+}
+
+// replaceDiffBlock 将 a 整段删除后插入 b。
+func replaceDiffBlock(a, b []string) []diffEdit {
+	edits := make([]diffEdit, 0, len(a)+len(b))
+	for _, token := range a {
+		edits = append(edits, diffEdit{op: DiffDelete, text: token})
+	}
+	for _, token := range b {
+		edits = append(edits, diffEdit{op: DiffInsert, text: token})
+	}
+	return edits
+}
+
+func splitDiffLines(text string) []string {
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	if normalized == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(normalized, "\n"), "\n")
+}
+
+// tokenizeWords 切分词级比较的 token：拉丁字母与数字按连续单词切分，
+// 中日韩文字逐字切分，空白与标点各自成为独立 token。
+func tokenizeWords(text string) []string {
+	tokens := make([]string, 0, len(text)/2)
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isCJKRune(r):
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && !isCJKRune(runes[j]) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case unicode.IsSpace(r):
+			j := i + 1
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+func isCJKRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// attachWordSegments 将相邻的删除行与新增行按顺序配对，补充词级差异。
+func attachWordSegments(lines []DiffLine) {
+	for i := 0; i < len(lines); {
+		if lines[i].Op != DiffDelete {
+			i++
+			continue
+		}
+		delStart := i
+		for i < len(lines) && lines[i].Op == DiffDelete {
+			i++
+		}
+		insStart := i
+		for i < len(lines) && lines[i].Op == DiffInsert {
+			i++
+		}
+
+		pairs := min(insStart-delStart, i-insStart)
+		for p := 0; p < pairs; p++ {
+			oldLine := &lines[delStart+p]
+			newLine := &lines[insStart+p]
+			segments := DiffWords(oldLine.Text, newLine.Text)
+			for _, segment := range segments {
+				if segment.Op != DiffInsert {
+					oldLine.Segments = append(oldLine.Segments, segment)
+				}
+				if segment.Op != DiffDelete {
+					newLine.Segments = append(newLine.Segments, segment)
+				}
+			}
+		}
+	}
+}
+
+func groupHunks(lines []DiffLine, context int) []DiffHunk {
+	hunks := make([]DiffHunk, 0)
+	i := 0
+	for i < len(lines) {
+		for i < len(lines) && lines[i].Op == DiffEqual {
+			i++
+		}
+		if i >= len(lines) {
+			break
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != DiffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].Op == DiffEqual {
+				run++
+			}
+			// 两处修改之间的相同行不超过两倍上下文时合并为同一个块
+			if run < len(lines) && run-end <= 2*context {
+				end = run
+				continue
+			}
+			end = min(end+context, len(lines))
+			break
+		}
+
+		hunk := DiffHunk{Lines: append([]DiffLine(nil), lines[start:end]...)}
+		for _, line := range hunk.Lines {
+			if line.Op != DiffInsert {
+				if hunk.OldStart == 0 {
+					hunk.OldStart = line.OldLine
+				}
+				hunk.OldLines++
+			}
+			if line.Op != DiffDelete {
+				if hunk.NewStart == 0 {
+					hunk.NewStart = line.NewLine
+				}
+				hunk.NewLines++
+			}
+		}
+		// 纯新增或纯删除时，另一侧起始行沿用统一 diff 的约定
+		if hunk.OldLines == 0 {
+			hunk.OldStart = precedingLine(lines, start, func(l DiffLine) int { return l.OldLine })
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart = precedingLine(lines, start, func(l DiffLine) int { return l.NewLine })
+		}
+		hunks = append(hunks, hunk)
+		i = end
+	}
+	return hunks
+}
+
+func precedingLine(lines []DiffLine, idx int, pick func(DiffLine) int) int {
+	for j := idx - 1; j >= 0; j-- {
+		if n := pick(lines[j]); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
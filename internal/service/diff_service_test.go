@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestDiffTextGroupsHunksWithContext(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk"
+
+	hunks := DiffText(oldText, newText, 2)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+	if got := hunks[0].Header(); got != "@@ -1,4 +1,4 @@" {
+		t.Fatalf("unexpected first hunk header %q", got)
+	}
+	if got := hunks[1].Header(); got != "@@ -9,2 +9,3 @@" {
+		t.Fatalf("unexpected second hunk header %q", got)
+	}
+
+	last := hunks[1].Lines[len(hunks[1].Lines)-1]
+	if last.Op != DiffInsert || last.NewLine != 11 || last.Text != "k" {
+		t.Fatalf("expected appended line k at 11, got %+v", last)
+	}
+
+	if hunks := DiffText("same\ntext", "same\ntext\n", 3); len(hunks) != 0 {
+		t.Fatalf("expected identical text to produce no hunks, got %d", len(hunks))
+	}
+}
+
+func TestDiffWordsSplitsCJKByCharacter(t *testing.T) {
+	segments := DiffWords("今天天气很好 ok", "今天天气不错 ok")
+	expected := []DiffSegment{
+		{Op: DiffEqual, Text: "今天天气"},
+		{Op: DiffDelete, Text: "很好"},
+		{Op: DiffInsert, Text: "不错"},
+		{Op: DiffEqual, Text: " ok"},
+	}
+	if len(segments) != len(expected) {
+		t.Fatalf("expected %d segments, got %+v", len(expected), segments)
+	}
+	for i := range expected {
+		if segments[i] != expected[i] {
+			t.Fatalf("segment %d: expected %+v, got %+v", i, expected[i], segments[i])
+		}
+	}
+
+	segments = DiffWords("use goroutine pool", "use worker pool")
+	if segments[1].Op != DiffDelete || segments[1].Text != "goroutine" {
+		t.Fatalf("expected latin words to be compared as whole words, got %+v", segments)
+	}
+}
+
+func TestDiffTextFallsBackToBlockReplaceForLargeRewrites(t *testing.T) {
+	oldLines := make([]string, 20000)
+	newLines := make([]string, 20000)
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("旧内容 %d", i)
+		newLines[i] = fmt.Sprintf("rewritten %d", i)
+	}
+	oldText := "标题\n" + strings.Join(oldLines, "\n")
+	newText := "标题\n" + strings.Join(newLines, "\n")
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	hunks := DiffText(oldText, newText, 0)
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 256<<20 {
+		t.Fatalf("expected bounded memory for a full rewrite, allocated %d MB", allocated>>20)
+	}
+	if len(hunks) != 1 {
+		t.Fatalf("expected a single hunk, got %d", len(hunks))
+	}
+	lines := hunks[0].Lines
+	if len(lines) != 40000 {
+		t.Fatalf("expected every line to be replaced, got %d lines", len(lines))
+	}
+	if lines[0].Op != DiffDelete || lines[0].Text != "旧内容 0" || lines[19999].Op != DiffDelete || lines[20000].Op != DiffInsert || lines[39999].Text != "rewritten 19999" {
+		t.Fatalf("expected deletions followed by insertions, got %+v ... %+v", lines[0], lines[39999])
+	}
+}
+
+func TestRenderDiffHTMLEscapesContent(t *testing.T) {
+	rendered := RenderDiffHTML(DiffText("<b>old</b>", "<b>new</b>", 3))
+	if strings.Contains(rendered, "<b>") {
+		t.Fatalf("expected markup to be escaped, got %s", rendered)
+	}
+	if !strings.Contains(rendered, "<del>old</del>") || !strings.Contains(rendered, "<ins>new</ins>") {
+		t.Fatalf("expected word level markers, got %s", rendered)
+	}
+}
+
+func TestDiffService_ComparePostVersions(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	diffs := NewDiffService(gdb)
+
+	user := db.User{Username: "differ"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	post := createSchedulablePost(t, svc, user.ID, "# 标题\n第一段\n第二段")
+	if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if _, err := svc.Update(post.ID, PostInput{
+		Content:     "# 标题\n第一段\n第二段修改\n第三段",
+		UserID:      user.ID,
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	diff, err := diffs.ComparePost(post.ID, "publication", "current", DefaultDiffContext)
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if diff.From.Ref != "publication:1" || diff.To.Ref != "current" {
+		t.Fatalf("unexpected refs %q -> %q", diff.From.Ref, diff.To.Ref)
+	}
+	if diff.Additions != 2 || diff.Deletions != 1 {
+		t.Fatalf("expected +2 -1, got +%d -%d", diff.Additions, diff.Deletions)
+	}
+
+	diff, err = diffs.ComparePost(post.ID, "draft:1", "draft:2", DefaultDiffContext)
+	if err != nil {
+		t.Fatalf("compare drafts: %v", err)
+	}
+	if diff.Additions == 0 {
+		t.Fatalf("expected draft versions to differ")
+	}
+
+	if _, err := diffs.ComparePost(post.ID, "draft:99", "current", DefaultDiffContext); !errors.Is(err, ErrDraftVersionNotFound) {
+		t.Fatalf("expected ErrDraftVersionNotFound, got %v", err)
+	}
+	if _, err := diffs.ComparePost(post.ID, "bogus", "current", DefaultDiffContext); !errors.Is(err, ErrDiffRefInvalid) {
+		t.Fatalf("expected ErrDiffRefInvalid, got %v", err)
+	}
+}
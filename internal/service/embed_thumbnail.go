@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// embedThumbnailDir 是缩略图在上传目录下的子目录。
+	embedThumbnailDir = "embeds"
+	// embedThumbnailMaxBytes 限制单张缩略图的大小。
+	embedThumbnailMaxBytes = 5 << 20
+	// embedMetadataMaxBytes 限制元数据接口响应的大小。
+	embedMetadataMaxBytes = 1 << 20
+	embedThumbnailTimeout = 10 * time.Second
+	// embedThumbnailRetryAfter 是下载失败后再次尝试的间隔。
+	embedThumbnailRetryAfter = 24 * time.Hour
+	// embedThumbnailFailedExt 是失败记录文件的扩展名，文件修改时间即失败时间。
+	embedThumbnailFailedExt = ".failed"
+)
+
+// embedThumbnailKeyPattern 限定缓存键只包含安全的文件名字符。
+var embedThumbnailKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// embedThumbnailExtensions 是允许缓存的图片类型及对应扩展名。
+var embedThumbnailExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// ErrEmbedThumbnailUnavailable 表示平台没有返回可用的缩略图。
+var ErrEmbedThumbnailUnavailable = errors.New("embed thumbnail unavailable")
+
+// EmbedThumbnailSource 描述如何获取某个嵌入内容的缩略图。
+//
+// ImageURL 可直接下载；否则请求 MetadataURL 返回的 JSON，
+// 按 MetadataField（以 . 分隔的字段路径，例如 data.pic）读取图片地址。
+type EmbedThumbnailSource struct {
+	Key           string
+	ImageURL      string
+	MetadataURL   string
+	MetadataField string
+}
+
+// EmbedThumbnailCache 将第三方缩略图下载到上传目录，读者访问时只加载本站图片。
+//
+// 下载只在保存或发布时于后台进行，渲染页面时只读取本地文件；
+// 下载失败会在缓存目录留下失败记录，间隔一天后才会重试。
+type EmbedThumbnailCache struct {
+	dir        string
+	urlPrefix  string
+	httpClient httpDoer
+	now        func() time.Time
+
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+// NewEmbedThumbnailCache 创建缩略图缓存，文件保存在 uploadDir/embeds 下。
+func NewEmbedThumbnailCache(uploadDir, uploadURL string) *EmbedThumbnailCache {
+	prefix := strings.TrimRight(strings.TrimSpace(uploadURL), "/")
+	if prefix == "" {
+		prefix = "/uploads"
+	}
+	return &EmbedThumbnailCache{
+		dir:        filepath.Join(uploadDir, embedThumbnailDir),
+		urlPrefix:  prefix + "/" + embedThumbnailDir,
+		httpClient: newPublicHTTPClient(embedThumbnailTimeout),
+		now:        time.Now,
+		inflight:   make(map[string]bool),
+	}
+}
+
+// SetHTTPClient 替换下载使用的 HTTP 客户端，主要用于测试。
+func (c *EmbedThumbnailCache) SetHTTPClient(client httpDoer) {
+	if client == nil {
+		client = newPublicHTTPClient(embedThumbnailTimeout)
+	}
+	c.httpClient = client
+}
+
+// Lookup 返回已缓存缩略图的访问地址，只读取本地文件。
+func (c *EmbedThumbnailCache) Lookup(key string) (string, bool) {
+	if c == nil || !embedThumbnailKeyPattern.MatchString(key) {
+		return "", false
+	}
+	for _, ext := range []string{".jpg", ".png", ".webp"} {
+		if info, err := os.Stat(filepath.Join(c.dir, key+ext)); err == nil && info.Mode().IsRegular() {
+			return c.urlPrefix + "/" + key + ext, true
+		}
+	}
+	return "", false
+}
+
+// Schedule 在后台下载尚未缓存的缩略图，调用方无需等待。
+// 同一个键同时只会下载一次，最近下载失败的键在重试间隔内跳过。
+func (c *EmbedThumbnailCache) Schedule(sources []EmbedThumbnailSource) {
+	if c == nil {
+		return
+	}
+	pending := c.pending(sources)
+	if len(pending) == 0 {
+		return
+	}
+	go func() {
+		defer c.release(pending)
+		for _, source := range pending {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*embedThumbnailTimeout)
+			if _, err := c.Fetch(ctx, source); err != nil {
+				log.Printf("[embed] cache thumbnail %s failed: %v", source.Key, err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// pending 过滤出需要下载且没有在下载中的缩略图，并标记为下载中。
+func (c *EmbedThumbnailCache) pending(sources []EmbedThumbnailSource) []EmbedThumbnailSource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var pending []EmbedThumbnailSource
+	for _, source := range sources {
+		if !embedThumbnailKeyPattern.MatchString(source.Key) || c.inflight[source.Key] {
+			continue
+		}
+		if _, ok := c.Lookup(source.Key); ok || c.recentlyFailed(source.Key) {
+			continue
+		}
+		c.inflight[source.Key] = true
+		pending = append(pending, source)
+	}
+	return pending
+}
+
+func (c *EmbedThumbnailCache) release(sources []EmbedThumbnailSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, source := range sources {
+		delete(c.inflight, source.Key)
+	}
+}
+
+// recentlyFailed 判断缩略图是否在重试间隔内下载失败过。
+func (c *EmbedThumbnailCache) recentlyFailed(key string) bool {
+	info, err := os.Stat(filepath.Join(c.dir, key+embedThumbnailFailedExt))
+	if err != nil {
+		return false
+	}
+	return c.now().Sub(info.ModTime()) < embedThumbnailRetryAfter
+}
+
+// recordFailure 写入失败记录，内容为失败原因，便于排查。
+func (c *EmbedThumbnailCache) recordFailure(key string, fetchErr error) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+	marker := filepath.Join(c.dir, key+embedThumbnailFailedExt)
+	if err := os.WriteFile(marker, []byte(truncateRunes(fetchErr.Error(), 500)), 0o644); err != nil {
+		return
+	}
+	now := c.now()
+	_ = os.Chtimes(marker, now, now)
+}
+
+// Fetch 下载缩略图并写入缓存，已缓存时直接返回访问地址；失败时写入失败记录。
+func (c *EmbedThumbnailCache) Fetch(ctx context.Context, source EmbedThumbnailSource) (string, error) {
+	if !embedThumbnailKeyPattern.MatchString(source.Key) {
+		return "", fmt.Errorf("invalid thumbnail key %q", source.Key)
+	}
+	if cached, ok := c.Lookup(source.Key); ok {
+		return cached, nil
+	}
+
+	cached, err := c.download(ctx, source)
+	if err != nil {
+		c.recordFailure(source.Key, err)
+		return "", err
+	}
+	_ = os.Remove(filepath.Join(c.dir, source.Key+embedThumbnailFailedExt))
+	return cached, nil
+}
+
+// download 解析图片地址并下载到缓存目录。
+func (c *EmbedThumbnailCache) download(ctx context.Context, source EmbedThumbnailSource) (string, error) {
+	imageURL := source.ImageURL
+	if imageURL == "" && source.MetadataURL != "" {
+		resolved, err := c.resolveMetadataImage(ctx, source.MetadataURL, source.MetadataField)
+		if err != nil {
+			return "", err
+		}
+		imageURL = resolved
+	}
+	if !strings.HasPrefix(imageURL, "https://") && !strings.HasPrefix(imageURL, "http://") {
+		return "", ErrEmbedThumbnailUnavailable
+	}
+
+	resp, err := c.get(ctx, imageURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0]))
+	ext, ok := embedThumbnailExtensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("unsupported thumbnail type %q", contentType)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, embedThumbnailMaxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("read thumbnail: %w", err)
+	}
+	if len(data) == 0 || len(data) > embedThumbnailMaxBytes {
+		return "", fmt.Errorf("thumbnail size %d out of range", len(data))
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return "", fmt.Errorf("create thumbnail directory: %w", err)
+	}
+	// 先写临时文件再改名，避免读者拿到写了一半的图片
+	tmp, err := os.CreateTemp(c.dir, source.Key+"-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("create thumbnail: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("write thumbnail: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("write thumbnail: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, source.Key+ext)); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("save thumbnail: %w", err)
+	}
+	return c.urlPrefix + "/" + source.Key + ext, nil
+}
+
+// resolveMetadataImage 请求 oEmbed 等 JSON 接口并读取缩略图地址。
+func (c *EmbedThumbnailCache) resolveMetadataImage(ctx context.Context, endpoint, field string) (string, error) {
+	resp, err := c.get(ctx, endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var payload any
+	if err := json.NewDecoder(io.LimitReader(resp.Body, embedMetadataMaxBytes)).Decode(&payload); err != nil {
+		return "", fmt.Errorf("decode thumbnail metadata: %w", err)
+	}
+	for _, name := range strings.Split(field, ".") {
+		object, ok := payload.(map[string]any)
+		if !ok {
+			return "", ErrEmbedThumbnailUnavailable
+		}
+		payload = object[name]
+	}
+	value, ok := payload.(string)
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", ErrEmbedThumbnailUnavailable
+	}
+	value = strings.TrimSpace(value)
+	// B 站接口返回的图片地址可能省略协议
+	if strings.HasPrefix(value, "//") {
+		value = "https:" + value
+	}
+	return value, nil
+}
+
+func (c *EmbedThumbnailCache) get(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build thumbnail request: %w", err)
+	}
+	req.Header.Set("User-Agent", "commitlog-embed/1.0")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %s: %w", target, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("request %s: unexpected status %d", target, resp.StatusCode)
+	}
+	return resp, nil
+}
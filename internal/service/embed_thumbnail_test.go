@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmbedThumbnailCache_FetchesOnceThroughMetadata(t *testing.T) {
+	uploadDir := t.TempDir()
+	cache := NewEmbedThumbnailCache(uploadDir, "/uploads/")
+
+	var requests []string
+	cache.SetHTTPClient(fakeHTTPClient{handler: func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.URL.String())
+		header := make(http.Header)
+		body := ""
+		switch req.URL.Host {
+		case "api.bilibili.com":
+			header.Set("Content-Type", "application/json")
+			body = `{"code":0,"data":{"pic":"//i0.hdslb.com/bfs/archive/cover.jpg"}}`
+		case "i0.hdslb.com":
+			header.Set("Content-Type", "image/jpeg")
+			body = "jpeg-bytes"
+		default:
+			t.Fatalf("unexpected request %s", req.URL)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}})
+
+	source := EmbedThumbnailSource{
+		Key:           "bilibili-BV1x5411c7mD",
+		MetadataURL:   "https://api.bilibili.com/x/web-interface/view?bvid=BV1x5411c7mD",
+		MetadataField: "data.pic",
+	}
+	if _, ok := cache.Lookup(source.Key); ok {
+		t.Fatal("expected empty cache before fetching")
+	}
+
+	url, err := cache.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("fetch thumbnail: %v", err)
+	}
+	if url != "/uploads/embeds/bilibili-BV1x5411c7mD.jpg" {
+		t.Fatalf("unexpected thumbnail url %q", url)
+	}
+	data, err := os.ReadFile(filepath.Join(uploadDir, "embeds", "bilibili-BV1x5411c7mD.jpg"))
+	if err != nil || string(data) != "jpeg-bytes" {
+		t.Fatalf("expected cached thumbnail on disk, got %q (%v)", data, err)
+	}
+	if len(requests) != 2 || requests[1] != "https://i0.hdslb.com/bfs/archive/cover.jpg" {
+		t.Fatalf("unexpected requests: %v", requests)
+	}
+
+	if cached, ok := cache.Lookup(source.Key); !ok || cached != url {
+		t.Fatalf("expected lookup to hit the cache, got %q %v", cached, ok)
+	}
+	if _, err := cache.Fetch(context.Background(), source); err != nil {
+		t.Fatalf("fetch cached thumbnail: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected cached thumbnail to skip the network, got %v", requests)
+	}
+}
+
+func TestEmbedThumbnailCache_RejectsUnsafeInput(t *testing.T) {
+	cache := NewEmbedThumbnailCache(t.TempDir(), "/uploads")
+	cache.SetHTTPClient(fakeHTTPClient{handler: func(req *http.Request) (*http.Response, error) {
+		header := make(http.Header)
+		header.Set("Content-Type", "text/html")
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader("<html>"))}, nil
+	}})
+
+	if _, err := cache.Fetch(context.Background(), EmbedThumbnailSource{Key: "../escape", ImageURL: "https://example.com/a.jpg"}); err == nil {
+		t.Fatal("expected path-like key to be rejected")
+	}
+	if _, err := cache.Fetch(context.Background(), EmbedThumbnailSource{Key: "youtube-abc", ImageURL: "https://example.com/a.jpg"}); err == nil {
+		t.Fatal("expected non-image response to be rejected")
+	}
+	if _, ok := cache.Lookup("youtube-abc"); ok {
+		t.Fatal("expected rejected thumbnail not to be cached")
+	}
+}
+
+func TestEmbedThumbnailCache_RecordsFailuresAndRetriesLater(t *testing.T) {
+	cache := NewEmbedThumbnailCache(t.TempDir(), "/uploads")
+	requests := 0
+	cache.SetHTTPClient(fakeHTTPClient{handler: func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}})
+	now := time.Date(2025, time.March, 1, 8, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	source := EmbedThumbnailSource{Key: "youtube-missing", ImageURL: "https://i.ytimg.com/vi/missing/hqdefault.jpg"}
+	if _, err := cache.Fetch(context.Background(), source); err == nil {
+		t.Fatal("expected missing thumbnail to fail")
+	}
+	if requests != 1 {
+		t.Fatalf("expected one request, got %d", requests)
+	}
+	if _, ok := cache.Lookup(source.Key); ok {
+		t.Fatal("expected failure record not to be served as a thumbnail")
+	}
+
+	if pending := cache.pending([]EmbedThumbnailSource{source}); len(pending) != 0 {
+		t.Fatalf("expected recent failure to be skipped, got %+v", pending)
+	}
+	now = now.Add(embedThumbnailRetryAfter)
+	pending := cache.pending([]EmbedThumbnailSource{source})
+	if len(pending) != 1 {
+		t.Fatalf("expected failure to be retried after the interval, got %+v", pending)
+	}
+	cache.release(pending)
+}
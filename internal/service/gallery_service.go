@@ -179,7 +179,7 @@ func (s *GalleryService) Update(id uint, input GalleryInput) (*db.GalleryImage,
 	return &item, nil
 }
 
-// Delete removes a gallery image.
+// Delete moves a gallery image into the trash.
 func (s *GalleryService) Delete(id uint) error {
 	var item db.GalleryImage
 	if err := s.db.First(&item, id).Error; err != nil {
@@ -188,7 +188,7 @@ func (s *GalleryService) Delete(id uint) error {
 		}
 		return err
 	}
-	return s.db.Unscoped().Delete(&item).Error
+	return s.db.Delete(&item).Error
 }
 
 func validateGalleryInput(input GalleryInput) error {
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// DefaultLinkCheckInterval 是定时检查失效链接的默认间隔。
+const DefaultLinkCheckInterval = 24 * time.Hour
+
+// linkCheckPollInterval 是检查是否需要执行的轮询间隔，重启后按上次检查时间续上节奏。
+const linkCheckPollInterval = 30 * time.Minute
+
+// LinkCheckScheduler 在后台按间隔执行失效链接检查。
+type LinkCheckScheduler struct {
+	checker  *LinkChecker
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewLinkCheckScheduler 创建定时链接检查任务，interval 非正数时使用默认间隔。
+func NewLinkCheckScheduler(checker *LinkChecker, interval time.Duration) *LinkCheckScheduler {
+	if interval <= 0 {
+		interval = DefaultLinkCheckInterval
+	}
+	return &LinkCheckScheduler{checker: checker, interval: interval, now: time.Now}
+}
+
+// Run 持续检查并执行链接检查直到 ctx 被取消，启动时先检查一次。
+func (s *LinkCheckScheduler) Run(ctx context.Context) {
+	s.RunOnce(ctx)
+
+	ticker := time.NewTicker(linkCheckPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce 在距上次检查超过间隔时执行检查，返回是否执行了检查。
+func (s *LinkCheckScheduler) RunOnce(ctx context.Context) bool {
+	latest, err := s.checker.LatestRunTime()
+	if err != nil {
+		log.Printf("[link-check] read latest run failed: %v", err)
+		return false
+	}
+	if !latest.IsZero() && s.now().Sub(latest) < s.interval {
+		return false
+	}
+
+	run, err := s.checker.Run(ctx)
+	if errors.Is(err, ErrLinkCheckRunning) {
+		return false
+	}
+	if err != nil {
+		log.Printf("[link-check] run failed: %v", err)
+		return false
+	}
+	log.Printf("[link-check] checked %d links, %d broken", run.Checked, run.Broken)
+	return true
+}
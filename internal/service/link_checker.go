@@ -0,0 +1,569 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+const (
+	// linkCheckConcurrency 限制同时进行的外部链接请求数量。
+	linkCheckConcurrency = 8
+	// linkCheckTimeout 是单个外部链接的请求超时。
+	linkCheckTimeout = 10 * time.Second
+	// linkCheckStaleAfter 之后仍未结束的检查视为中断，允许重新开始。
+	linkCheckStaleAfter = time.Hour
+)
+
+// ErrLinkCheckRunning 表示已有检查正在进行。
+var ErrLinkCheckRunning = errors.New("link check already running")
+
+var (
+	// linkCheckMarkdownPattern 匹配 [文字](地址) 与 ![图片](地址)。
+	linkCheckMarkdownPattern = regexp.MustCompile(`\]\(\s*<?([^)\s>]+)>?`)
+	// linkCheckReferencePattern 匹配 [id]: 地址 形式的引用定义。
+	linkCheckReferencePattern = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*<?([^\s>]+)>?`)
+	// linkCheckHTMLPattern 匹配正文中 HTML 标签的 src 与 href。
+	linkCheckHTMLPattern = regexp.MustCompile(`(?i)\b(?:src|href)\s*=\s*["']([^"']+)["']`)
+	// linkCheckBarePattern 匹配裸露的外部链接。
+	linkCheckBarePattern = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+)
+
+// LinkChecker 扫描已发布文章、页面与相册中的链接，找出缺失的上传文件、失效的站内文章与外部链接。
+type LinkChecker struct {
+	db         *gorm.DB
+	posts      *PostService
+	uploadDir  string
+	uploadURL  string
+	httpClient httpDoer
+	now        func() time.Time
+}
+
+// NewLinkChecker 创建失效链接检查器，uploadDir 与 uploadURL 用于定位上传文件。
+func NewLinkChecker(gdb *gorm.DB, uploadDir, uploadURL string) *LinkChecker {
+	return &LinkChecker{
+		db:         gdb,
+		posts:      NewPostService(gdb),
+		uploadDir:  uploadDir,
+		uploadURL:  uploadURL,
+		httpClient: newPublicHTTPClient(linkCheckTimeout),
+		now:        time.Now,
+	}
+}
+
+// SetHTTPClient 替换外部链接检查使用的 HTTP 客户端，主要用于测试。
+func (c *LinkChecker) SetHTTPClient(client httpDoer) {
+	if client == nil {
+		client = newPublicHTTPClient(linkCheckTimeout)
+	}
+	c.httpClient = client
+}
+
+// linkCheckSource 是一处包含链接的内容。
+type linkCheckSource struct {
+	Type  string
+	ID    uint
+	Title string
+	URLs  []string
+}
+
+// linkCheckTarget 是归类后的待检查链接。
+type linkCheckTarget struct {
+	Kind string
+	// Key 是检查时使用的值：上传文件的相对路径、文章引用或外部地址。
+	Key string
+}
+
+// linkCheckOutcome 是单个链接的检查结果，Broken 为 false 表示正常。
+type linkCheckOutcome struct {
+	Broken     bool
+	StatusCode int
+	Error      string
+}
+
+// Run 执行一次完整检查并保存结果，只保留最近一次检查发现的问题。
+func (c *LinkChecker) Run(ctx context.Context) (*db.LinkCheckRun, error) {
+	run, err := c.startRun()
+	if err != nil {
+		return nil, err
+	}
+	return c.finish(ctx, run)
+}
+
+// Start 记录检查开始后在后台完成检查，用于后台手动触发，已有检查进行中时返回 ErrLinkCheckRunning。
+func (c *LinkChecker) Start(ctx context.Context) (*db.LinkCheckRun, error) {
+	run, err := c.startRun()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if _, err := c.finish(ctx, run); err != nil {
+			log.Printf("[link-check] run failed: %v", err)
+		}
+	}()
+	return run, nil
+}
+
+// finish 执行检查并保存结果，检查失败时只记录错误，保留上一次的问题列表。
+func (c *LinkChecker) finish(ctx context.Context, run *db.LinkCheckRun) (*db.LinkCheckRun, error) {
+	issues, checked, runErr := c.check(ctx, run.ID)
+	finished := c.now()
+	run.FinishedAt = &finished
+	run.Checked = checked
+	run.Broken = len(issues)
+	if runErr != nil {
+		run.Error = truncateRunes(runErr.Error(), 500)
+	}
+
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if runErr == nil {
+			if err := tx.Where("run_id <> ?", run.ID).Delete(&db.LinkCheckIssue{}).Error; err != nil {
+				return err
+			}
+			if len(issues) > 0 {
+				if err := tx.CreateInBatches(&issues, 200).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Model(&db.LinkCheckRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+			"finished_at": run.FinishedAt,
+			"checked":     run.Checked,
+			"broken":      run.Broken,
+			"error":       run.Error,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if runErr != nil {
+		return run, runErr
+	}
+	return run, nil
+}
+
+// startRun 记录检查开始，存在未结束且未超时的检查时返回 ErrLinkCheckRunning。
+// 状态保存在数据库中，后台任务与手动触发的检查不会同时进行。
+func (c *LinkChecker) startRun() (*db.LinkCheckRun, error) {
+	run := &db.LinkCheckRun{StartedAt: c.now()}
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		var running int64
+		if err := tx.Model(&db.LinkCheckRun{}).
+			Where("finished_at IS NULL AND started_at > ?", run.StartedAt.Add(-linkCheckStaleAfter)).
+			Count(&running).Error; err != nil {
+			return err
+		}
+		if running > 0 {
+			return ErrLinkCheckRunning
+		}
+		return tx.Create(run).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// check 收集并检查所有链接，返回发现的问题与检查过的不重复链接数量。
+func (c *LinkChecker) check(ctx context.Context, runID uint) ([]db.LinkCheckIssue, int, error) {
+	sources, err := c.collectSources()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	targets := make(map[linkCheckTarget]bool)
+	for _, source := range sources {
+		for _, raw := range source.URLs {
+			if target, ok := c.classify(raw); ok {
+				targets[target] = true
+			}
+		}
+	}
+
+	outcomes := make(map[linkCheckTarget]linkCheckOutcome, len(targets))
+	var external []linkCheckTarget
+	for target := range targets {
+		switch target.Kind {
+		case db.LinkCheckKindUpload:
+			outcomes[target] = c.checkUpload(target.Key)
+		case db.LinkCheckKindInternal:
+			outcomes[target] = c.checkInternal(target.Key)
+		default:
+			external = append(external, target)
+		}
+	}
+	for target, outcome := range c.checkExternal(ctx, external) {
+		outcomes[target] = outcome
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, len(targets), err
+	}
+
+	var issues []db.LinkCheckIssue
+	for _, source := range sources {
+		seen := make(map[string]bool)
+		for _, raw := range source.URLs {
+			target, ok := c.classify(raw)
+			if !ok || seen[raw] {
+				continue
+			}
+			seen[raw] = true
+			outcome := outcomes[target]
+			if !outcome.Broken {
+				continue
+			}
+			issues = append(issues, db.LinkCheckIssue{
+				RunID:       runID,
+				SourceType:  source.Type,
+				SourceID:    source.ID,
+				SourceTitle: truncateRunes(source.Title, 300),
+				URL:         truncateRunes(raw, 2048),
+				Kind:        target.Kind,
+				StatusCode:  outcome.StatusCode,
+				Error:       truncateRunes(outcome.Error, 500),
+			})
+		}
+	}
+	return issues, len(targets), nil
+}
+
+// collectSources 读取最新发布快照的正文与封面、页面正文与相册图片。
+func (c *LinkChecker) collectSources() ([]linkCheckSource, error) {
+	var sources []linkCheckSource
+
+	var posts []struct {
+		ID       uint
+		Content  string
+		CoverURL string
+	}
+	if err := c.db.Table("posts").
+		Select("posts.id, post_publications.content, post_publications.cover_url").
+		Joins("JOIN post_publications ON post_publications.id = posts.latest_publication_id").
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published").
+		Order("posts.id asc").
+		Scan(&posts).Error; err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		urls := extractCheckableURLs(post.Content)
+		if cover := strings.TrimSpace(post.CoverURL); cover != "" {
+			urls = append(urls, cover)
+		}
+		sources = append(sources, linkCheckSource{
+			Type:  db.LinkCheckSourcePost,
+			ID:    post.ID,
+			Title: db.DeriveTitleFromContent(post.Content),
+			URLs:  urls,
+		})
+	}
+
+	var pages []db.Page
+	if err := c.db.Select("id", "title", "content").Order("id asc").Find(&pages).Error; err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		sources = append(sources, linkCheckSource{
+			Type:  db.LinkCheckSourcePage,
+			ID:    page.ID,
+			Title: page.Title,
+			URLs:  extractCheckableURLs(page.Content),
+		})
+	}
+
+	var images []db.GalleryImage
+	if err := c.db.Select("id", "title", "image_url").Order("id asc").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		if strings.TrimSpace(image.ImageURL) == "" {
+			continue
+		}
+		sources = append(sources, linkCheckSource{
+			Type:  db.LinkCheckSourceGallery,
+			ID:    image.ID,
+			Title: image.Title,
+			URLs:  []string{strings.TrimSpace(image.ImageURL)},
+		})
+	}
+	return sources, nil
+}
+
+// extractCheckableURLs 提取 Markdown 链接、图片、引用定义、HTML src/href 与裸露的外部链接，
+// 忽略代码块与行内代码中的内容。
+func extractCheckableURLs(content string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(raw string) {
+		raw = strings.TrimRight(strings.TrimSpace(raw), ".,;:!?。，；：！？")
+		if raw == "" || seen[raw] {
+			return
+		}
+		seen[raw] = true
+		urls = append(urls, raw)
+	}
+
+	fence := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		line = inlineCodePattern.ReplaceAllString(line, "")
+
+		if match := linkCheckReferencePattern.FindStringSubmatch(line); match != nil {
+			add(match[1])
+		}
+		for _, match := range linkCheckMarkdownPattern.FindAllStringSubmatch(line, -1) {
+			add(match[1])
+		}
+		for _, match := range linkCheckHTMLPattern.FindAllStringSubmatch(line, -1) {
+			add(match[1])
+		}
+		for _, match := range linkCheckBarePattern.FindAllString(line, -1) {
+			add(match)
+		}
+	}
+	return urls
+}
+
+// classify 判断链接类型，锚点、mailto 与相对路径等无法检查的链接返回 false。
+func (c *LinkChecker) classify(raw string) (linkCheckTarget, bool) {
+	value := strings.TrimSpace(raw)
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		target, _, _ := strings.Cut(value, "#")
+		return linkCheckTarget{Kind: db.LinkCheckKindExternal, Key: target}, true
+	case strings.HasPrefix(lower, "//"):
+		target, _, _ := strings.Cut(value, "#")
+		return linkCheckTarget{Kind: db.LinkCheckKindExternal, Key: "https:" + target}, true
+	case !strings.HasPrefix(value, "/"):
+		return linkCheckTarget{}, false
+	}
+
+	local := value
+	if end := strings.IndexAny(local, "?#"); end >= 0 {
+		local = local[:end]
+	}
+	for _, prefix := range c.uploadPrefixes() {
+		if !strings.HasPrefix(local, prefix+"/") {
+			continue
+		}
+		rel := path.Clean(strings.TrimPrefix(local, prefix+"/"))
+		if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+			return linkCheckTarget{}, false
+		}
+		return linkCheckTarget{Kind: db.LinkCheckKindUpload, Key: rel}, true
+	}
+	if ref, ok := strings.CutPrefix(local, "/posts/"); ok {
+		ref = strings.TrimSuffix(ref, "/")
+		if ref == "" || strings.Contains(ref, "/") {
+			return linkCheckTarget{}, false
+		}
+		return linkCheckTarget{Kind: db.LinkCheckKindInternal, Key: ref}, true
+	}
+	return linkCheckTarget{}, false
+}
+
+// uploadPrefixes 返回上传文件的访问前缀，兼容旧版固定的 /uploads 与默认的 /static/uploads。
+func (c *LinkChecker) uploadPrefixes() []string {
+	prefixes := []string{"/uploads", "/static/uploads"}
+	if configured := strings.TrimRight(strings.TrimSpace(c.uploadURL), "/"); configured != "" && configured != "/uploads" && configured != "/static/uploads" {
+		prefixes = append([]string{configured}, prefixes...)
+	}
+	return prefixes
+}
+
+func (c *LinkChecker) checkUpload(rel string) linkCheckOutcome {
+	info, err := os.Stat(filepath.Join(c.uploadDir, filepath.FromSlash(rel)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return linkCheckOutcome{Broken: true, Error: "文件不存在"}
+		}
+		return linkCheckOutcome{Broken: true, Error: err.Error()}
+	}
+	if !info.Mode().IsRegular() {
+		return linkCheckOutcome{Broken: true, Error: "不是文件"}
+	}
+	return linkCheckOutcome{}
+}
+
+// checkInternal 检查站内文章链接是否仍指向已发布的文章，历史 slug 会被跳转，视为正常。
+func (c *LinkChecker) checkInternal(ref string) linkCheckOutcome {
+	resolved, err := c.posts.ResolvePostRef(ref)
+	if err != nil {
+		if errors.Is(err, ErrPostNotFound) {
+			return linkCheckOutcome{Broken: true, Error: "文章不存在"}
+		}
+		return linkCheckOutcome{Broken: true, Error: err.Error()}
+	}
+	var published int64
+	if err := c.db.Model(&db.Post{}).
+		Where("id = ? AND status = ? AND latest_publication_id IS NOT NULL", resolved.PostID, "published").
+		Count(&published).Error; err != nil {
+		return linkCheckOutcome{Broken: true, Error: err.Error()}
+	}
+	if published == 0 {
+		return linkCheckOutcome{Broken: true, Error: "文章未发布"}
+	}
+	return linkCheckOutcome{}
+}
+
+// checkExternal 并发检查外部链接，同时进行的请求数量不超过 linkCheckConcurrency。
+func (c *LinkChecker) checkExternal(ctx context.Context, targets []linkCheckTarget) map[linkCheckTarget]linkCheckOutcome {
+	outcomes := make(map[linkCheckTarget]linkCheckOutcome, len(targets))
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Key < targets[j].Key })
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, linkCheckConcurrency)
+	for _, target := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(target linkCheckTarget) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			outcome := c.checkURL(ctx, target.Key)
+			mu.Lock()
+			outcomes[target] = outcome
+			mu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// checkURL 先发送 HEAD 请求，不支持 HEAD 的站点改用 GET 再确认一次。
+// 429 表示被限流而非链接失效，不计为问题。
+func (c *LinkChecker) checkURL(ctx context.Context, target string) linkCheckOutcome {
+	status, err := c.request(ctx, http.MethodHead, target)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusForbidden || status == http.StatusNotImplemented) {
+		status, err = c.request(ctx, http.MethodGet, target)
+	}
+	if err != nil {
+		return linkCheckOutcome{Broken: true, Error: err.Error()}
+	}
+	if status >= http.StatusBadRequest && status != http.StatusTooManyRequests {
+		return linkCheckOutcome{Broken: true, StatusCode: status, Error: http.StatusText(status)}
+	}
+	return linkCheckOutcome{StatusCode: status}
+}
+
+func (c *LinkChecker) request(ctx context.Context, method, target string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, linkCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("User-Agent", "commitlog-link-checker/1.0")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// LinkCheckGroup 汇总同一来源中发现的问题。
+type LinkCheckGroup struct {
+	SourceType  string              `json:"source_type"`
+	SourceID    uint                `json:"source_id"`
+	SourceTitle string              `json:"source_title"`
+	Issues      []db.LinkCheckIssue `json:"issues"`
+}
+
+// LinkCheckReport 是最近一次检查的结果。
+type LinkCheckReport struct {
+	// Run 是最近一次结束的检查，从未检查过时为空。
+	Run     *db.LinkCheckRun `json:"run"`
+	Running bool             `json:"running"`
+	Groups  []LinkCheckGroup `json:"groups"`
+}
+
+// LatestReport 返回最近一次检查发现的问题，按文章、页面、相册分组。
+func (c *LinkChecker) LatestReport() (*LinkCheckReport, error) {
+	report := &LinkCheckReport{Groups: []LinkCheckGroup{}}
+
+	var running int64
+	if err := c.db.Model(&db.LinkCheckRun{}).
+		Where("finished_at IS NULL AND started_at > ?", c.now().Add(-linkCheckStaleAfter)).
+		Count(&running).Error; err != nil {
+		return nil, err
+	}
+	report.Running = running > 0
+
+	var run db.LinkCheckRun
+	err := c.db.Where("finished_at IS NOT NULL AND error = ''").Order("started_at desc, id desc").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Run = &run
+
+	var issues []db.LinkCheckIssue
+	if err := c.db.Where("run_id = ?", run.ID).Order("id asc").Find(&issues).Error; err != nil {
+		return nil, err
+	}
+	groupIndex := make(map[string]int)
+	for _, issue := range issues {
+		key := fmt.Sprintf("%s:%d", issue.SourceType, issue.SourceID)
+		index, ok := groupIndex[key]
+		if !ok {
+			index = len(report.Groups)
+			groupIndex[key] = index
+			report.Groups = append(report.Groups, LinkCheckGroup{
+				SourceType:  issue.SourceType,
+				SourceID:    issue.SourceID,
+				SourceTitle: issue.SourceTitle,
+			})
+		}
+		report.Groups[index].Issues = append(report.Groups[index].Issues, issue)
+	}
+	return report, nil
+}
+
+// LatestRunTime 返回最近一次检查的开始时间，从未检查过时返回零值。
+func (c *LinkChecker) LatestRunTime() (time.Time, error) {
+	var run db.LinkCheckRun
+	err := c.db.Order("started_at desc, id desc").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return run.StartedAt, nil
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestExtractCheckableURLs_SkipsCode(t *testing.T) {
+	content := "![图](/static/uploads/a.png) 见 [文档](https://example.com/docs)。\n" +
+		"<img src=\"/uploads/b.png\"> 以及 https://example.com/bare.\n" +
+		"[ref]: https://example.com/ref\n" +
+		"`https://example.com/inline`\n" +
+		"```\n![代码](/uploads/code.png)\n```\n" +
+		"    https://example.com/indented"
+
+	got := extractCheckableURLs(content)
+	want := []string{
+		"/static/uploads/a.png",
+		"https://example.com/docs",
+		"/uploads/b.png",
+		"https://example.com/bare",
+		"https://example.com/ref",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestLinkChecker_RunReportsBrokenLinksBySource(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	if err := gdb.AutoMigrate(&db.Page{}, &db.LinkCheckRun{}, &db.LinkCheckIssue{}); err != nil {
+		t.Fatalf("migrate link check tables: %v", err)
+	}
+	svc := NewPostService(gdb)
+
+	uploadDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(uploadDir, "2024"), 0o755); err != nil {
+		t.Fatalf("create upload dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(uploadDir, "2024", "ok.png"), []byte("png"), 0o644); err != nil {
+		t.Fatalf("write upload: %v", err)
+	}
+
+	user := db.User{Username: "link-checker"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	create := func(content string, publish bool) *db.Post {
+		t.Helper()
+		post, err := svc.Create(PostInput{
+			Content:     content,
+			UserID:      user.ID,
+			CoverURL:    "/static/uploads/2024/ok.png",
+			CoverWidth:  1200,
+			CoverHeight: 800,
+		})
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		if publish {
+			if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+				t.Fatalf("publish post: %v", err)
+			}
+		}
+		reloaded, err := svc.Get(post.ID)
+		if err != nil {
+			t.Fatalf("reload post: %v", err)
+		}
+		return reloaded
+	}
+
+	target := create("# 目标文章\n正文", true)
+	source := create("# 有问题的文章\n"+
+		"![正常](/static/uploads/2024/ok.png) ![缺失](/uploads/2024/missing.png)\n"+
+		"[正常](https://example.com/ok) [失效](https://example.com/dead) [仅 GET](https://example.com/get-only)\n"+
+		"[站内]("+target.PublicPath()+") [不存在](/posts/no-such-post)\n"+
+		"[越界](/uploads/../secret.txt) [锚点](#intro)", true)
+	create("# 草稿\n[失效](https://example.com/draft-dead)", false)
+
+	if err := gdb.Create(&db.Page{Slug: "about", Title: "关于", Content: "![头像](/static/uploads/avatar.png)"}).Error; err != nil {
+		t.Fatalf("create page: %v", err)
+	}
+	if err := gdb.Create(&db.GalleryImage{Title: "日落", ImageURL: "/static/uploads/2024/sunset.jpg"}).Error; err != nil {
+		t.Fatalf("create gallery image: %v", err)
+	}
+
+	var mu sync.Mutex
+	var requests []string
+	checker := NewLinkChecker(gdb, uploadDir, "/static/uploads")
+	checker.SetHTTPClient(fakeHTTPClient{handler: func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		mu.Unlock()
+		status := http.StatusOK
+		switch req.URL.Path {
+		case "/dead":
+			status = http.StatusNotFound
+		case "/get-only":
+			if req.Method == http.MethodHead {
+				status = http.StatusMethodNotAllowed
+			}
+		case "/ok":
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		return &http.Response{StatusCode: status, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+	}})
+
+	run, err := checker.Run(context.Background())
+	if err != nil {
+		t.Fatalf("run link check: %v", err)
+	}
+	if run.FinishedAt == nil || run.Checked != 9 || run.Broken != 5 {
+		t.Fatalf("unexpected run summary %+v", run)
+	}
+	if len(requests) != 4 {
+		t.Fatalf("expected HEAD for each external link plus a GET fallback, got %v", requests)
+	}
+
+	report, err := checker.LatestReport()
+	if err != nil {
+		t.Fatalf("load report: %v", err)
+	}
+	if report.Running || report.Run == nil || report.Run.ID != run.ID {
+		t.Fatalf("unexpected report run %+v running=%v", report.Run, report.Running)
+	}
+	if len(report.Groups) != 3 {
+		t.Fatalf("expected post, page and gallery groups, got %+v", report.Groups)
+	}
+
+	post := report.Groups[0]
+	if post.SourceType != db.LinkCheckSourcePost || post.SourceID != source.ID || post.SourceTitle != "有问题的文章" {
+		t.Fatalf("unexpected post group %+v", post)
+	}
+	var broken []string
+	for _, issue := range post.Issues {
+		broken = append(broken, issue.Kind+" "+issue.URL)
+	}
+	want := []string{
+		db.LinkCheckKindUpload + " /uploads/2024/missing.png",
+		db.LinkCheckKindExternal + " https://example.com/dead",
+		db.LinkCheckKindInternal + " /posts/no-such-post",
+	}
+	if !reflect.DeepEqual(broken, want) {
+		t.Fatalf("expected %v, got %v", want, broken)
+	}
+	if post.Issues[1].StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status code on external issue, got %+v", post.Issues[1])
+	}
+	if report.Groups[1].SourceType != db.LinkCheckSourcePage || report.Groups[2].SourceType != db.LinkCheckSourceGallery {
+		t.Fatalf("unexpected group order %+v", report.Groups)
+	}
+
+	// 修复后重新检查，旧的问题不再保留
+	if err := os.WriteFile(filepath.Join(uploadDir, "avatar.png"), []byte("png"), 0o644); err != nil {
+		t.Fatalf("write avatar: %v", err)
+	}
+	if _, err := checker.Run(context.Background()); err != nil {
+		t.Fatalf("rerun link check: %v", err)
+	}
+	var remaining int64
+	gdb.Model(&db.LinkCheckIssue{}).Count(&remaining)
+	if remaining != 4 {
+		t.Fatalf("expected only the latest run's issues to remain, got %d", remaining)
+	}
+}
+
+func TestLinkChecker_RejectsConcurrentRuns(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	if err := gdb.AutoMigrate(&db.Page{}, &db.LinkCheckRun{}, &db.LinkCheckIssue{}); err != nil {
+		t.Fatalf("migrate link check tables: %v", err)
+	}
+	checker := NewLinkChecker(gdb, t.TempDir(), "/static/uploads")
+
+	now := time.Now()
+	checker.now = func() time.Time { return now }
+	if err := gdb.Create(&db.LinkCheckRun{StartedAt: now.Add(-time.Minute)}).Error; err != nil {
+		t.Fatalf("create running run: %v", err)
+	}
+	if _, err := checker.Run(context.Background()); !errors.Is(err, ErrLinkCheckRunning) {
+		t.Fatalf("expected ErrLinkCheckRunning, got %v", err)
+	}
+
+	// 超过一小时仍未结束的检查视为已中断
+	checker.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, err := checker.Run(context.Background()); err != nil {
+		t.Fatalf("expected stale run to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// linkPreviewTimeout 限制单个页面的抓取时间。
+	linkPreviewTimeout = 8 * time.Second
+	// linkPreviewMaxBytes 只读取页面开头部分，Open Graph 标签都在 <head> 中。
+	linkPreviewMaxBytes = 512 << 10
+	// linkPreviewRefreshAfter 是成功结果的有效期，过期后在后台重新抓取。
+	linkPreviewRefreshAfter = 7 * 24 * time.Hour
+	// linkPreviewRetryAfter 是失败结果的重试间隔。
+	linkPreviewRetryAfter = 24 * time.Hour
+	// linkPreviewMaxPerSave 限制一次保存最多抓取的链接数量。
+	linkPreviewMaxPerSave = 20
+)
+
+// ErrTargetNotPublic 表示链接指向本机或内网地址，不允许服务端发起请求。
+var ErrTargetNotPublic = errors.New("target address is not public")
+
+// LinkPreviewService 抓取链接的 Open Graph / Twitter Card 信息并缓存到数据库。
+//
+// 抓取只在保存、发布或发现缓存过期时于后台进行，渲染页面时只读取数据库。
+type LinkPreviewService struct {
+	db         *gorm.DB
+	thumbnails *EmbedThumbnailCache
+	httpClient httpDoer
+	now        func() time.Time
+
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+// NewLinkPreviewService 创建链接预览服务，预览图片缓存到 thumbnails 中。
+func NewLinkPreviewService(gdb *gorm.DB, thumbnails *EmbedThumbnailCache) *LinkPreviewService {
+	return &LinkPreviewService{
+		db:         gdb,
+		thumbnails: thumbnails,
+		httpClient: newPublicHTTPClient(linkPreviewTimeout),
+		now:        time.Now,
+		inflight:   make(map[string]bool),
+	}
+}
+
+// SetHTTPClient 替换抓取使用的 HTTP 客户端，主要用于测试。
+func (s *LinkPreviewService) SetHTTPClient(client httpDoer) {
+	if client == nil {
+		client = newPublicHTTPClient(linkPreviewTimeout)
+	}
+	s.httpClient = client
+}
+
+// newPublicHTTPClient 创建拒绝连接本机与内网地址的客户端，用于请求文章中作者填写的外部地址。
+// 在建立连接时检查解析后的 IP，重定向与 DNS 重绑定同样受到限制。
+func newPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				return ErrTargetNotPublic
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// NormalizeLinkPreviewURL 校验并规范化需要生成卡片的链接，只接受 http/https。
+func NormalizeLinkPreviewURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > 2048 {
+		return "", false
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" || parsed.User != nil {
+		return "", false
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	return parsed.String(), true
+}
+
+// Lookup 批量读取已缓存的预览，只访问数据库，不会发起网络请求。
+func (s *LinkPreviewService) Lookup(urls []string) (map[string]db.LinkPreview, error) {
+	result := make(map[string]db.LinkPreview)
+	if s == nil || len(urls) == 0 {
+		return result, nil
+	}
+	var previews []db.LinkPreview
+	if err := s.db.Where("url IN ?", urls).Find(&previews).Error; err != nil {
+		return nil, err
+	}
+	for _, preview := range previews {
+		result[preview.URL] = preview
+	}
+	return result, nil
+}
+
+// Stale 判断缓存是否需要重新抓取：成功结果七天后刷新，失败结果一天后重试。
+func (s *LinkPreviewService) Stale(preview db.LinkPreview) bool {
+	ttl := linkPreviewRefreshAfter
+	if preview.FetchError != "" {
+		ttl = linkPreviewRetryAfter
+	}
+	return s.now().Sub(preview.FetchedAt) >= ttl
+}
+
+// Schedule 在后台抓取尚未缓存或已过期的链接，调用方无需等待。
+// 同一链接同时只会抓取一次。
+func (s *LinkPreviewService) Schedule(urls []string) {
+	if s == nil {
+		return
+	}
+	pending := s.pending(urls)
+	if len(pending) == 0 {
+		return
+	}
+	go func() {
+		defer s.release(pending)
+		for _, target := range pending {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*linkPreviewTimeout)
+			if _, err := s.Refresh(ctx, target); err != nil {
+				log.Printf("[link-preview] fetch %s failed: %v", target, err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// pending 过滤出需要抓取且没有在抓取中的链接，并标记为抓取中。
+func (s *LinkPreviewService) pending(urls []string) []string {
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, raw := range urls {
+		target, ok := NormalizeLinkPreviewURL(raw)
+		if !ok || seen[target] {
+			continue
+		}
+		seen[target] = true
+		candidates = append(candidates, target)
+		if len(candidates) == linkPreviewMaxPerSave {
+			break
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	cached, err := s.Lookup(candidates)
+	if err != nil {
+		log.Printf("[link-preview] load cache failed: %v", err)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []string
+	for _, target := range candidates {
+		if preview, ok := cached[target]; ok && !s.Stale(preview) {
+			continue
+		}
+		if s.inflight[target] {
+			continue
+		}
+		s.inflight[target] = true
+		pending = append(pending, target)
+	}
+	return pending
+}
+
+func (s *LinkPreviewService) release(urls []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, target := range urls {
+		delete(s.inflight, target)
+	}
+}
+
+// Refresh 立即抓取链接并写入缓存，抓取失败时同样记录失败结果以便按间隔重试。
+func (s *LinkPreviewService) Refresh(ctx context.Context, raw string) (db.LinkPreview, error) {
+	target, ok := NormalizeLinkPreviewURL(raw)
+	if !ok {
+		return db.LinkPreview{}, fmt.Errorf("invalid link preview url %q", raw)
+	}
+
+	preview := db.LinkPreview{URL: target, FetchedAt: s.now()}
+	meta, fetchErr := s.fetchMetadata(ctx, target)
+	if fetchErr != nil {
+		preview.FetchError = truncateRunes(fetchErr.Error(), 500)
+	} else {
+		preview.Title = truncateRunes(meta.Title, 300)
+		preview.Description = truncateRunes(meta.Description, 1000)
+		preview.SiteName = truncateRunes(meta.SiteName, 191)
+		if meta.Image != "" && s.thumbnails != nil {
+			source := EmbedThumbnailSource{Key: linkPreviewImageKey(meta.Image), ImageURL: meta.Image}
+			if local, err := s.thumbnails.Fetch(ctx, source); err == nil {
+				preview.ImageURL = local
+			} else {
+				log.Printf("[link-preview] cache image for %s failed: %v", target, err)
+			}
+		}
+	}
+
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "description", "site_name", "image_url", "fetch_error", "fetched_at", "updated_at"}),
+	}).Create(&preview).Error
+	if err != nil {
+		return preview, fmt.Errorf("save link preview: %w", err)
+	}
+	return preview, fetchErr
+}
+
+// linkPreviewImageKey 按图片地址生成缓存键，页面更换图片后会缓存为新文件。
+func linkPreviewImageKey(imageURL string) string {
+	sum := sha256.Sum256([]byte(imageURL))
+	return "link-" + hex.EncodeToString(sum[:16])
+}
+
+// linkMetadata 是从页面 <head> 中读取的信息。
+type linkMetadata struct {
+	Title       string
+	Description string
+	SiteName    string
+	Image       string
+}
+
+func (s *LinkPreviewService) fetchMetadata(ctx context.Context, target string) (linkMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return linkMetadata{}, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("User-Agent", "commitlog-link-preview/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return linkMetadata{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return linkMetadata{}, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.Contains(strings.ToLower(contentType), "html") {
+		return linkMetadata{}, fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	// 按响应头或 <meta charset> 转码，兼容仍在使用 GBK 的中文站点
+	body, err := charset.NewReader(io.LimitReader(resp.Body, linkPreviewMaxBytes), contentType)
+	if err != nil {
+		return linkMetadata{}, fmt.Errorf("decode page: %w", err)
+	}
+	base := req.URL
+	if resp.Request != nil && resp.Request.URL != nil {
+		base = resp.Request.URL
+	}
+	meta := parseLinkMetadata(body, base)
+	if meta.Title == "" {
+		return linkMetadata{}, errors.New("page has no title")
+	}
+	return meta, nil
+}
+
+// parseLinkMetadata 读取 Open Graph、Twitter Card 与普通 meta 标签，
+// 优先使用 og:*，缺失时依次回退到 twitter:* 与 <title>、description。
+func parseLinkMetadata(r io.Reader, base *url.URL) linkMetadata {
+	values := make(map[string]string)
+	title := ""
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+loop:
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				break loop
+			case "title":
+				inTitle = title == ""
+			case "meta":
+				var key, content string
+				for _, attr := range token.Attr {
+					switch strings.ToLower(attr.Key) {
+					case "property", "name":
+						if key == "" {
+							key = strings.ToLower(strings.TrimSpace(attr.Val))
+						}
+					case "content":
+						content = strings.TrimSpace(attr.Val)
+					}
+				}
+				if key != "" && content != "" {
+					if _, exists := values[key]; !exists {
+						values[key] = content
+					}
+				}
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = false
+			case "head":
+				break loop
+			}
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value := strings.Join(strings.Fields(values[key]), " "); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	values["title"] = title
+
+	meta := linkMetadata{
+		Title:       first("og:title", "twitter:title", "title"),
+		Description: first("og:description", "twitter:description", "description"),
+		SiteName:    first("og:site_name", "application-name"),
+	}
+	if image := first("og:image:secure_url", "og:image", "twitter:image", "twitter:image:src"); image != "" {
+		if resolved, err := base.Parse(image); err == nil && (resolved.Scheme == "http" || resolved.Scheme == "https") {
+			meta.Image = resolved.String()
+		}
+	}
+	if meta.SiteName == "" && base != nil {
+		meta.SiteName = strings.TrimPrefix(base.Hostname(), "www.")
+	}
+	return meta
+}
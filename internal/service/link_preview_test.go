@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupLinkPreviewTestDB(t *testing.T) (*gorm.DB, func()) {
+	t.Helper()
+
+	gdb, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+
+	if err := gdb.AutoMigrate(&db.LinkPreview{}); err != nil {
+		t.Fatalf("failed to migrate test db: %v", err)
+	}
+
+	return gdb, func() {
+		sqlDB, err := gdb.DB()
+		if err == nil {
+			sqlDB.Close()
+		}
+	}
+}
+
+func TestLinkPreviewRefresh_ReadsOpenGraphAndCachesImage(t *testing.T) {
+	gdb, cleanup := setupLinkPreviewTestDB(t)
+	defer cleanup()
+
+	thumbnails := NewEmbedThumbnailCache(t.TempDir(), "/uploads")
+	client := fakeHTTPClient{handler: func(req *http.Request) (*http.Response, error) {
+		header := make(http.Header)
+		body := ""
+		switch req.URL.Path {
+		case "/article":
+			header.Set("Content-Type", "text/html; charset=utf-8")
+			body = `<!doctype html><html><head>
+<title>Fallback Title</title>
+<meta property="og:title" content="  Go 1.24 发布  ">
+<meta name="twitter:description" content="新版本带来了泛型类型别名。">
+<meta property="og:site_name" content="The Go Blog">
+<meta property="og:image" content="/images/cover.png">
+</head><body><meta property="og:title" content="ignored"></body></html>`
+		case "/images/cover.png":
+			header.Set("Content-Type", "image/png")
+			body = "png-bytes"
+		default:
+			t.Fatalf("unexpected request %s", req.URL)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	}}
+	thumbnails.SetHTTPClient(client)
+	svc := NewLinkPreviewService(gdb, thumbnails)
+	svc.SetHTTPClient(client)
+
+	preview, err := svc.Refresh(context.Background(), "https://Go.dev/article#intro")
+	if err != nil {
+		t.Fatalf("refresh preview: %v", err)
+	}
+	if preview.URL != "https://go.dev/article" {
+		t.Fatalf("expected normalized url, got %q", preview.URL)
+	}
+	if preview.Title != "Go 1.24 发布" || preview.Description != "新版本带来了泛型类型别名。" || preview.SiteName != "The Go Blog" {
+		t.Fatalf("unexpected metadata: %+v", preview)
+	}
+	if !strings.HasPrefix(preview.ImageURL, "/uploads/embeds/link-") || !strings.HasSuffix(preview.ImageURL, ".png") {
+		t.Fatalf("expected image cached locally, got %q", preview.ImageURL)
+	}
+
+	cached, err := svc.Lookup([]string{"https://go.dev/article"})
+	if err != nil {
+		t.Fatalf("lookup preview: %v", err)
+	}
+	if stored, ok := cached["https://go.dev/article"]; !ok || !stored.Usable() || svc.Stale(stored) {
+		t.Fatalf("expected fresh usable preview in cache, got %+v", stored)
+	}
+}
+
+func TestLinkPreviewRefresh_RecordsFailureAndRetriesLater(t *testing.T) {
+	gdb, cleanup := setupLinkPreviewTestDB(t)
+	defer cleanup()
+
+	svc := NewLinkPreviewService(gdb, nil)
+	svc.SetHTTPClient(fakeHTTPClient{handler: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+	}})
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	if _, err := svc.Refresh(context.Background(), "https://example.com/missing"); err == nil {
+		t.Fatal("expected refresh to report the failed fetch")
+	}
+	cached, err := svc.Lookup([]string{"https://example.com/missing"})
+	if err != nil {
+		t.Fatalf("lookup preview: %v", err)
+	}
+	failed, ok := cached["https://example.com/missing"]
+	if !ok || failed.FetchError == "" || failed.Usable() {
+		t.Fatalf("expected failure to be recorded, got %+v", failed)
+	}
+	if svc.Stale(failed) {
+		t.Fatal("expected recent failure to wait before retrying")
+	}
+
+	now = now.Add(25 * time.Hour)
+	if !svc.Stale(failed) {
+		t.Fatal("expected failure to be retried after a day")
+	}
+	if svc.Stale(db.LinkPreview{Title: "ok", FetchedAt: now.Add(-25 * time.Hour)}) {
+		t.Fatal("expected successful preview to stay fresh for a week")
+	}
+
+	if _, err := svc.Refresh(context.Background(), "javascript:alert(1)"); err == nil {
+		t.Fatal("expected non-http url to be rejected")
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import "strings"
+
+// diagramLanguages 是按图表处理的代码块语言及其显示名称。
+var diagramLanguages = map[string]string{
+	"mermaid":  "Mermaid",
+	"plantuml": "PlantUML",
+	"puml":     "PlantUML",
+}
+
+// DiagramLanguageName 判断代码块语言是否为图表，返回图表类型的显示名称。
+func DiagramLanguageName(language string) (string, bool) {
+	name, ok := diagramLanguages[strings.ToLower(strings.TrimSpace(language))]
+	return name, ok
+}
+
+// DiagramFallbackText 返回图表在纯文本、订阅等无法渲染场景中的替代文字。
+func DiagramFallbackText(name string) string {
+	return "[" + name + " 图表]"
+}
+
+// StripDiagramFences 将 Markdown 中的图表代码块替换为替代文字，其余代码块保持不变。
+func StripDiagramFences(content string) string {
+	if !strings.Contains(content, "```") && !strings.Contains(content, "~~~") {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	inDiagram := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			marker := openingFence(trimmed)
+			if marker == "" {
+				out = append(out, line)
+				continue
+			}
+			fence = marker
+			language := strings.TrimLeft(trimmed, marker[:1])
+			if end := strings.IndexAny(language, " \t{:"); end >= 0 {
+				language = language[:end]
+			}
+			if name, ok := DiagramLanguageName(language); ok {
+				inDiagram = true
+				out = append(out, DiagramFallbackText(name))
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+
+		closing := strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+		if !inDiagram {
+			out = append(out, line)
+		}
+		if closing {
+			fence = ""
+			inDiagram = false
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// openingFence 返回代码块起始行的围栏（至少三个 ` 或 ~），不是围栏时返回空字符串。
+func openingFence(line string) string {
+	for _, char := range []string{"`", "~"} {
+		if !strings.HasPrefix(line, char+char+char) {
+			continue
+		}
+		return line[:len(line)-len(strings.TrimLeft(line, char))]
+	}
+	return ""
+}
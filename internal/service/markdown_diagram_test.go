@@ -0,0 +1,19 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripDiagramFences(t *testing.T) {
+	content := "开头\n\n```mermaid\ngraph TD\n  A-->B\n```\n\n````go\n```mermaid\n````\n\n~~~plantuml\n@startuml\n@enduml\n~~~\n结尾"
+	want := "开头\n\n[Mermaid 图表]\n\n````go\n```mermaid\n````\n\n[PlantUML 图表]\n结尾"
+	if got := StripDiagramFences(content); got != want {
+		t.Fatalf("StripDiagramFences() = %q, want %q", got, want)
+	}
+
+	long := "```mermaid\n" + strings.Repeat("A-->B\n", 500) + "```\n短文"
+	if minutes := CalculateReadingTime(long); minutes != 1 {
+		t.Fatalf("expected diagram source to be excluded from reading time, got %d minutes", minutes)
+	}
+}
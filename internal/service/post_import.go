@@ -0,0 +1,701 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+var ErrImportArchiveInvalid = errors.New("import archive is invalid")
+
+const (
+	maxImportArchiveFiles = 5000
+	maxImportArchiveBytes = 1 << 30 // 解压后总大小上限 1GB
+
+	importCoverProbeTimeout = 10 * time.Second
+	// importCoverProbeBytes 是读取远程封面尺寸时最多下载的字节数，图片头部信息通常位于开头
+	importCoverProbeBytes = 8 << 20
+)
+
+// 导入文章时对已有文章的处理结果。
+const (
+	ImportActionCreated   = "created"
+	ImportActionUpdated   = "updated"
+	ImportActionUnchanged = "unchanged"
+)
+
+var (
+	importMarkdownImagePattern = regexp.MustCompile(`(!\[[^\]]*\]\(\s*<?)([^)\s>]+)(>?(?:\s+"[^"]*")?\s*\))`)
+	importHTMLImagePattern     = regexp.MustCompile(`(<img\b[^>]*?\bsrc=["'])([^"']+)(["'])`)
+	importImageExtensions      = map[string]struct{}{
+		".jpg": {}, ".jpeg": {}, ".png": {}, ".gif": {}, ".webp": {}, ".svg": {}, ".avif": {},
+	}
+	// 静态站点生成器的输出、主题与依赖目录不包含源文章
+	importSkippedDirs = map[string]struct{}{
+		".git": {}, "node_modules": {}, "public": {}, "_site": {}, "themes": {}, "resources": {}, "vendor": {},
+	}
+	// 站点根路径的图片在不同生成器中分别位于 static/、source/ 或根目录
+	importStaticRoots = []string{"static", "source", ""}
+)
+
+// PostImporter 将 Hugo/Hexo/Jekyll 的 Markdown 文章导入为站内文章。
+type PostImporter struct {
+	db         *gorm.DB
+	posts      *PostService
+	tags       *TagService
+	uploadDir  string
+	uploadURL  string
+	httpClient httpDoer
+	now        func() time.Time
+}
+
+// PostImportResult 汇总一次导入的结果。
+type PostImportResult struct {
+	Imported []ImportedPost  `json:"imported"`
+	Failed   []ImportFailure `json:"failed"`
+}
+
+// ImportedPost 描述成功导入的一篇文章。
+type ImportedPost struct {
+	Source string `json:"source"`
+	PostID uint   `json:"post_id"`
+	Title  string `json:"title"`
+	// Action 表示新建、更新了 slug 相同的已有文章，或内容未变化而跳过
+	Action    string `json:"action"`
+	Published bool   `json:"published"`
+	// ScheduledAt 是发布日期晚于导入时间的文章交给定时发布的时间
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	Images      int        `json:"images"`
+	Warnings    []string   `json:"warnings,omitempty"`
+}
+
+// ImportFailure 记录未能导入的文件及原因。
+type ImportFailure struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// NewPostImporter 创建文章导入器，图片会复制到 uploadDir 并以 uploadURL 为前缀改写链接。
+func NewPostImporter(gdb *gorm.DB, uploadDir, uploadURL string) *PostImporter {
+	return &PostImporter{
+		db:         gdb,
+		posts:      NewPostService(gdb),
+		tags:       NewTagService(gdb),
+		uploadDir:  uploadDir,
+		uploadURL:  uploadURL,
+		httpClient: newPublicHTTPClient(importCoverProbeTimeout),
+		now:        time.Now,
+	}
+}
+
+// SetHTTPClient 替换读取远程封面尺寸使用的 HTTP 客户端，主要用于测试。
+func (im *PostImporter) SetHTTPClient(client httpDoer) {
+	if client == nil {
+		client = newPublicHTTPClient(importCoverProbeTimeout)
+	}
+	im.httpClient = client
+}
+
+// ImportArchive 解压 zip 包后导入其中的全部文章。
+func (im *PostImporter) ImportArchive(r io.ReaderAt, size int64, userID uint) (*PostImportResult, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportArchiveInvalid, err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "commitlog-import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := extractImportArchive(reader, tempDir); err != nil {
+		return nil, err
+	}
+
+	// 压缩包只包含一个顶层目录时，以该目录作为站点根目录
+	root := tempDir
+	if entries, readErr := os.ReadDir(tempDir); readErr == nil && len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(tempDir, entries[0].Name())
+	}
+	return im.ImportDir(root, userID)
+}
+
+// ImportDir 遍历站点目录导入全部带 front matter 的 Markdown 文章。
+func (im *PostImporter) ImportDir(root string, userID uint) (*PostImportResult, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			if _, skip := importSkippedDirs[entry.Name()]; skip && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := strings.ToLower(entry.Name())
+		if strings.HasPrefix(name, "_index.") {
+			return nil
+		}
+		if ext := filepath.Ext(name); ext == ".md" || ext == ".markdown" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PostImportResult{Imported: []ImportedPost{}, Failed: []ImportFailure{}}
+	session := &importSession{importer: im, root: root, copied: map[string]importedImage{}, tagIDs: map[string]uint{}}
+	for _, path := range files {
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		imported, importErr := session.importFile(path, rel, userID)
+		if importErr != nil {
+			if errors.Is(importErr, errFrontMatterMissing) {
+				continue
+			}
+			result.Failed = append(result.Failed, ImportFailure{Source: rel, Error: importErr.Error()})
+			continue
+		}
+		result.Imported = append(result.Imported, *imported)
+	}
+	return result, nil
+}
+
+type importedImage struct {
+	URL    string
+	Width  int
+	Height int
+	// path 是上传目录中的文件，created 表示文件由本次导入新建
+	path    string
+	created bool
+}
+
+// importSession 在一次导入中缓存已复制的图片与已解析的标签。
+type importSession struct {
+	importer *PostImporter
+	root     string
+	copied   map[string]importedImage
+	tagIDs   map[string]uint
+	// pending 是当前文章新复制的图片来源，文章保存失败时删除对应文件
+	pending []string
+}
+
+func (s *importSession) importFile(path, rel string, userID uint) (*ImportedPost, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseImportedDocument(rel, string(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	// 文章保存前失败时清理本篇新复制的图片，避免留下无人引用的文件
+	s.pending = s.pending[:0]
+	saved := false
+	defer func() {
+		if !saved {
+			s.discardPendingImages()
+		}
+	}()
+
+	imported := &ImportedPost{Source: rel, Title: doc.Title, Action: ImportActionCreated}
+	dir := filepath.Dir(path)
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+
+	body, images := s.rewriteImages(doc.Body, dir, stem)
+	imported.Images = images
+
+	tagIDs, err := s.resolveTags(doc.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	input := PostInput{
+		Title:   doc.Title,
+		Slug:    doc.Slug,
+		Content: composeImportedContent(doc.Title, body),
+		Summary: doc.Summary,
+		TagIDs:  tagIDs,
+		UserID:  userID,
+	}
+	if doc.Cover != "" {
+		if cover, ok := s.copyImage(doc.Cover, dir, stem); ok {
+			input.CoverURL, input.CoverWidth, input.CoverHeight = cover.URL, cover.Width, cover.Height
+			imported.Images++
+		} else if isRemoteImageRef(doc.Cover) {
+			// 发布要求封面带有尺寸，读取不到尺寸的远程封面直接忽略
+			if width, height, probeErr := s.importer.probeRemoteImage(doc.Cover); probeErr == nil {
+				input.CoverURL, input.CoverWidth, input.CoverHeight = doc.Cover, width, height
+			} else {
+				imported.Warnings = append(imported.Warnings, "无法读取远程封面尺寸，已忽略："+doc.Cover)
+			}
+		} else {
+			imported.Warnings = append(imported.Warnings, "封面图片不存在："+doc.Cover)
+		}
+	}
+
+	existing, err := s.findExistingPost(doc.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发布日期在未来的文章交给定时发布，而不是立即上线并带上未来的时间
+	var scheduleAt *time.Time
+	if !doc.Draft && doc.Date.After(s.importer.now()) {
+		scheduleAt = &doc.Date
+	}
+
+	var post *db.Post
+	switch {
+	case existing == nil:
+		post, err = s.importer.posts.Create(input)
+		if errors.Is(err, ErrSlugTaken) || errors.Is(err, ErrSlugInvalid) {
+			imported.Warnings = append(imported.Warnings, fmt.Sprintf("slug %q 不可用，发布时将自动生成", doc.Slug))
+			input.Slug = ""
+			post, err = s.importer.posts.Create(input)
+		}
+	case importUnchanged(existing, input, doc.Draft, scheduleAt):
+		saved = true
+		imported.PostID = existing.ID
+		imported.Action = ImportActionUnchanged
+		imported.Published = existing.Status == "published"
+		imported.ScheduledAt = existing.ScheduledAt
+		return imported, nil
+	default:
+		// 重复导入同一站点时按 slug 更新已有文章，而不是创建副本
+		input.Slug = existing.Slug
+		post, err = s.importer.posts.Update(existing.ID, input)
+		imported.Action = ImportActionUpdated
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved = true
+	imported.PostID = post.ID
+
+	if doc.Draft {
+		imported.Published = existing != nil && existing.Status == "published"
+		return imported, nil
+	}
+	if scheduleAt != nil {
+		scheduled, err := s.importer.posts.SchedulePublish(post.ID, *scheduleAt, s.importer.now())
+		if err != nil {
+			if warning, ok := importPublishWarning(err); ok {
+				imported.Warnings = append(imported.Warnings, warning)
+				return imported, nil
+			}
+			return nil, err
+		}
+		imported.Published = scheduled.Status == "published"
+		imported.ScheduledAt = scheduled.ScheduledAt
+		return imported, nil
+	}
+
+	publishedAt := doc.Date
+	if publishedAt.IsZero() {
+		if info, statErr := os.Stat(path); statErr == nil {
+			publishedAt = info.ModTime()
+		}
+	}
+	if _, err := s.importer.posts.Publish(post.ID, userID, &publishedAt); err != nil {
+		if warning, ok := importPublishWarning(err); ok {
+			imported.Warnings = append(imported.Warnings, warning)
+			return imported, nil
+		}
+		return nil, err
+	}
+	imported.Published = true
+	return imported, nil
+}
+
+// importPublishWarning 将内容不满足发布条件的错误转换为提示，文章保留为草稿。
+func importPublishWarning(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrCoverRequired), errors.Is(err, ErrCoverInvalid):
+		return "缺少可用的封面图片，已保存为草稿", true
+	case errors.Is(err, ErrInvalidPublishState):
+		return "标题或正文为空，已保存为草稿", true
+	}
+	return "", false
+}
+
+// findExistingPost 按 slug 查找此前导入或已存在的文章，回收站中的文章不参与匹配。
+func (s *importSession) findExistingPost(rawSlug string) (*db.Post, error) {
+	slug, err := normalizeSlugInput(rawSlug)
+	if err != nil || slug == "" {
+		return nil, nil
+	}
+	var post db.Post
+	if err := s.importer.db.Preload("Tags").Where("slug = ?", slug).First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &post, nil
+}
+
+// importUnchanged 判断已有文章是否与本次导入的内容一致且发布状态无需变化，
+// scheduleAt 不为空时要求已有文章在同一时间定时发布。
+func importUnchanged(existing *db.Post, input PostInput, draft bool, scheduleAt *time.Time) bool {
+	if existing.Content != input.Content ||
+		existing.Summary != strings.TrimSpace(input.Summary) ||
+		existing.CoverURL != input.CoverURL {
+		return false
+	}
+	switch {
+	case draft:
+	case scheduleAt != nil:
+		if existing.ScheduledAt == nil || !existing.ScheduledAt.Equal(*scheduleAt) {
+			return false
+		}
+	case existing.Status != "published":
+		return false
+	}
+	tagIDs := make([]uint, 0, len(existing.Tags))
+	for _, tag := range existing.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	wanted := append([]uint(nil), input.TagIDs...)
+	sort.Slice(tagIDs, func(i, j int) bool { return tagIDs[i] < tagIDs[j] })
+	sort.Slice(wanted, func(i, j int) bool { return wanted[i] < wanted[j] })
+	if len(tagIDs) != len(wanted) {
+		return false
+	}
+	for i := range tagIDs {
+		if tagIDs[i] != wanted[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// discardPendingImages 删除当前文章新复制的图片，并从缓存中移除。
+func (s *importSession) discardPendingImages() {
+	for _, source := range s.pending {
+		if copied, ok := s.copied[source]; ok {
+			if copied.created {
+				os.Remove(copied.path)
+			}
+			delete(s.copied, source)
+		}
+	}
+	s.pending = s.pending[:0]
+}
+
+// probeRemoteImage 下载远程图片的开头部分以读取尺寸，只允许访问公网地址。
+func (im *PostImporter) probeRemoteImage(ref string) (int, int, error) {
+	target := strings.TrimSpace(ref)
+	if strings.HasPrefix(target, "//") {
+		target = "https:" + target
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp, err := im.httpClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	config, _, err := image.DecodeConfig(io.LimitReader(resp.Body, importCoverProbeBytes))
+	if err != nil {
+		return 0, 0, err
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return 0, 0, errors.New("image has no dimensions")
+	}
+	return config.Width, config.Height, nil
+}
+
+// composeImportedContent 将 front matter 中的标题写为正文首行，本站从首行推导文章标题。
+func composeImportedContent(title, body string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return body
+	}
+	if firstLine, _, _ := strings.Cut(body, "\n"); db.DeriveTitleFromContent(firstLine) == title && strings.HasPrefix(firstLine, "#") {
+		return body
+	}
+	if body == "" {
+		return "# " + title
+	}
+	return "# " + title + "\n\n" + body
+}
+
+// rewriteImages 复制正文中引用的本地图片并改写为上传地址，返回复制的图片数量。
+func (s *importSession) rewriteImages(body, dir, stem string) (string, int) {
+	count := 0
+	replace := func(pattern *regexp.Regexp, input string) string {
+		return pattern.ReplaceAllStringFunc(input, func(match string) string {
+			parts := pattern.FindStringSubmatch(match)
+			copied, ok := s.copyImage(parts[2], dir, stem)
+			if !ok {
+				return match
+			}
+			count++
+			return parts[1] + copied.URL + parts[3]
+		})
+	}
+	body = replace(importMarkdownImagePattern, body)
+	body = replace(importHTMLImagePattern, body)
+	return body, count
+}
+
+func (s *importSession) copyImage(ref, dir, stem string) (importedImage, bool) {
+	source := s.locateImage(ref, dir, stem)
+	if source == "" {
+		return importedImage{}, false
+	}
+	if cached, ok := s.copied[source]; ok {
+		return cached, true
+	}
+
+	uploadDir := s.importer.uploadDir
+	if strings.TrimSpace(uploadDir) == "" {
+		uploadDir = "web/static/uploads"
+	}
+	if err := os.MkdirAll(uploadDir, 0o755); err != nil {
+		return importedImage{}, false
+	}
+	// 以内容摘要命名，重复导入同一张图片时复用已有文件，改写后的正文保持不变
+	digest, err := importFileDigest(source)
+	if err != nil {
+		return importedImage{}, false
+	}
+	name := "import-" + digest + strings.ToLower(filepath.Ext(source))
+	copied := importedImage{path: filepath.Join(uploadDir, name)}
+	if _, statErr := os.Stat(copied.path); errors.Is(statErr, os.ErrNotExist) {
+		if err := copyImportFile(source, copied.path); err != nil {
+			return importedImage{}, false
+		}
+		copied.created = true
+	} else if statErr != nil {
+		return importedImage{}, false
+	}
+
+	prefix := strings.TrimRight(s.importer.uploadURL, "/")
+	if prefix == "" {
+		prefix = "/uploads"
+	}
+	copied.URL = prefix + "/" + name
+	copied.Width, copied.Height = importImageDimensions(source)
+	s.copied[source] = copied
+	s.pending = append(s.pending, source)
+	return copied, true
+}
+
+// importFileDigest 返回文件内容 SHA-256 摘要的前 20 位十六进制字符。
+func importFileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:20], nil
+}
+
+// locateImage 在站点目录中查找图片引用对应的文件，找不到或越出站点目录时返回空字符串。
+func (s *importSession) locateImage(ref, dir, stem string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || isRemoteImageRef(ref) || strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+		return ""
+	}
+	if idx := strings.IndexAny(ref, "?#"); idx >= 0 {
+		ref = ref[:idx]
+	}
+	if decoded, err := url.PathUnescape(ref); err == nil {
+		ref = decoded
+	}
+	if _, ok := importImageExtensions[strings.ToLower(filepath.Ext(ref))]; !ok {
+		return ""
+	}
+
+	var candidates []string
+	if strings.HasPrefix(ref, "/") {
+		for _, staticRoot := range importStaticRoots {
+			candidates = append(candidates, filepath.Join(s.root, staticRoot, filepath.FromSlash(ref)))
+		}
+	} else {
+		// Hexo 开启 post_asset_folder 时图片位于与文章同名的目录中
+		candidates = append(candidates,
+			filepath.Join(dir, filepath.FromSlash(ref)),
+			filepath.Join(stem, filepath.FromSlash(ref)),
+		)
+	}
+
+	for _, candidate := range candidates {
+		rel, err := filepath.Rel(s.root, candidate)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func (s *importSession) resolveTags(names []string) ([]uint, error) {
+	ids := make([]uint, 0, len(names))
+	for _, name := range names {
+		if id, ok := s.tagIDs[name]; ok {
+			ids = append(ids, id)
+			continue
+		}
+
+		var tag db.Tag
+		err := s.importer.db.Where("name = ?", name).First(&tag).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			created, createErr := s.importer.tags.Create(name)
+			if createErr != nil {
+				return nil, createErr
+			}
+			tag = *created
+		} else if err != nil {
+			return nil, err
+		}
+		s.tagIDs[name] = tag.ID
+		ids = append(ids, tag.ID)
+	}
+	return ids, nil
+}
+
+func isRemoteImageRef(ref string) bool {
+	lower := strings.ToLower(strings.TrimSpace(ref))
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "//")
+}
+
+func importImageDimensions(path string) (int, int) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
+
+func copyImportFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// extractImportArchive 解压 zip 包，拒绝越出目标目录的路径与符号链接，并限制文件数量和总大小。
+func extractImportArchive(reader *zip.Reader, dest string) error {
+	if len(reader.File) > maxImportArchiveFiles {
+		return fmt.Errorf("%w: too many files", ErrImportArchiveInvalid)
+	}
+
+	var written int64
+	for _, file := range reader.File {
+		name := filepath.FromSlash(file.Name)
+		target := filepath.Join(dest, name)
+		rel, err := filepath.Rel(dest, target)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(name) {
+			return fmt.Errorf("%w: illegal path %q", ErrImportArchiveInvalid, file.Name)
+		}
+
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			continue
+		case !mode.IsRegular():
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		n, err := extractImportFile(file, target, maxImportArchiveBytes-written)
+		if err != nil {
+			return err
+		}
+		written += n
+	}
+	return nil
+}
+
+func extractImportFile(file *zip.File, target string, remaining int64) (int64, error) {
+	in, err := file.Open()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrImportArchiveInvalid, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	n, err := io.Copy(out, io.LimitReader(in, remaining+1))
+	if err != nil {
+		return n, err
+	}
+	if n > remaining {
+		return n, fmt.Errorf("%w: archive too large", ErrImportArchiveInvalid)
+	}
+	return n, nil
+}
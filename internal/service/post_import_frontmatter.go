@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// importedDocument 是从静态站点 Markdown 文件解析出的文章信息。
+type importedDocument struct {
+	Title   string
+	Slug    string
+	Date    time.Time
+	Tags    []string
+	Draft   bool
+	Summary string
+	Cover   string
+	Body    string
+}
+
+var (
+	errFrontMatterMissing  = errors.New("front matter not found")
+	jekyllFilenamePattern  = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+	frontMatterDateLayouts = []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05 -07:00",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+)
+
+// parseImportedDocument 解析 Hugo/Hexo/Jekyll 文章，支持 YAML（---）与 TOML（+++）两种 front matter。
+func parseImportedDocument(relPath, source string) (*importedDocument, error) {
+	source = strings.TrimPrefix(source, "\ufeff")
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	meta, body, err := splitFrontMatter(source)
+	if err != nil {
+		return nil, err
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(relPath), filepath.Ext(relPath))
+	if stem == "index" {
+		// Hugo 的 page bundle 以目录名作为文章名
+		stem = filepath.Base(filepath.Dir(relPath))
+	}
+	var filenameDate time.Time
+	if match := jekyllFilenamePattern.FindStringSubmatch(stem); match != nil {
+		filenameDate, _ = time.ParseInLocation("2006-01-02", match[1], time.Local)
+		stem = match[2]
+	}
+
+	doc := &importedDocument{
+		Title:   frontMatterString(meta, "title"),
+		Slug:    frontMatterString(meta, "slug"),
+		Summary: frontMatterString(meta, "summary", "description", "excerpt"),
+		Cover:   frontMatterCover(meta),
+		Tags:    frontMatterStrings(meta, "tags"),
+		Body:    strings.TrimSpace(body),
+	}
+	if doc.Title == "" {
+		doc.Title = stem
+	}
+	if doc.Slug == "" {
+		doc.Slug = stem
+	}
+	doc.Date = frontMatterTime(meta, "date", "publishDate", "publishdate", "pubDate")
+	if doc.Date.IsZero() {
+		doc.Date = filenameDate
+	}
+
+	// Hugo 使用 draft: true，Jekyll 使用 published: false，Hexo 草稿位于 _drafts 目录
+	doc.Draft = frontMatterBool(meta, "draft")
+	if published, ok := frontMatterValue(meta, "published"); ok && published == false {
+		doc.Draft = true
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if segment == "_drafts" {
+			doc.Draft = true
+		}
+	}
+	return doc, nil
+}
+
+func splitFrontMatter(source string) (map[string]interface{}, string, error) {
+	var (
+		delimiter string
+		decode    func([]byte, interface{}) error
+	)
+	switch {
+	case strings.HasPrefix(source, "---\n"):
+		delimiter, decode = "---", yaml.Unmarshal
+	case strings.HasPrefix(source, "+++\n"):
+		delimiter, decode = "+++", toml.Unmarshal
+	default:
+		return nil, "", errFrontMatterMissing
+	}
+
+	rest := source[len(delimiter)+1:]
+	end := -1
+	offset := 0
+	for _, line := range strings.SplitAfter(rest, "\n") {
+		trimmed := strings.TrimRight(line, "\n")
+		if trimmed == delimiter || (delimiter == "---" && trimmed == "...") {
+			end = offset
+			offset += len(line)
+			break
+		}
+		offset += len(line)
+	}
+	if end < 0 {
+		return nil, "", errFrontMatterMissing
+	}
+
+	meta := map[string]interface{}{}
+	if err := decode([]byte(rest[:end]), &meta); err != nil {
+		return nil, "", fmt.Errorf("parse front matter: %w", err)
+	}
+	return meta, rest[offset:], nil
+}
+
+func frontMatterValue(meta map[string]interface{}, keys ...string) (interface{}, bool) {
+	for _, key := range keys {
+		for name, value := range meta {
+			if strings.EqualFold(name, key) && value != nil {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func frontMatterString(meta map[string]interface{}, keys ...string) string {
+	value, ok := frontMatterValue(meta, keys...)
+	if !ok {
+		return ""
+	}
+	switch typed := value.(type) {
+	case string:
+		return strings.TrimSpace(typed)
+	case fmt.Stringer:
+		return strings.TrimSpace(typed.String())
+	case int, int64, float64:
+		return fmt.Sprint(typed)
+	}
+	return ""
+}
+
+func frontMatterBool(meta map[string]interface{}, keys ...string) bool {
+	value, ok := frontMatterValue(meta, keys...)
+	if !ok {
+		return false
+	}
+	switch typed := value.(type) {
+	case bool:
+		return typed
+	case string:
+		return strings.EqualFold(strings.TrimSpace(typed), "true")
+	}
+	return false
+}
+
+// frontMatterStrings 读取列表字段，兼容逗号分隔的字符串写法。
+func frontMatterStrings(meta map[string]interface{}, keys ...string) []string {
+	value, ok := frontMatterValue(meta, keys...)
+	if !ok {
+		return nil
+	}
+
+	var raw []string
+	switch typed := value.(type) {
+	case string:
+		raw = strings.Split(typed, ",")
+	case []interface{}:
+		for _, item := range typed {
+			raw = append(raw, fmt.Sprint(item))
+		}
+	case []string:
+		raw = typed
+	}
+
+	seen := make(map[string]struct{}, len(raw))
+	values := make([]string, 0, len(raw))
+	for _, item := range raw {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if _, exists := seen[item]; exists {
+			continue
+		}
+		seen[item] = struct{}{}
+		values = append(values, item)
+	}
+	return values
+}
+
+func frontMatterTime(meta map[string]interface{}, keys ...string) time.Time {
+	value, ok := frontMatterValue(meta, keys...)
+	if !ok {
+		return time.Time{}
+	}
+	switch typed := value.(type) {
+	case time.Time:
+		return typed
+	case toml.LocalDateTime:
+		return typed.AsTime(time.Local)
+	case toml.LocalDate:
+		return typed.AsTime(time.Local)
+	}
+
+	raw := strings.TrimSpace(fmt.Sprint(value))
+	for _, layout := range frontMatterDateLayouts {
+		if parsed, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// frontMatterCover 兼容常见主题的封面字段，例如 PaperMod 的 cover.image。
+func frontMatterCover(meta map[string]interface{}) string {
+	if value, ok := frontMatterValue(meta, "cover"); ok {
+		if nested, isMap := value.(map[string]interface{}); isMap {
+			if image := frontMatterString(nested, "image", "src", "url"); image != "" {
+				return image
+			}
+		}
+	}
+	return frontMatterString(meta, "cover", "cover_image", "featured_image", "image", "thumbnail", "banner")
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+func writeImportFile(t *testing.T, root, rel string, data []byte) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", rel, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestPostImporter_ImportDir(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	user := db.User{Username: "importer"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := gdb.Create(&db.Tag{Name: "Go"}).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	site := t.TempDir()
+	uploadDir := t.TempDir()
+	writeImportFile(t, site, "static/images/cover.png", encodeTestPNG(t, 640, 480))
+	writeImportFile(t, site, "content/posts/hello/diagram.png", encodeTestPNG(t, 10, 10))
+	writeImportFile(t, site, "content/posts/hello/index.md", []byte(`---
+title: "Hello Hugo"
+date: 2021-05-04T08:30:00+08:00
+tags: [Go, 迁移]
+summary: 从 Hugo 迁移
+cover:
+  image: /images/cover.png
+---
+正文段落
+
+![示意图](diagram.png "图")
+![远程](https://example.com/remote.png)
+<img src="/images/cover.png" alt="重复引用">
+`))
+	writeImportFile(t, site, "content/posts/draft.md", []byte(`+++
+title = "TOML 草稿"
+draft = true
+tags = ["Go"]
++++
+草稿内容
+`))
+	writeImportFile(t, site, "_posts/2019-01-02-jekyll-post.md", []byte("---\ntitle: Jekyll 文章\n---\n没有封面\n"))
+	writeImportFile(t, site, "content/posts/_index.md", []byte("---\ntitle: 列表页\n---\n"))
+	writeImportFile(t, site, "README.md", []byte("# 没有 front matter\n"))
+	writeImportFile(t, site, "content/posts/broken.md", []byte("---\ntitle: [\n---\n"))
+
+	importer := NewPostImporter(gdb, uploadDir, "/uploads/")
+	result, err := importer.ImportDir(site, user.ID)
+	if err != nil {
+		t.Fatalf("import dir: %v", err)
+	}
+	if len(result.Imported) != 3 {
+		t.Fatalf("expected 3 imported posts, got %+v", result.Imported)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Source != "content/posts/broken.md" {
+		t.Fatalf("expected broken front matter to fail, got %+v", result.Failed)
+	}
+
+	bySource := map[string]ImportedPost{}
+	for _, item := range result.Imported {
+		bySource[item.Source] = item
+	}
+
+	hello := bySource["content/posts/hello/index.md"]
+	if !hello.Published || hello.Images != 3 {
+		t.Fatalf("unexpected hugo import result: %+v", hello)
+	}
+	posts := NewPostService(gdb)
+	post, err := posts.Get(hello.PostID)
+	if err != nil {
+		t.Fatalf("get imported post: %v", err)
+	}
+	if post.Title != "Hello Hugo" || post.Slug != "hello" || post.Summary != "从 Hugo 迁移" {
+		t.Fatalf("unexpected imported post: title=%q slug=%q summary=%q", post.Title, post.Slug, post.Summary)
+	}
+	if post.CoverWidth != 640 || post.CoverHeight != 480 || !strings.HasPrefix(post.CoverURL, "/uploads/") {
+		t.Fatalf("expected local cover to be copied, got %q %dx%d", post.CoverURL, post.CoverWidth, post.CoverHeight)
+	}
+	if strings.Contains(post.Content, "(diagram.png") || !strings.Contains(post.Content, "https://example.com/remote.png") {
+		t.Fatalf("expected local images rewritten and remote kept, got %s", post.Content)
+	}
+	if !strings.Contains(post.Content, `<img src="`+post.CoverURL+`"`) {
+		t.Fatalf("expected repeated image to reuse copied file, got %s", post.Content)
+	}
+	if len(post.Tags) != 2 {
+		t.Fatalf("expected existing and new tags, got %+v", post.Tags)
+	}
+	expectedDate := time.Date(2021, 5, 4, 0, 30, 0, 0, time.UTC)
+	if !post.PublishedAt.Equal(expectedDate) {
+		t.Fatalf("expected original publish date, got %v", post.PublishedAt)
+	}
+	copied, err := os.ReadDir(uploadDir)
+	if err != nil {
+		t.Fatalf("read upload dir: %v", err)
+	}
+	if len(copied) != 2 {
+		t.Fatalf("expected 2 copied images, got %d", len(copied))
+	}
+
+	draft := bySource["content/posts/draft.md"]
+	if draft.Published {
+		t.Fatalf("expected TOML draft to stay unpublished")
+	}
+	jekyll := bySource["_posts/2019-01-02-jekyll-post.md"]
+	if jekyll.Published || len(jekyll.Warnings) == 0 {
+		t.Fatalf("expected post without cover to be kept as draft with warning, got %+v", jekyll)
+	}
+	jekyllPost, err := posts.Get(jekyll.PostID)
+	if err != nil {
+		t.Fatalf("get jekyll post: %v", err)
+	}
+	if jekyllPost.Slug != "jekyll-post" || jekyllPost.Title != "Jekyll 文章" {
+		t.Fatalf("unexpected jekyll post: slug=%q title=%q", jekyllPost.Slug, jekyllPost.Title)
+	}
+}
+
+func TestPostImporter_ImportArchiveRejectsEscapingPaths(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	importer := NewPostImporter(gdb, t.TempDir(), "/uploads")
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	entry, err := writer.Create("../evil.md")
+	if err != nil {
+		t.Fatalf("create zip entry: %v", err)
+	}
+	entry.Write([]byte("---\ntitle: evil\n---\n"))
+	writer.Close()
+
+	if _, err := importer.ImportArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 1); !errors.Is(err, ErrImportArchiveInvalid) {
+		t.Fatalf("expected ErrImportArchiveInvalid, got %v", err)
+	}
+
+	buf.Reset()
+	writer = zip.NewWriter(&buf)
+	entry, _ = writer.Create("blog/source/_posts/hexo.md")
+	entry.Write([]byte("---\ntitle: Hexo 文章\ntags: 随笔\n---\n内容\n"))
+	writer.Close()
+
+	result, err := importer.ImportArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 1)
+	if err != nil {
+		t.Fatalf("import archive: %v", err)
+	}
+	if len(result.Imported) != 1 || result.Imported[0].Source != "source/_posts/hexo.md" {
+		t.Fatalf("expected archive post to be imported relative to site root, got %+v", result)
+	}
+}
+
+func TestPostImporter_ProbesRemoteCoverAndUpdatesOnReimport(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	user := db.User{Username: "reimporter"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	site := t.TempDir()
+	uploadDir := t.TempDir()
+	writeImportFile(t, site, "content/posts/remote.md", []byte("---\ntitle: 远程封面\ncover: https://cdn.example.com/cover.png\n---\n正文\n"))
+	writeImportFile(t, site, "content/posts/missing.md", []byte("---\ntitle: 失效封面\ncover: https://cdn.example.com/404.png\n---\n正文\n"))
+	writeImportFile(t, site, "content/posts/local.png", encodeTestPNG(t, 8, 8))
+	writeImportFile(t, site, "content/posts/broken.md", []byte("---\ntitle: 坏文章\n---\n![图](local.png)\n"))
+
+	// 模拟保存文章失败，已复制的图片应被清理
+	gdb.Callback().Create().Before("gorm:create").Register("test:fail_broken_post", func(tx *gorm.DB) {
+		if post, ok := tx.Statement.Dest.(*db.Post); ok && strings.Contains(post.Content, "坏文章") {
+			tx.AddError(errors.New("save failed"))
+		}
+	})
+
+	coverPNG := encodeTestPNG(t, 800, 600)
+	importer := NewPostImporter(gdb, uploadDir, "/uploads")
+	importer.SetHTTPClient(fakeHTTPClient{handler: func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/cover.png" {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(coverPNG))}, nil
+	}})
+
+	result, err := importer.ImportDir(site, user.ID)
+	if err != nil {
+		t.Fatalf("import dir: %v", err)
+	}
+	bySource := map[string]ImportedPost{}
+	for _, item := range result.Imported {
+		bySource[item.Source] = item
+	}
+
+	remote := bySource["content/posts/remote.md"]
+	if !remote.Published || remote.Action != ImportActionCreated {
+		t.Fatalf("expected remote cover post to be published, got %+v", remote)
+	}
+	posts := NewPostService(gdb)
+	post, err := posts.Get(remote.PostID)
+	if err != nil {
+		t.Fatalf("get remote post: %v", err)
+	}
+	if post.CoverURL != "https://cdn.example.com/cover.png" || post.CoverWidth != 800 || post.CoverHeight != 600 {
+		t.Fatalf("expected probed remote cover, got %q %dx%d", post.CoverURL, post.CoverWidth, post.CoverHeight)
+	}
+
+	missing := bySource["content/posts/missing.md"]
+	if missing.Published || len(missing.Warnings) == 0 || !strings.Contains(missing.Warnings[0], "404.png") {
+		t.Fatalf("expected unreadable remote cover to be reported, got %+v", missing)
+	}
+
+	if len(result.Failed) != 1 || result.Failed[0].Source != "content/posts/broken.md" {
+		t.Fatalf("expected broken post to fail, got %+v", result.Failed)
+	}
+	if entries, _ := os.ReadDir(uploadDir); len(entries) != 0 {
+		t.Fatalf("expected images of failed post to be removed, got %d files", len(entries))
+	}
+
+	// 再次导入时按 slug 匹配已有文章，不产生副本
+	writeImportFile(t, site, "content/posts/missing.md", []byte("---\ntitle: 失效封面\n---\n修改后的正文\n"))
+	again, err := importer.ImportDir(site, user.ID)
+	if err != nil {
+		t.Fatalf("reimport dir: %v", err)
+	}
+	actions := map[string]ImportedPost{}
+	for _, item := range again.Imported {
+		actions[item.Source] = item
+	}
+	if item := actions["content/posts/remote.md"]; item.Action != ImportActionUnchanged || item.PostID != remote.PostID || !item.Published {
+		t.Fatalf("expected unchanged remote post, got %+v", item)
+	}
+	if item := actions["content/posts/missing.md"]; item.Action != ImportActionUpdated || item.PostID != missing.PostID {
+		t.Fatalf("expected updated post, got %+v", item)
+	}
+	var count int64
+	if err := gdb.Model(&db.Post{}).Count(&count).Error; err != nil {
+		t.Fatalf("count posts: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected re-import to reuse existing posts, got %d posts", count)
+	}
+	updated, err := posts.Get(missing.PostID)
+	if err != nil {
+		t.Fatalf("get updated post: %v", err)
+	}
+	if !strings.Contains(updated.Content, "修改后的正文") {
+		t.Fatalf("expected content to be updated, got %s", updated.Content)
+	}
+}
+
+func TestPostImporter_SchedulesFutureDatedPosts(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	user := db.User{Username: "future-importer"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	site := t.TempDir()
+	writeImportFile(t, site, "static/images/cover.png", encodeTestPNG(t, 640, 480))
+	writeImportFile(t, site, "content/posts/future.md", []byte(`---
+title: 未来的文章
+date: 2030-01-02T08:00:00Z
+cover: /images/cover.png
+---
+正文
+`))
+
+	importer := NewPostImporter(gdb, t.TempDir(), "/uploads")
+	importer.now = func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	result, err := importer.ImportDir(site, user.ID)
+	if err != nil {
+		t.Fatalf("import dir: %v", err)
+	}
+	if len(result.Imported) != 1 || len(result.Failed) != 0 {
+		t.Fatalf("unexpected import result %+v", result)
+	}
+	imported := result.Imported[0]
+	want := time.Date(2030, 1, 2, 8, 0, 0, 0, time.UTC)
+	if imported.Published || imported.ScheduledAt == nil || !imported.ScheduledAt.Equal(want) {
+		t.Fatalf("expected future post to be scheduled at %v, got %+v", want, imported)
+	}
+
+	svc := NewPostService(gdb)
+	post, err := svc.Get(imported.PostID)
+	if err != nil {
+		t.Fatalf("get post: %v", err)
+	}
+	if post.Status != "scheduled" || post.LatestPublicationID != nil {
+		t.Fatalf("expected post to wait for the scheduler, got %q (%v)", post.Status, post.LatestPublicationID)
+	}
+
+	// 再次导入时定时不变视为无变化
+	again, err := importer.ImportDir(site, user.ID)
+	if err != nil {
+		t.Fatalf("reimport dir: %v", err)
+	}
+	if len(again.Imported) != 1 || again.Imported[0].Action != ImportActionUnchanged {
+		t.Fatalf("expected unchanged reimport, got %+v", again.Imported)
+	}
+
+	due, err := svc.PublishDue(want)
+	if err != nil {
+		t.Fatalf("publish due: %v", err)
+	}
+	if len(due.Published) != 1 || due.Published[0] != imported.PostID {
+		t.Fatalf("expected scheduler to publish the imported post, got %+v", due)
+	}
+	publication, err := svc.LatestPublication(imported.PostID)
+	if err != nil {
+		t.Fatalf("latest publication: %v", err)
+	}
+	if !publication.PublishedAt.Equal(want) {
+		t.Fatalf("expected publication at %v, got %v", want, publication.PublishedAt)
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPostPasswordRequired  = errors.New("protected post requires a password")
+	ErrPostPasswordIncorrect = errors.New("post password is incorrect")
+	ErrPostUnlockThrottled   = errors.New("too many incorrect post password attempts")
+)
+
+const (
+	// PostUnlockTTL 是解锁令牌的有效期，过期后需要重新输入密码。
+	PostUnlockTTL = 30 * 24 * time.Hour
+	// PostUnlockWindow 内同一访客对同一文章输错 postUnlockMaxFailures 次后，窗口结束前不再校验密码。
+	PostUnlockWindow      = 15 * time.Minute
+	postUnlockMaxFailures = 5
+	// unlockAttemptPruneSize 是记录数超过该值时顺带清理过期记录的阈值
+	unlockAttemptPruneSize = 10000
+)
+
+// SetUnlockSecret 设置签发解锁令牌使用的服务端密钥，通常为 SESSION_SECRET。
+func (s *PostService) SetUnlockSecret(secret string) {
+	s.unlockSecret = []byte(secret)
+}
+
+// resolvePostPasswordHash 根据输入决定保存的密码哈希：留空沿用现有哈希，否则重新生成。
+func resolvePostPasswordHash(password, current string) (string, error) {
+	if strings.TrimSpace(password) == "" {
+		return current, nil
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// UnlockProtected 校验受保护文章的访问密码，成功时返回可写入 Cookie 的解锁令牌。
+// client 标识访客（通常为客户端 IP），同一访客对同一文章输错过多时返回 ErrPostUnlockThrottled，
+// 此时不再进行 bcrypt 比对。
+func (s *PostService) UnlockProtected(postID uint, client, password string) (string, error) {
+	key := strconv.FormatUint(uint64(postID), 10) + "|" + client
+	if !s.unlockAttempts.allow(key) {
+		return "", ErrPostUnlockThrottled
+	}
+	hash, err := s.postPasswordHash(postID)
+	if err != nil {
+		return "", err
+	}
+	if hash == "" {
+		return "", ErrPostPasswordRequired
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		s.unlockAttempts.fail(key)
+		return "", ErrPostPasswordIncorrect
+	}
+	s.unlockAttempts.reset(key)
+	return postUnlockToken(s.unlockSecret, postID, hash, time.Now().Add(PostUnlockTTL)), nil
+}
+
+// VerifyUnlockToken 判断解锁令牌是否仍然有效；令牌过期或更换密码后随之失效。
+func (s *PostService) VerifyUnlockToken(postID uint, token string) bool {
+	rawExpires, _, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok {
+		return false
+	}
+	expiresUnix, err := strconv.ParseInt(rawExpires, 10, 64)
+	if err != nil {
+		return false
+	}
+	expires := time.Unix(expiresUnix, 0)
+	if !time.Now().Before(expires) {
+		return false
+	}
+	hash, err := s.postPasswordHash(postID)
+	if err != nil || hash == "" {
+		return false
+	}
+	return hmac.Equal([]byte(strings.TrimSpace(token)), []byte(postUnlockToken(s.unlockSecret, postID, hash, expires)))
+}
+
+func (s *PostService) postPasswordHash(postID uint) (string, error) {
+	var post db.Post
+	if err := s.db.Select("id", "password_hash").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrPostNotFound
+		}
+		return "", err
+	}
+	return post.PasswordHash, nil
+}
+
+// postUnlockToken 以服务端密钥对文章 ID、密码哈希指纹与过期时间签名，格式为“过期时间戳.签名”。
+// 令牌只对该文章有效，更换密码后指纹变化，旧令牌随之失效。
+func postUnlockToken(secret []byte, postID uint, passwordHash string, expires time.Time) string {
+	fingerprint := sha256.Sum256([]byte(passwordHash))
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "post-unlock:%d:%x:%d", postID, fingerprint[:8], expires.Unix())
+	return strconv.FormatInt(expires.Unix(), 10) + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// unlockAttemptLimiter 在内存中按“文章|访客”统计 PostUnlockWindow 内的密码错误次数。
+type unlockAttemptLimiter struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	attempts map[string]*unlockAttempt
+}
+
+type unlockAttempt struct {
+	failures int
+	// resetAt 是首次输错后窗口结束的时间，之后重新计数
+	resetAt time.Time
+}
+
+func newUnlockAttemptLimiter() *unlockAttemptLimiter {
+	return &unlockAttemptLimiter{now: time.Now, attempts: make(map[string]*unlockAttempt)}
+}
+
+// allow 判断该访客当前是否还可以尝试密码。
+func (l *unlockAttemptLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attempt, ok := l.attempts[key]
+	if !ok {
+		return true
+	}
+	if !l.now().Before(attempt.resetAt) {
+		delete(l.attempts, key)
+		return true
+	}
+	return attempt.failures < postUnlockMaxFailures
+}
+
+// fail 记录一次密码错误。
+func (l *unlockAttemptLimiter) fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.attempts) >= unlockAttemptPruneSize {
+		for k, attempt := range l.attempts {
+			if !now.Before(attempt.resetAt) {
+				delete(l.attempts, k)
+			}
+		}
+	}
+	attempt, ok := l.attempts[key]
+	if !ok || !now.Before(attempt.resetAt) {
+		l.attempts[key] = &unlockAttempt{failures: 1, resetAt: now.Add(PostUnlockWindow)}
+		return
+	}
+	attempt.failures++
+}
+
+// reset 在密码正确后清除该访客的错误记录。
+func (l *unlockAttemptLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, key)
+}
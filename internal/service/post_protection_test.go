@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestPostService_ProtectedPostPasswordAndUnlock(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "protector"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	input := PostInput{
+		Content:     "# 加密文章\n秘密正文",
+		Summary:     "公开摘要",
+		Visibility:  db.PostVisibilityProtected,
+		UserID:      user.ID,
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	}
+	post, err := svc.Create(input)
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if post.HasPassword {
+		t.Fatalf("expected draft without password")
+	}
+	if _, err := svc.Publish(post.ID, user.ID, nil); !errors.Is(err, ErrPostPasswordRequired) {
+		t.Fatalf("expected ErrPostPasswordRequired, got %v", err)
+	}
+
+	input.Password = "open-sesame"
+	post, err = svc.Update(post.ID, input)
+	if err != nil {
+		t.Fatalf("update post: %v", err)
+	}
+	if !post.HasPassword || post.PasswordHash == "open-sesame" {
+		t.Fatalf("expected password to be stored as hash, got %+v", post)
+	}
+	if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish protected post: %v", err)
+	}
+
+	if _, err := svc.UnlockProtected(post.ID, "192.0.2.1", "wrong"); !errors.Is(err, ErrPostPasswordIncorrect) {
+		t.Fatalf("expected ErrPostPasswordIncorrect, got %v", err)
+	}
+	token, err := svc.UnlockProtected(post.ID, "192.0.2.1", "open-sesame")
+	if err != nil {
+		t.Fatalf("unlock post: %v", err)
+	}
+	if !svc.VerifyUnlockToken(post.ID, token) {
+		t.Fatalf("expected token to be valid")
+	}
+	if svc.VerifyUnlockToken(post.ID+1, token) {
+		t.Fatalf("expected token to be scoped to its post")
+	}
+
+	// 令牌由服务端密钥签名并带有过期时间
+	var stored db.Post
+	if err := gdb.Select("id", "password_hash").First(&stored, post.ID).Error; err != nil {
+		t.Fatalf("load password hash: %v", err)
+	}
+	expired := postUnlockToken(nil, post.ID, stored.PasswordHash, time.Now().Add(-time.Minute))
+	if svc.VerifyUnlockToken(post.ID, expired) {
+		t.Fatalf("expected expired token to be rejected")
+	}
+	otherSecret := NewPostService(gdb)
+	otherSecret.SetUnlockSecret("another-secret")
+	if otherSecret.VerifyUnlockToken(post.ID, token) {
+		t.Fatalf("expected token signed with another secret to be rejected")
+	}
+
+	// 留空密码保留原哈希，更换密码后旧令牌失效
+	input.Password = ""
+	if _, err := svc.Update(post.ID, input); err != nil {
+		t.Fatalf("update without password: %v", err)
+	}
+	if !svc.VerifyUnlockToken(post.ID, token) {
+		t.Fatalf("expected empty password input to keep existing hash")
+	}
+	input.Password = "new-secret"
+	if _, err := svc.Update(post.ID, input); err != nil {
+		t.Fatalf("change password: %v", err)
+	}
+	if svc.VerifyUnlockToken(post.ID, token) {
+		t.Fatalf("expected password change to invalidate old token")
+	}
+
+	// 受保护文章出现在列表中，但只能按标题搜索
+	listed, err := svc.ListPublished(PostFilter{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("list published: %v", err)
+	}
+	if len(listed.Publications) != 1 {
+		t.Fatalf("expected protected post in listing, got %d", len(listed.Publications))
+	}
+	for search, want := range map[string]int{"加密": 1, "秘密正文": 0, "公开摘要": 0} {
+		result, err := svc.ListPublished(PostFilter{Search: search, Page: 1, PerPage: 10})
+		if err != nil {
+			t.Fatalf("search %q: %v", search, err)
+		}
+		if len(result.Publications) != want {
+			t.Fatalf("search %q: expected %d results, got %d", search, want, len(result.Publications))
+		}
+	}
+}
+
+func TestPostService_UnlockProtectedThrottlesRepeatedFailures(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	svc.unlockAttempts.now = func() time.Time { return now }
+
+	user := db.User{Username: "throttled"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	post, err := svc.Create(PostInput{
+		Content:     "# 限流文章\n正文",
+		Visibility:  db.PostVisibilityProtected,
+		Password:    "open-sesame",
+		UserID:      user.ID,
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	for i := 0; i < postUnlockMaxFailures; i++ {
+		if _, err := svc.UnlockProtected(post.ID, "198.51.100.7", "wrong"); !errors.Is(err, ErrPostPasswordIncorrect) {
+			t.Fatalf("attempt %d: expected ErrPostPasswordIncorrect, got %v", i+1, err)
+		}
+	}
+	// 超过次数后即使密码正确也暂时拒绝
+	if _, err := svc.UnlockProtected(post.ID, "198.51.100.7", "open-sesame"); !errors.Is(err, ErrPostUnlockThrottled) {
+		t.Fatalf("expected ErrPostUnlockThrottled, got %v", err)
+	}
+	// 其他访客不受影响
+	if _, err := svc.UnlockProtected(post.ID, "198.51.100.8", "open-sesame"); err != nil {
+		t.Fatalf("expected other client to unlock, got %v", err)
+	}
+
+	now = now.Add(PostUnlockWindow)
+	if _, err := svc.UnlockProtected(post.ID, "198.51.100.7", "open-sesame"); err != nil {
+		t.Fatalf("expected unlock after the window, got %v", err)
+	}
+}
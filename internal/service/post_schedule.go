@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrScheduleInPast   = errors.New("scheduled time must be in the future")
+	ErrPostNotScheduled = errors.New("post has no pending scheduled publication")
+)
+
+// errScheduleNotPending 表示读取到期列表后定时任务已被取消、改期或下线，本轮不再发布。
+var errScheduleNotPending = errors.New("scheduled publication is no longer pending")
+
+// ScheduledPublishResult 汇总一次到期定时发布的执行结果。
+type ScheduledPublishResult struct {
+	Published []uint
+	// Cancelled 记录因内容不满足发布条件而被取消定时的文章
+	Cancelled []uint
+}
+
+// SchedulePublish 将文章设置为在指定时间自动发布，已有定时任务时直接覆盖。
+func (s *PostService) SchedulePublish(postID uint, at time.Time, now time.Time) (*db.Post, error) {
+	if !at.After(now) {
+		return nil, ErrScheduleInPast
+	}
+
+	var post db.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	if err := validatePublishable(&post); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"scheduled_at": at.UTC()}
+	// 已发布文章在定时更新前保持线上版本可见，已下线文章在到期前保持下线
+	if post.Status != "published" && post.Status != "withdrawn" {
+		updates["status"] = "scheduled"
+	}
+	if err := s.db.Model(&db.Post{}).Where("id = ?", post.ID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	return s.Get(post.ID)
+}
+
+// Reschedule 调整已有定时发布任务的执行时间。
+func (s *PostService) Reschedule(postID uint, at time.Time, now time.Time) (*db.Post, error) {
+	post, err := s.Get(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.ScheduledAt == nil {
+		return nil, ErrPostNotScheduled
+	}
+	return s.SchedulePublish(postID, at, now)
+}
+
+// CancelSchedule 取消文章的定时发布，未发布过的文章回到草稿状态。
+func (s *PostService) CancelSchedule(postID uint) (*db.Post, error) {
+	post, err := s.Get(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.ScheduledAt == nil {
+		return nil, ErrPostNotScheduled
+	}
+
+	if err := s.clearSchedule(post); err != nil {
+		return nil, err
+	}
+	return s.Get(postID)
+}
+
+// ListScheduled 返回所有待执行的定时发布文章，按执行时间升序排列。
+func (s *PostService) ListScheduled() ([]db.Post, error) {
+	var posts []db.Post
+	if err := s.db.Preload("Tags").
+		Where("scheduled_at IS NOT NULL").
+		Order("scheduled_at asc, id asc").
+		Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		posts[i].PopulateDerivedFields()
+	}
+	return posts, nil
+}
+
+// PublishDue 发布所有到期的定时文章，发布时间记为原定时间以便补发停机期间错过的任务。
+func (s *PostService) PublishDue(now time.Time) (ScheduledPublishResult, error) {
+	var result ScheduledPublishResult
+
+	var due []db.Post
+	if err := s.db.Select("id", "user_id", "status", "scheduled_at").
+		Where("scheduled_at IS NOT NULL AND scheduled_at <= ?", now.UTC()).
+		Order("scheduled_at asc, id asc").
+		Find(&due).Error; err != nil {
+		return result, err
+	}
+
+	var errs []error
+	for i := range due {
+		post := &due[i]
+		err := s.publishScheduled(post)
+		switch {
+		case err == nil:
+			result.Published = append(result.Published, post.ID)
+		case errors.Is(err, errScheduleNotPending):
+			continue
+		case errors.Is(err, ErrInvalidPublishState), errors.Is(err, ErrCoverRequired), errors.Is(err, ErrCoverInvalid), errors.Is(err, ErrPostPasswordRequired):
+			// 内容已不满足发布条件，取消定时避免反复重试
+			if clearErr := s.clearSchedule(post); clearErr != nil {
+				errs = append(errs, fmt.Errorf("cancel schedule for post %d: %w", post.ID, clearErr))
+				continue
+			}
+			result.Cancelled = append(result.Cancelled, post.ID)
+		case errors.Is(err, ErrPostNotFound):
+			continue
+		default:
+			errs = append(errs, fmt.Errorf("publish scheduled post %d: %w", post.ID, err))
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// publishScheduled 发布一篇到期的定时文章。发布事务先按读取到的定时时间认领任务，
+// 期间被撤回、取消或改期的文章认领失败，避免刚执行的操作被调度器覆盖。
+func (s *PostService) publishScheduled(post *db.Post) error {
+	_, err := s.publish(post.ID, post.UserID, post.ScheduledAt, func(tx *gorm.DB) error {
+		result := tx.Model(&db.Post{}).
+			Where("id = ? AND scheduled_at = ?", post.ID, post.ScheduledAt).
+			Update("scheduled_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errScheduleNotPending
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *PostService) clearSchedule(post *db.Post) error {
+	updates := map[string]interface{}{"scheduled_at": nil}
+	if post.Status == "scheduled" {
+		updates["status"] = "draft"
+	}
+	return s.db.Model(&db.Post{}).Where("id = ?", post.ID).Updates(updates).Error
+}
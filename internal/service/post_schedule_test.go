@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+)
+
+func createSchedulablePost(t *testing.T, svc *PostService, userID uint, content string) *db.Post {
+	t.Helper()
+	post, err := svc.Create(PostInput{
+		Content:     content,
+		UserID:      userID,
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	return post
+}
+
+func TestPostService_ScheduledPublishing(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "scheduler"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	now := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	post := createSchedulablePost(t, svc, user.ID, "# 定时文章\n正文")
+
+	if _, err := svc.SchedulePublish(post.ID, now.Add(-time.Minute), now); !errors.Is(err, ErrScheduleInPast) {
+		t.Fatalf("expected ErrScheduleInPast, got %v", err)
+	}
+
+	scheduledAt := now.Add(time.Hour)
+	scheduled, err := svc.SchedulePublish(post.ID, scheduledAt, now)
+	if err != nil {
+		t.Fatalf("schedule publish: %v", err)
+	}
+	if scheduled.Status != "scheduled" || scheduled.ScheduledAt == nil {
+		t.Fatalf("expected scheduled status, got %q (%v)", scheduled.Status, scheduled.ScheduledAt)
+	}
+
+	listed, err := svc.ListPublished(PostFilter{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("list published: %v", err)
+	}
+	if listed.Total != 0 {
+		t.Fatalf("expected scheduled post to stay hidden, got %d publications", listed.Total)
+	}
+
+	result, err := svc.PublishDue(now.Add(30 * time.Minute))
+	if err != nil {
+		t.Fatalf("publish due before schedule: %v", err)
+	}
+	if len(result.Published) != 0 {
+		t.Fatalf("expected nothing to publish before schedule, got %v", result.Published)
+	}
+
+	// 模拟停机后补发：到期时间早于当前时间
+	result, err = svc.PublishDue(now.Add(3 * time.Hour))
+	if err != nil {
+		t.Fatalf("publish due: %v", err)
+	}
+	if len(result.Published) != 1 || result.Published[0] != post.ID {
+		t.Fatalf("expected post %d to be published, got %v", post.ID, result.Published)
+	}
+
+	publication, err := svc.LatestPublication(post.ID)
+	if err != nil {
+		t.Fatalf("latest publication: %v", err)
+	}
+	if !publication.PublishedAt.Equal(scheduledAt) {
+		t.Fatalf("expected publication time %v, got %v", scheduledAt, publication.PublishedAt)
+	}
+
+	reloaded, err := svc.Get(post.ID)
+	if err != nil {
+		t.Fatalf("reload post: %v", err)
+	}
+	if reloaded.Status != "published" || reloaded.ScheduledAt != nil {
+		t.Fatalf("expected schedule to be cleared after publish, got %q (%v)", reloaded.Status, reloaded.ScheduledAt)
+	}
+}
+
+func TestPostService_RescheduleAndCancel(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "rescheduler"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	now := time.Now()
+	post := createSchedulablePost(t, svc, user.ID, "# 改期文章\n正文")
+
+	if _, err := svc.Reschedule(post.ID, now.Add(time.Hour), now); !errors.Is(err, ErrPostNotScheduled) {
+		t.Fatalf("expected ErrPostNotScheduled, got %v", err)
+	}
+
+	if _, err := svc.SchedulePublish(post.ID, now.Add(time.Hour), now); err != nil {
+		t.Fatalf("schedule publish: %v", err)
+	}
+	later := now.Add(48 * time.Hour)
+	if _, err := svc.Reschedule(post.ID, later, now); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+
+	scheduled, err := svc.ListScheduled()
+	if err != nil {
+		t.Fatalf("list scheduled: %v", err)
+	}
+	if len(scheduled) != 1 || !scheduled[0].ScheduledAt.Equal(later) {
+		t.Fatalf("expected rescheduled post in list, got %+v", scheduled)
+	}
+
+	cancelled, err := svc.CancelSchedule(post.ID)
+	if err != nil {
+		t.Fatalf("cancel schedule: %v", err)
+	}
+	if cancelled.Status != "draft" || cancelled.ScheduledAt != nil {
+		t.Fatalf("expected cancelled post to return to draft, got %q (%v)", cancelled.Status, cancelled.ScheduledAt)
+	}
+}
+
+func TestPostService_PublishDueCancelsUnpublishablePosts(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "broken-schedule"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	now := time.Now()
+	post := createSchedulablePost(t, svc, user.ID, "# 会被清空的文章\n正文")
+	if _, err := svc.SchedulePublish(post.ID, now.Add(time.Minute), now); err != nil {
+		t.Fatalf("schedule publish: %v", err)
+	}
+	if err := gdb.Model(&db.Post{}).Where("id = ?", post.ID).Update("cover_url", "").Error; err != nil {
+		t.Fatalf("clear cover: %v", err)
+	}
+
+	result, err := svc.PublishDue(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("publish due: %v", err)
+	}
+	if len(result.Cancelled) != 1 || len(result.Published) != 0 {
+		t.Fatalf("expected schedule to be cancelled, got %+v", result)
+	}
+
+	reloaded, err := svc.Get(post.ID)
+	if err != nil {
+		t.Fatalf("reload post: %v", err)
+	}
+	if reloaded.Status != "draft" || reloaded.ScheduledAt != nil {
+		t.Fatalf("expected post to return to draft, got %q (%v)", reloaded.Status, reloaded.ScheduledAt)
+	}
+}
+
+func TestPostService_PublishScheduledSkipsChangedSchedules(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "schedule-race"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	now := time.Now()
+	loadDue := func(postID uint) *db.Post {
+		t.Helper()
+		var post db.Post
+		if err := gdb.Select("id", "user_id", "status", "scheduled_at").First(&post, postID).Error; err != nil {
+			t.Fatalf("load scheduled post: %v", err)
+		}
+		return &post
+	}
+
+	// 到期列表读出后文章被撤回，调度器不得重新发布
+	withdrawn := createSchedulablePost(t, svc, user.ID, "# 撤回后的定时更新\n正文")
+	if _, err := svc.Publish(withdrawn.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish post: %v", err)
+	}
+	if _, err := svc.SchedulePublish(withdrawn.ID, now.Add(time.Hour), now); err != nil {
+		t.Fatalf("schedule update: %v", err)
+	}
+	stale := loadDue(withdrawn.ID)
+	if _, err := svc.Withdraw(withdrawn.ID); err != nil {
+		t.Fatalf("withdraw post: %v", err)
+	}
+	if err := svc.publishScheduled(stale); !errors.Is(err, errScheduleNotPending) {
+		t.Fatalf("expected errScheduleNotPending after withdraw, got %v", err)
+	}
+	if post, _ := svc.Get(withdrawn.ID); post.Status != "withdrawn" || post.PublicationCount != 1 {
+		t.Fatalf("expected post to stay withdrawn, got %q (%d publications)", post.Status, post.PublicationCount)
+	}
+
+	// 改期后旧的到期记录同样认领失败，新的定时保留
+	rescheduled := createSchedulablePost(t, svc, user.ID, "# 改期的定时文章\n正文")
+	if _, err := svc.SchedulePublish(rescheduled.ID, now.Add(time.Hour), now); err != nil {
+		t.Fatalf("schedule publish: %v", err)
+	}
+	stale = loadDue(rescheduled.ID)
+	later := now.Add(48 * time.Hour)
+	if _, err := svc.Reschedule(rescheduled.ID, later, now); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if err := svc.publishScheduled(stale); !errors.Is(err, errScheduleNotPending) {
+		t.Fatalf("expected errScheduleNotPending after reschedule, got %v", err)
+	}
+	if post, _ := svc.Get(rescheduled.ID); post.Status != "scheduled" || post.ScheduledAt == nil || !post.ScheduledAt.Equal(later) {
+		t.Fatalf("expected new schedule to be kept, got %q (%v)", post.Status, post.ScheduledAt)
+	}
+
+	// 未变化的任务正常认领并发布
+	if err := svc.publishScheduled(loadDue(rescheduled.ID)); err != nil {
+		t.Fatalf("publish scheduled post: %v", err)
+	}
+	if post, _ := svc.Get(rescheduled.ID); post.Status != "published" || post.ScheduledAt != nil {
+		t.Fatalf("expected scheduled post to be published, got %q (%v)", post.Status, post.ScheduledAt)
+	}
+}
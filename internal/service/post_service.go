@@ -27,11 +27,18 @@ var (
 
 const maxDraftVersions = 10
 
+// publicationWithSlugColumns 在查询发布快照时附带文章当前 slug。
+const publicationWithSlugColumns = "post_publications.*, posts.slug AS post_slug"
+
 const hiddenLegacyDraftTitle = "about me"
 
 // PostService wraps post related database operations.
 type PostService struct {
 	db *gorm.DB
+	// unlockSecret 用于签发受保护文章的解锁令牌
+	unlockSecret []byte
+	// unlockAttempts 记录访客输错文章密码的次数，用于限制暴力尝试
+	unlockAttempts *unlockAttemptLimiter
 }
 
 // PostFilter describes filters for listing posts.
@@ -44,6 +51,10 @@ type PostFilter struct {
 	Sort      string
 	Page      int
 	PerPage   int
+	// 以下条件由 ParseSearchQuery 从搜索语句中编译得到
+	Phrases      []string
+	ExcludeTerms []string
+	RequiredTags []string
 }
 
 // PostListResult aggregates paginated list data and counters.
@@ -52,6 +63,8 @@ type PostListResult struct {
 	Total          int64
 	PublishedCount int64
 	DraftCount     int64
+	ScheduledCount int64
+	WithdrawnCount int64
 	TotalPages     int
 	Page           int
 	PerPage        int
@@ -76,6 +89,7 @@ type DailyCreationPoint struct {
 // PostInput represents fields accepted when creating or updating a post.
 type PostInput struct {
 	Title          string
+	Slug           string
 	Content        string
 	Summary        string
 	Visibility     string
@@ -85,6 +99,16 @@ type PostInput struct {
 	CoverWidth     int
 	CoverHeight    int
 	DraftSessionID string
+	// Password 仅在设置或更换访问密码时传入，留空保留原密码
+	Password string
+}
+
+// PostRef 描述前台 URL 引用解析出的文章及其规范 slug。
+type PostRef struct {
+	PostID uint
+	Slug   string
+	// Canonical 表示引用已是规范地址，无需重定向
+	Canonical bool
 }
 
 // CreatePostFromTemplateInput 表示从模板创建文章时的输入参数。
@@ -98,7 +122,7 @@ type CreatePostFromTemplateInput struct {
 
 // NewPostService creates a PostService instance.
 func NewPostService(gdb *gorm.DB) *PostService {
-	return &PostService{db: gdb}
+	return &PostService{db: gdb, unlockAttempts: newUnlockAttemptLimiter()}
 }
 
 // ListAll returns all posts ordered by created time descending.
@@ -135,6 +159,14 @@ func (s *PostService) Create(input PostInput) (*db.Post, error) {
 	if err != nil {
 		return nil, err
 	}
+	slug, err := normalizeSlugInput(input.Slug)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := resolvePostPasswordHash(input.Password, "")
+	if err != nil {
+		return nil, err
+	}
 
 	post := db.Post{
 		Content:     input.Content,
@@ -146,9 +178,20 @@ func (s *PostService) Create(input PostInput) (*db.Post, error) {
 		CoverWidth:  coverWidth,
 		CoverHeight: coverHeight,
 		ReadingTime: calculateReadingTime(input.Content),
+
+		PasswordHash: passwordHash,
 	}
 
-	return s.saveWithTags(&post, input.TagIDs, input.UserID, input.DraftSessionID)
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := applySlugChange(tx, &post, slug); err != nil {
+			return err
+		}
+		return s.saveWithTagsInTx(tx, &post, input.TagIDs, input.UserID, input.DraftSessionID)
+	}); err != nil {
+		return nil, err
+	}
+
+	return &post, nil
 }
 
 // CreateFromTemplate 根据模板创建一篇新的草稿文章。
@@ -234,21 +277,34 @@ func (s *PostService) Update(id uint, input PostInput) (*db.Post, error) {
 	if err != nil {
 		return nil, err
 	}
+	slug, err := normalizeSlugInput(input.Slug)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := resolvePostPasswordHash(input.Password, existing.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
 
 	existing.Content = input.Content
 	existing.Summary = strings.TrimSpace(input.Summary)
 	existing.Visibility = visibility
+	existing.PasswordHash = passwordHash
 	existing.CoverURL = coverURL
 	existing.CoverWidth = coverWidth
 	existing.CoverHeight = coverHeight
 	existing.ReadingTime = calculateReadingTime(input.Content)
 
-	post, err := s.saveWithTags(&existing, input.TagIDs, input.UserID, input.DraftSessionID)
-	if err != nil {
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := applySlugChange(tx, &existing, slug); err != nil {
+			return err
+		}
+		return s.saveWithTagsInTx(tx, &existing, input.TagIDs, input.UserID, input.DraftSessionID)
+	}); err != nil {
 		return nil, err
 	}
 
-	return post, nil
+	return &existing, nil
 }
 
 // UpdateSummary 仅更新文章摘要字段。
@@ -273,22 +329,115 @@ func (s *PostService) UpdateSummary(id uint, summary string) error {
 			return err
 		}
 
-		return tx.Model(&db.PostPublication{}).
+		if err := tx.Model(&db.PostPublication{}).
 			Where("id = ?", publication.ID).
-			Update("summary", trimmed).Error
+			Update("summary", trimmed).Error; err != nil {
+			return err
+		}
+		if err := syncSearchIndex(tx, id); err != nil {
+			return err
+		}
+		return markRelatedPostsStale(tx)
 	})
 }
 
-// Delete removes a post by id.
+// Delete 将文章移入回收站，标签、发布历史、系列归属与 slug 均保留以便恢复，
+// 彻底清除由 TrashService.Purge 完成。
 func (s *PostService) Delete(id uint) error {
-	if err := s.db.Unscoped().Delete(&db.Post{}, id).Error; err != nil {
+	result := s.db.Delete(&db.Post{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostNotFound
+	}
+	if err := syncSearchIndex(s.db, id); err != nil {
 		return err
 	}
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := refreshPostLinks(tx, id); err != nil {
+			return err
+		}
+		return markRelatedPostsStale(tx)
+	})
+}
+
+// ResolvePostRef 根据 URL 中的 slug、历史 slug 或数字 ID 定位文章。
+func (s *PostService) ResolvePostRef(ref string) (*PostRef, error) {
+	trimmed := strings.TrimSpace(ref)
+	if trimmed == "" {
+		return nil, ErrPostNotFound
+	}
+
+	var post db.Post
+	query := s.db.Select("id", "slug")
+	if onlyDigits(trimmed) {
+		query = query.Where("id = ?", trimmed)
+	} else {
+		query = query.Where("slug = ?", trimmed)
+	}
+	err := query.First(&post).Error
+	if err == nil {
+		return &PostRef{
+			PostID:    post.ID,
+			Slug:      post.Slug,
+			Canonical: post.Slug == trimmed || (post.Slug == "" && onlyDigits(trimmed)),
+		}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if onlyDigits(trimmed) {
+		return nil, ErrPostNotFound
+	}
+
+	var redirect db.PostSlugRedirect
+	if err := s.db.Where("slug = ?", trimmed).First(&redirect).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	if err := s.db.Select("id", "slug").First(&post, redirect.PostID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	return &PostRef{PostID: post.ID, Slug: post.Slug}, nil
+}
+
+// BackfillSlugs 为尚未设置 slug 的已发布文章按标题生成 slug。
+func (s *PostService) BackfillSlugs() (int, error) {
+	var posts []db.Post
+	if err := s.db.Select("id", "content", "slug").
+		Where("status = ? AND (slug IS NULL OR slug = '')", "published").
+		Order("id asc").
+		Find(&posts).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for i := range posts {
+		post := &posts[i]
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			slug, err := generateUniqueSlug(tx, post.Title, post.ID)
+			if err != nil {
+				return err
+			}
+			return tx.Model(&db.Post{}).Where("id = ?", post.ID).Update("slug", slug).Error
+		}); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
 }
 
 // List provides paginated posts with aggregated counters based on filters.
 func (s *PostService) List(filter PostFilter) (*PostListResult, error) {
+	filter = ParseSearchQuery(filter.Search, true).Apply(filter)
 	result := &PostListResult{Page: filter.Page, PerPage: filter.PerPage}
 	if result.Page <= 0 {
 		result.Page = 1
@@ -343,6 +492,14 @@ func (s *PostService) List(filter PostFilter) (*PostListResult, error) {
 		return nil, err
 	}
 
+	if err := counterBuilder().Where("posts.status = ?", "scheduled").Count(&result.ScheduledCount).Error; err != nil {
+		return nil, err
+	}
+
+	if err := counterBuilder().Where("posts.status = ?", "withdrawn").Count(&result.WithdrawnCount).Error; err != nil {
+		return nil, err
+	}
+
 	if result.Total == 0 {
 		result.TotalPages = 1
 	} else {
@@ -355,6 +512,11 @@ func (s *PostService) List(filter PostFilter) (*PostListResult, error) {
 
 // Publish 创建文章发布快照，并更新文章发布状态
 func (s *PostService) Publish(postID, userID uint, publishedAt *time.Time) (*db.PostPublication, error) {
+	return s.publish(postID, userID, publishedAt, nil)
+}
+
+// publish 是 Publish 的实现，claim 不为空时在发布事务开始时执行，返回错误则放弃本次发布。
+func (s *PostService) publish(postID, userID uint, publishedAt *time.Time, claim func(tx *gorm.DB) error) (*db.PostPublication, error) {
 	var post db.Post
 	if err := s.db.Preload("Tags").First(&post, postID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -365,67 +527,33 @@ func (s *PostService) Publish(postID, userID uint, publishedAt *time.Time) (*db.
 
 	post.PopulateDerivedFields()
 
-	if strings.TrimSpace(post.Title) == "" {
-		return nil, ErrInvalidPublishState
-	}
-	if strings.TrimSpace(post.Content) == "" {
-		return nil, ErrInvalidPublishState
-	}
-	if strings.TrimSpace(post.CoverURL) == "" {
-		return nil, ErrCoverRequired
-	}
-	if post.CoverWidth <= 0 || post.CoverHeight <= 0 {
-		return nil, ErrCoverInvalid
+	if err := validatePublishable(&post); err != nil {
+		return nil, err
 	}
 
-	readingTime := calculateReadingTime(post.Content)
-	version := post.PublicationCount + 1
-
 	publishTime := time.Now()
 	if publishedAt != nil && !publishedAt.IsZero() {
 		publishTime = *publishedAt
 	}
 
 	publication := db.PostPublication{
-		PostID:      post.ID,
 		Content:     post.Content,
 		Summary:     post.Summary,
 		Visibility:  post.Visibility,
-		ReadingTime: readingTime,
 		CoverURL:    post.CoverURL,
 		CoverWidth:  post.CoverWidth,
 		CoverHeight: post.CoverHeight,
 		UserID:      userID,
 		PublishedAt: publishTime,
-		Version:     version,
 	}
 
 	if err := s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(&publication).Error; err != nil {
-			return err
-		}
-
-		if len(post.Tags) > 0 {
-			if err := tx.Model(&publication).Association("Tags").Replace(post.Tags); err != nil {
+		if claim != nil {
+			if err := claim(tx); err != nil {
 				return err
 			}
 		}
-
-		updates := map[string]interface{}{
-			"status":                "published",
-			"reading_time":          readingTime,
-			"published_at":          publishTime,
-			"publication_count":     version,
-			"latest_publication_id": publication.ID,
-		}
-
-		if err := tx.Model(&db.Post{}).
-			Where("id = ?", post.ID).
-			Updates(updates).Error; err != nil {
-			return err
-		}
-
-		return nil
+		return publishSnapshot(tx, &post, &publication, post.Tags)
 	}); err != nil {
 		return nil, err
 	}
@@ -434,15 +562,98 @@ func (s *PostService) Publish(postID, userID uint, publishedAt *time.Time) (*db.
 		return nil, err
 	}
 
+	publication.PostSlug = post.Slug
 	publication.PopulateDerivedFields()
 	return &publication, nil
 }
 
+// publishSnapshot 写入新的发布快照并将文章切换为已发布状态，Publish 与 RepublishVersion 共用。
+// 快照派生的数据（全文索引、内部链接、相关推荐过期标记）在同一事务中随之更新，新增的发布副作用也应放在这里。
+func publishSnapshot(tx *gorm.DB, post *db.Post, publication *db.PostPublication, tags []db.Tag) error {
+	// 记录上一次发布的标题，标题变化后引用旧标题的链接需要重新解析
+	var previousTitle string
+	if post.LatestPublicationID != nil {
+		var previous db.PostPublication
+		if err := tx.Select("id", "content").First(&previous, *post.LatestPublicationID).Error; err == nil {
+			previousTitle = previous.Title
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	publication.PostID = post.ID
+	publication.Visibility = db.NormalizePostVisibility(publication.Visibility)
+	publication.ReadingTime = calculateReadingTime(publication.Content)
+	publication.Version = post.PublicationCount + 1
+
+	if err := tx.Create(publication).Error; err != nil {
+		return err
+	}
+	if len(tags) > 0 {
+		if err := tx.Model(publication).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+	}
+
+	if strings.TrimSpace(post.Slug) == "" {
+		slug, err := generateUniqueSlug(tx, db.DeriveTitleFromContent(publication.Content), post.ID)
+		if err != nil {
+			return err
+		}
+		post.Slug = slug
+	}
+
+	if err := tx.Model(&db.Post{}).
+		Where("id = ?", post.ID).
+		Updates(map[string]interface{}{
+			"status":                "published",
+			"published_at":          publication.PublishedAt,
+			"publication_count":     publication.Version,
+			"latest_publication_id": publication.ID,
+			"slug":                  post.Slug,
+			"scheduled_at":          nil,
+		}).Error; err != nil {
+		return err
+	}
+	post.Status = "published"
+	post.PublicationCount = publication.Version
+	post.LatestPublicationID = &publication.ID
+
+	if err := syncSearchIndex(tx, post.ID); err != nil {
+		return err
+	}
+	if err := refreshPostLinks(tx, post.ID, previousTitle); err != nil {
+		return err
+	}
+	return markRelatedPostsStale(tx)
+}
+
+// validatePublishable 校验文章是否满足发布所需的标题、正文与封面。
+func validatePublishable(post *db.Post) error {
+	if strings.TrimSpace(post.Title) == "" {
+		return ErrInvalidPublishState
+	}
+	if strings.TrimSpace(post.Content) == "" {
+		return ErrInvalidPublishState
+	}
+	if strings.TrimSpace(post.CoverURL) == "" {
+		return ErrCoverRequired
+	}
+	if post.CoverWidth <= 0 || post.CoverHeight <= 0 {
+		return ErrCoverInvalid
+	}
+	if db.NormalizePostVisibility(post.Visibility) == db.PostVisibilityProtected && strings.TrimSpace(post.PasswordHash) == "" {
+		return ErrPostPasswordRequired
+	}
+	return nil
+}
+
 // LatestPublication 返回文章最近一次发布快照
 func (s *PostService) LatestPublication(postID uint) (*db.PostPublication, error) {
 	var publication db.PostPublication
 	if err := s.db.Preload("Tags").
 		Preload("User").
+		Select(publicationWithSlugColumns).
 		Joins("JOIN posts ON posts.latest_publication_id = post_publications.id").
 		Where("posts.id = ?", postID).
 		First(&publication).Error; err != nil {
@@ -549,6 +760,7 @@ func (s *PostService) DailyCreationHeatmap(end time.Time, days int, loc *time.Lo
 
 // ListPublished 返回最新发布的文章快照列表
 func (s *PostService) ListPublished(filter PostFilter) (*PublicationListResult, error) {
+	filter = ParseSearchQuery(filter.Search, false).Apply(filter)
 	result := &PublicationListResult{Page: filter.Page, PerPage: filter.PerPage}
 	if result.Page <= 0 {
 		result.Page = 1
@@ -557,11 +769,19 @@ func (s *PostService) ListPublished(filter PostFilter) (*PublicationListResult,
 		result.PerPage = 10
 	}
 
+	// 全文索引可用时按 BM25 相关度排序，否则退回逐行匹配并按发布时间排序
+	indexed := false
+	match := ""
+	if filter.hasTextSearch() && searchIndexReady(s.db) {
+		indexed = true
+		match = buildSearchMatchQuery(filter.Search, filter.Phrases)
+	}
+
 	baseQuery := s.db.Model(&db.PostPublication{}).
 		Joins("JOIN posts ON posts.latest_publication_id = post_publications.id").
-		Where("posts.status = ?", "published")
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published")
 	baseQuery = s.applyDiscoverablePublicationFilter(baseQuery, "post_publications")
-	baseQuery = s.applyPublicationFilters(baseQuery, filter)
+	baseQuery = s.applyPublicationFilters(baseQuery, filter, indexed)
 
 	if err := baseQuery.Count(&result.Total).Error; err != nil {
 		return nil, err
@@ -574,9 +794,14 @@ func (s *PostService) ListPublished(filter PostFilter) (*PublicationListResult,
 		Preload("Tags").
 		Preload("User").
 		Joins("JOIN posts ON posts.latest_publication_id = post_publications.id").
-		Where("posts.status = ?", "published")
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published")
 	dataQuery = s.applyDiscoverablePublicationFilter(dataQuery, "post_publications")
-	dataQuery = s.applyPublicationFilters(dataQuery, filter)
+	dataQuery = s.applyPublicationFilters(dataQuery, filter, indexed)
+	if match != "" {
+		dataQuery = dataQuery.Select(publicationWithSlugColumns + ", " + searchSnippetColumn).Order(searchRankExpr)
+	} else {
+		dataQuery = dataQuery.Select(publicationWithSlugColumns)
+	}
 
 	if err := dataQuery.
 		Order("post_publications.published_at desc, post_publications.id desc").
@@ -588,6 +813,7 @@ func (s *PostService) ListPublished(filter PostFilter) (*PublicationListResult,
 
 	for i := range publications {
 		publications[i].PopulateDerivedFields()
+		publications[i].SearchSnippet = restoreSearchText(publications[i].SearchSnippet)
 	}
 
 	if result.Total == 0 {
@@ -603,8 +829,9 @@ func (s *PostService) ListPublished(filter PostFilter) (*PublicationListResult,
 // ListAllPublished 返回所有文章的最新发布快照
 func (s *PostService) ListAllPublished() ([]db.PostPublication, error) {
 	query := s.db.Preload("Tags").
+		Select(publicationWithSlugColumns).
 		Joins("JOIN posts ON posts.latest_publication_id = post_publications.id").
-		Where("posts.status = ?", "published")
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published")
 	query = s.applyDiscoverablePublicationFilter(query, "post_publications")
 
 	var publications []db.PostPublication
@@ -619,6 +846,23 @@ func (s *PostService) ListAllPublished() ([]db.PostPublication, error) {
 	return publications, nil
 }
 
+// ListPublishedPaths 返回所有已发布文章的前台路径，包含不在列表中展示的文章。
+func (s *PostService) ListPublishedPaths() ([]string, error) {
+	var posts []db.Post
+	if err := s.db.Select("id", "slug").
+		Where("status = ? AND latest_publication_id IS NOT NULL", "published").
+		Order("id asc").
+		Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(posts))
+	for _, post := range posts {
+		paths = append(paths, post.PublicPath())
+	}
+	return paths, nil
+}
+
 // ListDraftVersions 返回指定文章的草稿历史版本。
 func (s *PostService) ListDraftVersions(postID uint, limit int) ([]db.PostDraftVersion, error) {
 	if postID == 0 {
@@ -827,12 +1071,17 @@ func hashDraftContent(content string) string {
 func (s *PostService) applyFilters(query *gorm.DB, filter PostFilter, includeStatus bool) *gorm.DB {
 	query = s.applyHiddenDraftFilter(query, "posts")
 
-	if tokens := splitSearchTokens(filter.Search); len(tokens) > 0 {
+	if filter.hasTextSearch() {
 		alias := "posts"
 		titleExpr := derivedTitleQueryExpr(alias)
-		for _, token := range tokens {
+		matchExpr := fmt.Sprintf("(%s LIKE ? OR %s.content LIKE ? OR %s.summary LIKE ?)", titleExpr, alias, alias)
+		for _, token := range filter.searchPatterns() {
 			search := "%" + token + "%"
-			query = query.Where(fmt.Sprintf("(%s LIKE ? OR %s.content LIKE ? OR %s.summary LIKE ?)", titleExpr, alias, alias), search, search, search)
+			query = query.Where(matchExpr, search, search, search)
+		}
+		for _, term := range filter.ExcludeTerms {
+			search := "%" + term + "%"
+			query = query.Where("NOT "+matchExpr, search, search, search)
 		}
 	}
 
@@ -851,6 +1100,15 @@ func (s *PostService) applyFilters(query *gorm.DB, filter PostFilter, includeSta
 		query = query.Where("posts.id IN (?)", subQuery)
 	}
 
+	for _, tag := range filter.RequiredTags {
+		subQuery := s.db.Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("LOWER(tags.name) = ?", strings.ToLower(tag))
+
+		query = query.Where("posts.id IN (?)", subQuery)
+	}
+
 	if filter.StartDate != nil {
 		query = query.Where("posts.created_at >= ?", filter.StartDate)
 	}
@@ -868,22 +1126,51 @@ func (s *PostService) applyHiddenDraftFilter(query *gorm.DB, alias string) *gorm
 	return query.Where(clause, "draft", hiddenLegacyDraftTitle)
 }
 
+// applyDiscoverablePublicationFilter 保留可出现在列表中的快照，受密码保护的文章仅展示标题与加锁标记。
 func (s *PostService) applyDiscoverablePublicationFilter(query *gorm.DB, alias string) *gorm.DB {
+	return applyDiscoverablePublicationFilter(query, alias)
+}
+
+// applyDiscoverablePublicationFilter 供其他服务复用的列表可见性筛选，不公开列出的文章被排除。
+func applyDiscoverablePublicationFilter(query *gorm.DB, alias string) *gorm.DB {
 	if query == nil {
 		return nil
 	}
 	visibilityExpr := normalizedVisibilityQueryExpr(alias)
-	return query.Where(fmt.Sprintf("%s = ?", visibilityExpr), db.PostVisibilityPublic)
+	return query.Where(fmt.Sprintf("%s IN ?", visibilityExpr), []string{db.PostVisibilityPublic, db.PostVisibilityProtected})
 }
 
-func (s *PostService) applyPublicationFilters(query *gorm.DB, filter PostFilter) *gorm.DB {
-	if tokens := splitSearchTokens(filter.Search); len(tokens) > 0 {
-		alias := "post_publications"
-		titleExpr := derivedTitleQueryExpr(alias)
-		for _, token := range tokens {
+// applyPublicationFilters 应用搜索、标签与日期筛选，indexed 为 true 时通过全文索引检索。
+func (s *PostService) applyPublicationFilters(query *gorm.DB, filter PostFilter, indexed bool) *gorm.DB {
+	alias := "post_publications"
+	titleExpr := derivedTitleQueryExpr(alias)
+	visibilityExpr := normalizedVisibilityQueryExpr(alias)
+	// 受密码保护的文章只按标题匹配，避免通过搜索探测正文
+	likeExpr := fmt.Sprintf("(%s LIKE ? OR (%s <> ? AND (%s.content LIKE ? OR %s.summary LIKE ?)))", titleExpr, visibilityExpr, alias, alias)
+
+	match := ""
+	if indexed {
+		match = buildSearchMatchQuery(filter.Search, filter.Phrases)
+	}
+	if match != "" {
+		query = query.Joins("JOIN post_search ON post_search.rowid = posts.id").Where("post_search MATCH ?", match)
+	} else {
+		for _, token := range filter.searchPatterns() {
 			search := "%" + token + "%"
-			query = query.Where(fmt.Sprintf("(%s LIKE ? OR %s.content LIKE ? OR %s.summary LIKE ?)", titleExpr, alias, alias), search, search, search)
+			query = query.Where(likeExpr, search, db.PostVisibilityProtected, search, search)
+		}
+	}
+
+	// 排除词按短语匹配，-"error handling" 只排除包含完整短语的文章
+	for _, term := range filter.ExcludeTerms {
+		if indexed {
+			if exclude := buildSearchPhraseMatch(term); exclude != "" {
+				query = query.Where("posts.id NOT IN (SELECT rowid FROM post_search WHERE post_search MATCH ?)", exclude)
+				continue
+			}
 		}
+		search := "%" + term + "%"
+		query = query.Where("NOT "+likeExpr, search, db.PostVisibilityProtected, search, search)
 	}
 
 	if len(filter.TagNames) > 0 {
@@ -896,6 +1183,15 @@ func (s *PostService) applyPublicationFilters(query *gorm.DB, filter PostFilter)
 		query = query.Where("post_publications.id IN (?)", subQuery)
 	}
 
+	for _, tag := range filter.RequiredTags {
+		subQuery := s.db.Table("post_publication_tags").
+			Select("post_publication_tags.post_publication_id").
+			Joins("JOIN tags ON tags.id = post_publication_tags.tag_id").
+			Where("LOWER(tags.name) = ?", strings.ToLower(tag))
+
+		query = query.Where("post_publications.id IN (?)", subQuery)
+	}
+
 	if filter.StartDate != nil {
 		query = query.Where("post_publications.published_at >= ?", filter.StartDate)
 	}
@@ -926,7 +1222,9 @@ func calculateReadingTime(content string) int {
 		return 0
 	}
 
-	sanitized := imagePattern.ReplaceAllString(trimmed, "")
+	// 图表代码块不计入阅读字数
+	sanitized := StripDiagramFences(trimmed)
+	sanitized = imagePattern.ReplaceAllString(sanitized, "")
 	sanitized = linkPattern.ReplaceAllStringFunc(sanitized, func(match string) string {
 		open := strings.Index(match, "[")
 		close := strings.Index(match, "]")
@@ -969,7 +1267,7 @@ func normalizeVisibilityInput(raw, fallback string) (string, error) {
 	}
 
 	switch trimmed {
-	case db.PostVisibilityPublic, db.PostVisibilityUnlisted:
+	case db.PostVisibilityPublic, db.PostVisibilityUnlisted, db.PostVisibilityProtected:
 		return trimmed, nil
 	default:
 		return "", ErrVisibilityInvalid
@@ -989,3 +1287,13 @@ func derivedTitleQueryExpr(alias string) string {
 func splitSearchTokens(search string) []string {
 	return strings.Fields(search)
 }
+
+// hasTextSearch 表示筛选条件中包含关键词、短语或排除词。
+func (f PostFilter) hasTextSearch() bool {
+	return strings.TrimSpace(f.Search) != "" || len(f.Phrases) > 0 || len(f.ExcludeTerms) > 0
+}
+
+// searchPatterns 返回逐行匹配时需要同时命中的关键词与短语。
+func (f PostFilter) searchPatterns() []string {
+	return append(splitSearchTokens(f.Search), f.Phrases...)
+}
@@ -22,7 +22,7 @@ func setupPostServiceTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("failed to open test database: %v", err)
 	}
 
-	if err := gdb.AutoMigrate(&db.User{}, &db.Tag{}, &db.PostTemplate{}, &db.Post{}, &db.PostPublication{}, &db.PostDraftVersion{}, &db.PostStatistic{}); err != nil {
+	if err := gdb.AutoMigrate(&db.User{}, &db.Tag{}, &db.PostTemplate{}, &db.Post{}, &db.PostPublication{}, &db.PostSlugRedirect{}, &db.PostPreviewToken{}, &db.Series{}, &db.SeriesPost{}, &db.PostDraftVersion{}, &db.PostStatistic{}, &db.PostVisit{}, &db.GalleryImage{}, &db.PostLink{}, &db.RelatedPost{}, &db.SystemSetting{}); err != nil {
 		t.Fatalf("failed to migrate test database: %v", err)
 	}
 	return gdb
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+// ListPublications 返回文章的全部发布版本，按版本号倒序排列。
+func (s *PostService) ListPublications(postID uint) ([]db.PostPublication, error) {
+	if err := s.ensurePostExists(postID); err != nil {
+		return nil, err
+	}
+
+	var publications []db.PostPublication
+	if err := s.db.Preload("Tags").
+		Preload("User").
+		Where("post_id = ?", postID).
+		Order("version desc, id desc").
+		Find(&publications).Error; err != nil {
+		return nil, err
+	}
+	return publications, nil
+}
+
+// GetPublicationVersion 返回文章指定版本号的发布快照。
+func (s *PostService) GetPublicationVersion(postID uint, version int) (*db.PostPublication, error) {
+	if err := s.ensurePostExists(postID); err != nil {
+		return nil, err
+	}
+
+	var publication db.PostPublication
+	if err := s.db.Preload("Tags").
+		Preload("User").
+		Where("post_id = ? AND version = ?", postID, version).
+		Order("id desc").
+		First(&publication).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPublicationNotFound
+		}
+		return nil, err
+	}
+	return &publication, nil
+}
+
+// RepublishVersion 以指定版本的快照内容生成一个新的发布版本。
+// resetDraft 为 true 时同时把工作草稿重置为该快照，便于一键撤销错误的编辑。
+func (s *PostService) RepublishVersion(postID uint, version int, userID uint, resetDraft bool) (*db.PostPublication, error) {
+	source, err := s.GetPublicationVersion(postID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var post db.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	publication := db.PostPublication{
+		Content:     source.Content,
+		Summary:     source.Summary,
+		Visibility:  source.Visibility,
+		CoverURL:    source.CoverURL,
+		CoverWidth:  source.CoverWidth,
+		CoverHeight: source.CoverHeight,
+		UserID:      userID,
+		PublishedAt: time.Now(),
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if resetDraft {
+			post.Content = source.Content
+			post.Summary = source.Summary
+			post.Visibility = db.NormalizePostVisibility(source.Visibility)
+			post.CoverURL = source.CoverURL
+			post.CoverWidth = source.CoverWidth
+			post.CoverHeight = source.CoverHeight
+			post.ReadingTime = calculateReadingTime(source.Content)
+
+			tagIDs := make([]uint, 0, len(source.Tags))
+			for _, tag := range source.Tags {
+				tagIDs = append(tagIDs, tag.ID)
+			}
+			if err := s.saveWithTagsInTx(tx, &post, tagIDs, userID, ""); err != nil {
+				return err
+			}
+		}
+		return publishSnapshot(tx, &post, &publication, source.Tags)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.LatestPublication(post.ID)
+}
+
+func (s *PostService) ensurePostExists(postID uint) error {
+	if postID == 0 {
+		return ErrPostNotFound
+	}
+	if err := s.db.Select("id").First(&db.Post{}, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPostNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestPostService_RepublishVersion(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "rollback"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	goTag := db.Tag{Name: "Go"}
+	if err := gdb.Create(&goTag).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	post, err := svc.Create(PostInput{
+		Content:     "# 原始标题\n第一版正文",
+		Summary:     "第一版摘要",
+		TagIDs:      []uint{goTag.ID},
+		UserID:      user.ID,
+		CoverURL:    "https://example.com/v1.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish v1: %v", err)
+	}
+
+	if _, err := svc.Update(post.ID, PostInput{
+		Content:     "# 原始标题\n写坏了的第二版",
+		Visibility:  db.PostVisibilityUnlisted,
+		UserID:      user.ID,
+		CoverURL:    "https://example.com/v2.jpg",
+		CoverWidth:  1000,
+		CoverHeight: 500,
+	}); err != nil {
+		t.Fatalf("update post: %v", err)
+	}
+	if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish v2: %v", err)
+	}
+
+	if _, err := svc.GetPublicationVersion(post.ID, 9); !errors.Is(err, ErrPublicationNotFound) {
+		t.Fatalf("expected ErrPublicationNotFound, got %v", err)
+	}
+
+	republished, err := svc.RepublishVersion(post.ID, 1, user.ID, false)
+	if err != nil {
+		t.Fatalf("republish v1: %v", err)
+	}
+	if republished.Version != 3 {
+		t.Fatalf("expected new version 3, got %d", republished.Version)
+	}
+	if republished.Content != "# 原始标题\n第一版正文" || republished.CoverURL != "https://example.com/v1.jpg" {
+		t.Fatalf("expected snapshot content and cover to be copied, got %+v", republished)
+	}
+	if republished.Visibility != db.PostVisibilityPublic {
+		t.Fatalf("expected visibility from v1, got %q", republished.Visibility)
+	}
+	if len(republished.Tags) != 1 || republished.Tags[0].ID != goTag.ID {
+		t.Fatalf("expected tags from v1, got %+v", republished.Tags)
+	}
+
+	draft, err := svc.Get(post.ID)
+	if err != nil {
+		t.Fatalf("reload post: %v", err)
+	}
+	if draft.Content != "# 原始标题\n写坏了的第二版" {
+		t.Fatalf("expected working draft to be untouched without reset, got %q", draft.Content)
+	}
+	if draft.PublicationCount != 3 {
+		t.Fatalf("expected publication count 3, got %d", draft.PublicationCount)
+	}
+
+	if _, err := svc.RepublishVersion(post.ID, 1, user.ID, true); err != nil {
+		t.Fatalf("republish with reset: %v", err)
+	}
+	draft, err = svc.Get(post.ID)
+	if err != nil {
+		t.Fatalf("reload post after reset: %v", err)
+	}
+	if draft.Content != "# 原始标题\n第一版正文" || draft.Summary != "第一版摘要" || len(draft.Tags) != 1 {
+		t.Fatalf("expected working draft to be reset to v1, got %+v", draft)
+	}
+	if draft.Status != "published" || draft.PublicationCount != 4 {
+		t.Fatalf("expected published status with 4 publications, got %q/%d", draft.Status, draft.PublicationCount)
+	}
+
+	publications, err := svc.ListPublications(post.ID)
+	if err != nil {
+		t.Fatalf("list publications: %v", err)
+	}
+	if len(publications) != 4 || publications[0].Version != 4 {
+		t.Fatalf("expected 4 publications newest first, got %d", len(publications))
+	}
+}
+
+func TestPostService_RepublishVersionRestoresLinks(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "rollback-links"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	publish := func(id uint, content string) uint {
+		t.Helper()
+		input := PostInput{
+			Content:     content,
+			UserID:      user.ID,
+			CoverURL:    "https://example.com/cover.jpg",
+			CoverWidth:  1200,
+			CoverHeight: 800,
+		}
+		if id == 0 {
+			post, err := svc.Create(input)
+			if err != nil {
+				t.Fatalf("create post: %v", err)
+			}
+			id = post.ID
+		} else if _, err := svc.Update(id, input); err != nil {
+			t.Fatalf("update post: %v", err)
+		}
+		if _, err := svc.Publish(id, user.ID, nil); err != nil {
+			t.Fatalf("publish post: %v", err)
+		}
+		return id
+	}
+	backlinkCount := func(id uint) int {
+		t.Helper()
+		backlinks, err := svc.ListBacklinks(id)
+		if err != nil {
+			t.Fatalf("list backlinks: %v", err)
+		}
+		return len(backlinks)
+	}
+
+	target := publish(0, "# 目标文章\n正文")
+	source := publish(0, "# 引用文章\n见 [[目标文章]]")
+	publish(source, "# 引用文章\n删掉了链接")
+	if got := backlinkCount(target); got != 0 {
+		t.Fatalf("expected link removed by v2, got %d backlinks", got)
+	}
+
+	if _, err := svc.RepublishVersion(source, 1, user.ID, false); err != nil {
+		t.Fatalf("republish v1: %v", err)
+	}
+	if got := backlinkCount(target); got != 1 {
+		t.Fatalf("expected republished v1 to restore its link, got %d backlinks", got)
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPostNotPublished = errors.New("post is not published")
+	ErrPostWithdrawn    = errors.New("post has been withdrawn")
+)
+
+// Withdraw 将已发布文章下线，保留全部发布快照与版本计数。
+// 下线后文章不再出现在公开列表、RSS、站点地图与标签统计中，公开地址返回 410；
+// 再次发布时版本号在原有基础上继续递增。
+func (s *PostService) Withdraw(postID uint) (*db.Post, error) {
+	var post db.Post
+	if err := s.db.Select("id", "status").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	if post.Status != "published" {
+		return nil, ErrPostNotPublished
+	}
+
+	// 同时取消待执行的定时更新，避免下线后被调度器重新发布。
+	// 状态更新与索引、链接、推荐标记在同一事务中完成，按状态条件更新以防并发下线或发布交错
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&db.Post{}).
+			Where("id = ? AND status = ?", post.ID, "published").
+			Updates(map[string]interface{}{
+				"status":       "withdrawn",
+				"scheduled_at": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPostNotPublished
+		}
+		if err := syncSearchIndex(tx, post.ID); err != nil {
+			return err
+		}
+		if err := refreshPostLinks(tx, post.ID); err != nil {
+			return err
+		}
+		return markRelatedPostsStale(tx)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.Get(post.ID)
+}
+
+// PublicPublication 返回可公开访问的最新发布快照，已下线文章返回 ErrPostWithdrawn。
+func (s *PostService) PublicPublication(postID uint) (*db.PostPublication, error) {
+	var post db.Post
+	if err := s.db.Select("id", "status").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPublicationNotFound
+		}
+		return nil, err
+	}
+	if post.Status == "withdrawn" {
+		return nil, ErrPostWithdrawn
+	}
+	return s.LatestPublication(post.ID)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestPostService_WithdrawAndRepublish(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	tags := NewTagService(gdb)
+
+	user := db.User{Username: "withdrawer"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	tag := db.Tag{Name: "Go"}
+	if err := gdb.Create(&tag).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	post, err := svc.Create(PostInput{
+		Content:     "# 下线文章\n正文",
+		UserID:      user.ID,
+		TagIDs:      []uint{tag.ID},
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	if _, err := svc.Withdraw(post.ID); !errors.Is(err, ErrPostNotPublished) {
+		t.Fatalf("expected ErrPostNotPublished for draft, got %v", err)
+	}
+	if _, err := svc.Withdraw(post.ID + 100); !errors.Is(err, ErrPostNotFound) {
+		t.Fatalf("expected ErrPostNotFound, got %v", err)
+	}
+
+	if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish post: %v", err)
+	}
+
+	withdrawn, err := svc.Withdraw(post.ID)
+	if err != nil {
+		t.Fatalf("withdraw post: %v", err)
+	}
+	if withdrawn.Status != "withdrawn" {
+		t.Fatalf("expected withdrawn status, got %q", withdrawn.Status)
+	}
+	if withdrawn.LatestPublicationID == nil || withdrawn.PublicationCount != 1 {
+		t.Fatalf("expected publication history to be kept, got %+v", withdrawn)
+	}
+
+	if _, err := svc.PublicPublication(post.ID); !errors.Is(err, ErrPostWithdrawn) {
+		t.Fatalf("expected ErrPostWithdrawn, got %v", err)
+	}
+	if _, err := svc.LatestPublication(post.ID); err != nil {
+		t.Fatalf("expected latest publication to stay available for admin, got %v", err)
+	}
+
+	all, err := svc.ListAllPublished()
+	if err != nil {
+		t.Fatalf("list all published: %v", err)
+	}
+	if len(all) != 0 {
+		t.Fatalf("expected withdrawn post to be excluded, got %d publications", len(all))
+	}
+
+	usage, err := tags.PublishedUsage()
+	if err != nil {
+		t.Fatalf("published usage: %v", err)
+	}
+	for _, item := range usage {
+		if item.ID == tag.ID && item.Count != 0 {
+			t.Fatalf("expected withdrawn post to be excluded from tag usage, got %d", item.Count)
+		}
+	}
+
+	publications, err := svc.ListPublications(post.ID)
+	if err != nil {
+		t.Fatalf("list publications: %v", err)
+	}
+	if len(publications) != 1 {
+		t.Fatalf("expected publication history to be kept, got %d", len(publications))
+	}
+
+	republished, err := svc.Publish(post.ID, user.ID, nil)
+	if err != nil {
+		t.Fatalf("republish post: %v", err)
+	}
+	if republished.Version != 2 {
+		t.Fatalf("expected version numbering to continue at 2, got %d", republished.Version)
+	}
+	if _, err := svc.PublicPublication(post.ID); err != nil {
+		t.Fatalf("expected republished post to be public, got %v", err)
+	}
+}
+
+func TestPostService_WithdrawRollsBackWhenDerivedDataFails(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "withdraw-rollback"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	post, err := svc.Create(PostInput{
+		Content:     "# 回滚下线\n正文",
+		UserID:      user.ID,
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish post: %v", err)
+	}
+
+	// 相关推荐标记写入失败时，状态更新一并回滚，文章保持已发布以便重试
+	if err := gdb.Migrator().DropTable(&db.SystemSetting{}); err != nil {
+		t.Fatalf("drop system settings: %v", err)
+	}
+	if _, err := svc.Withdraw(post.ID); err == nil {
+		t.Fatalf("expected withdraw to fail")
+	}
+	var stored db.Post
+	if err := gdb.First(&stored, post.ID).Error; err != nil {
+		t.Fatalf("reload post: %v", err)
+	}
+	if stored.Status != "published" {
+		t.Fatalf("expected failed withdraw to keep the post published, got %q", stored.Status)
+	}
+
+	if err := gdb.AutoMigrate(&db.SystemSetting{}); err != nil {
+		t.Fatalf("migrate system settings: %v", err)
+	}
+	if _, err := svc.Withdraw(post.ID); err != nil {
+		t.Fatalf("retry withdraw: %v", err)
+	}
+	if _, err := svc.Withdraw(post.ID); !errors.Is(err, ErrPostNotPublished) {
+		t.Fatalf("expected second withdraw to report ErrPostNotPublished, got %v", err)
+	}
+}
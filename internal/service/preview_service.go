@@ -0,0 +1,162 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPreviewTokenNotFound = errors.New("preview token not found")
+	ErrPreviewTokenExpired  = errors.New("preview token expired or revoked")
+	ErrPreviewTTLInvalid    = errors.New("preview token lifetime is invalid")
+)
+
+const (
+	// DefaultPreviewTTL 是未指定有效期时预览链接的默认有效时长。
+	DefaultPreviewTTL = 7 * 24 * time.Hour
+	// MaxPreviewTTL 限制预览链接的最长有效期，避免草稿被长期外泄。
+	MaxPreviewTTL = 30 * 24 * time.Hour
+
+	previewTokenBytes = 24
+)
+
+// PreviewService 管理草稿的可分享预览链接。
+type PreviewService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// PreviewTokenInput 描述创建预览链接时的参数，TTL 为零时使用默认有效期。
+type PreviewTokenInput struct {
+	TTL  time.Duration
+	Note string
+}
+
+// NewPreviewService constructs a PreviewService.
+func NewPreviewService(gdb *gorm.DB) *PreviewService {
+	return &PreviewService{db: gdb, now: time.Now}
+}
+
+// Create 为文章生成新的预览链接，返回值中的 Token 是唯一一次可获得的原始凭证。
+func (s *PreviewService) Create(postID uint, input PreviewTokenInput) (*db.PostPreviewToken, error) {
+	ttl := input.TTL
+	if ttl == 0 {
+		ttl = DefaultPreviewTTL
+	}
+	if ttl < 0 || ttl > MaxPreviewTTL {
+		return nil, ErrPreviewTTLInvalid
+	}
+	if err := s.ensurePost(postID); err != nil {
+		return nil, err
+	}
+
+	token, err := generatePreviewToken()
+	if err != nil {
+		return nil, err
+	}
+	record := db.PostPreviewToken{
+		PostID:    postID,
+		TokenHash: db.HashPreviewToken(token),
+		Note:      strings.TrimSpace(input.Note),
+		ExpiresAt: s.now().Add(ttl),
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return nil, err
+	}
+	record.Token = token
+	return &record, nil
+}
+
+// List 返回文章的全部预览链接（含已过期与已撤销），按创建时间倒序。
+func (s *PreviewService) List(postID uint) ([]db.PostPreviewToken, error) {
+	if err := s.ensurePost(postID); err != nil {
+		return nil, err
+	}
+	var tokens []db.PostPreviewToken
+	if err := s.db.Where("post_id = ?", postID).Order("created_at DESC").Order("id DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke 立即撤销预览链接，重复撤销不会改变首次撤销时间。
+func (s *PreviewService) Revoke(postID, tokenID uint) (*db.PostPreviewToken, error) {
+	var record db.PostPreviewToken
+	if err := s.db.Where("id = ? AND post_id = ?", tokenID, postID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPreviewTokenNotFound
+		}
+		return nil, err
+	}
+	if record.RevokedAt != nil {
+		return &record, nil
+	}
+
+	now := s.now()
+	if err := s.db.Model(&record).Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	record.RevokedAt = &now
+	return &record, nil
+}
+
+// Resolve 校验预览凭证并返回对应文章的当前草稿，同时记录最近使用时间。
+func (s *PreviewService) Resolve(token string) (*db.Post, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrPreviewTokenNotFound
+	}
+
+	var record db.PostPreviewToken
+	if err := s.db.Where("token = ?", db.HashPreviewToken(token)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPreviewTokenNotFound
+		}
+		return nil, err
+	}
+	now := s.now()
+	if !record.Active(now) {
+		return nil, ErrPreviewTokenExpired
+	}
+
+	var post db.Post
+	if err := s.db.Preload("Tags").Preload("User").First(&post, record.PostID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPreviewTokenNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.db.Model(&record).UpdateColumns(map[string]interface{}{
+		"last_used_at": now,
+		"use_count":    gorm.Expr("use_count + 1"),
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (s *PreviewService) ensurePost(postID uint) error {
+	var count int64
+	if err := s.db.Model(&db.Post{}).Where("id = ?", postID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrPostNotFound
+	}
+	return nil
+}
+
+func generatePreviewToken() (string, error) {
+	buf := make([]byte, previewTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestPreviewService_TokenLifecycle(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	posts := NewPostService(gdb)
+	previews := NewPreviewService(gdb)
+
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	previews.now = func() time.Time { return now }
+
+	user := db.User{Username: "previewer"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	post, err := posts.Create(PostInput{Content: "# 预览草稿\n尚未发布", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	if _, err := previews.Create(post.ID+100, PreviewTokenInput{}); !errors.Is(err, ErrPostNotFound) {
+		t.Fatalf("expected ErrPostNotFound, got %v", err)
+	}
+	if _, err := previews.Create(post.ID, PreviewTokenInput{TTL: MaxPreviewTTL + time.Hour}); !errors.Is(err, ErrPreviewTTLInvalid) {
+		t.Fatalf("expected ErrPreviewTTLInvalid, got %v", err)
+	}
+
+	shortLived, err := previews.Create(post.ID, PreviewTokenInput{TTL: time.Hour, Note: " 给朋友 "})
+	if err != nil {
+		t.Fatalf("create preview token: %v", err)
+	}
+	if len(shortLived.Token) != previewTokenBytes*2 || shortLived.Note != "给朋友" {
+		t.Fatalf("unexpected token: %+v", shortLived)
+	}
+	if !shortLived.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("expected expiry in one hour, got %v", shortLived.ExpiresAt)
+	}
+	defaultLived, err := previews.Create(post.ID, PreviewTokenInput{})
+	if err != nil {
+		t.Fatalf("create default token: %v", err)
+	}
+	if !defaultLived.ExpiresAt.Equal(now.Add(DefaultPreviewTTL)) {
+		t.Fatalf("expected default expiry, got %v", defaultLived.ExpiresAt)
+	}
+
+	resolved, err := previews.Resolve(shortLived.Token)
+	if err != nil {
+		t.Fatalf("resolve token: %v", err)
+	}
+	if resolved.ID != post.ID || resolved.Title != "预览草稿" {
+		t.Fatalf("unexpected resolved post: %+v", resolved)
+	}
+
+	tokens, err := previews.List(post.ID)
+	if err != nil {
+		t.Fatalf("list tokens: %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("expected 2 tokens, got %d", len(tokens))
+	}
+	for _, token := range tokens {
+		// 数据库只保存摘要，列表中不再返回原始凭证
+		if token.Token != "" || token.PublicPath() != "" {
+			t.Fatalf("expected stored token to hide the raw value, got %+v", token)
+		}
+		if token.ID != shortLived.ID {
+			continue
+		}
+		if token.TokenHash != db.HashPreviewToken(shortLived.Token) || token.TokenHash == shortLived.Token {
+			t.Fatalf("expected token hash to be stored, got %q", token.TokenHash)
+		}
+		if token.LastUsedAt == nil || !token.LastUsedAt.Equal(now) || token.UseCount != 1 {
+			t.Fatalf("expected usage to be tracked, got %+v", token)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := previews.Resolve(shortLived.Token); !errors.Is(err, ErrPreviewTokenExpired) {
+		t.Fatalf("expected expired token, got %v", err)
+	}
+
+	if _, err := previews.Revoke(post.ID+1, defaultLived.ID); !errors.Is(err, ErrPreviewTokenNotFound) {
+		t.Fatalf("expected token to be scoped to post, got %v", err)
+	}
+	revoked, err := previews.Revoke(post.ID, defaultLived.ID)
+	if err != nil {
+		t.Fatalf("revoke token: %v", err)
+	}
+	if revoked.RevokedAt == nil {
+		t.Fatalf("expected revoked_at to be set")
+	}
+	if _, err := previews.Resolve(defaultLived.Token); !errors.Is(err, ErrPreviewTokenExpired) {
+		t.Fatalf("expected revoked token to be rejected, got %v", err)
+	}
+	if _, err := previews.Resolve("missing"); !errors.Is(err, ErrPreviewTokenNotFound) {
+		t.Fatalf("expected ErrPreviewTokenNotFound, got %v", err)
+	}
+}
+
+func TestPreviewService_ResolvesLegacyPlaintextTokensAfterMigration(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	posts := NewPostService(gdb)
+	previews := NewPreviewService(gdb)
+
+	user := db.User{Username: "legacy-previewer"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	post, err := posts.Create(PostInput{Content: "# 旧预览\n正文", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	// 旧版本直接保存明文凭证
+	legacy, err := generatePreviewToken()
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	if err := gdb.Create(&db.PostPreviewToken{PostID: post.ID, TokenHash: legacy, ExpiresAt: time.Now().Add(time.Hour)}).Error; err != nil {
+		t.Fatalf("create legacy token: %v", err)
+	}
+
+	if err := db.Migrate(gdb); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	var stored db.PostPreviewToken
+	if err := gdb.Where("post_id = ?", post.ID).First(&stored).Error; err != nil {
+		t.Fatalf("load token: %v", err)
+	}
+	if stored.TokenHash != db.HashPreviewToken(legacy) {
+		t.Fatalf("expected legacy token to be hashed, got %q", stored.TokenHash)
+	}
+	if resolved, err := previews.Resolve(legacy); err != nil || resolved.ID != post.ID {
+		t.Fatalf("expected shared legacy link to keep working, got %v", err)
+	}
+}
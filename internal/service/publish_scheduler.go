@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultPublishSchedulerInterval 是后台检查到期定时发布的默认间隔。
+const DefaultPublishSchedulerInterval = 30 * time.Second
+
+// PublishScheduler 在后台轮询并发布到期的定时文章。
+// 定时信息持久化在 posts.scheduled_at 中，重启后会立即补发停机期间错过的任务。
+type PublishScheduler struct {
+	posts    *PostService
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewPublishScheduler 创建定时发布调度器，interval 非正数时使用默认间隔。
+func NewPublishScheduler(posts *PostService, interval time.Duration) *PublishScheduler {
+	if interval <= 0 {
+		interval = DefaultPublishSchedulerInterval
+	}
+	return &PublishScheduler{posts: posts, interval: interval, now: time.Now}
+}
+
+// Run 持续执行调度直到 ctx 被取消，启动时先执行一次补发。
+func (s *PublishScheduler) Run(ctx context.Context) {
+	s.RunOnce()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunOnce()
+		}
+	}
+}
+
+// RunOnce 发布当前所有到期的定时文章。
+func (s *PublishScheduler) RunOnce() ScheduledPublishResult {
+	result, err := s.posts.PublishDue(s.now())
+	if err != nil {
+		log.Printf("[scheduler] publish due posts failed: %v", err)
+	}
+	if len(result.Published) > 0 {
+		log.Printf("[scheduler] published scheduled posts: %v", result.Published)
+	}
+	if len(result.Cancelled) > 0 {
+		log.Printf("[scheduler] cancelled schedules for unpublishable posts: %v", result.Cancelled)
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultRelatedPostRefreshInterval 是后台检查相关推荐是否需要重新计算的默认间隔。
+const DefaultRelatedPostRefreshInterval = 30 * time.Second
+
+// RelatedPostRefresher 在后台轮询相关推荐的过期标记并整体重建。
+// 标记保存在数据库中，导入脚本等其他进程中的发布也会在下一轮生效。
+type RelatedPostRefresher struct {
+	related  *RelatedPostService
+	interval time.Duration
+}
+
+// NewRelatedPostRefresher 创建相关推荐刷新任务，interval 非正数时使用默认间隔。
+func NewRelatedPostRefresher(related *RelatedPostService, interval time.Duration) *RelatedPostRefresher {
+	if interval <= 0 {
+		interval = DefaultRelatedPostRefreshInterval
+	}
+	return &RelatedPostRefresher{related: related, interval: interval}
+}
+
+// Run 持续执行刷新直到 ctx 被取消，启动时先执行一次。
+func (r *RelatedPostRefresher) Run(ctx context.Context) {
+	r.RunOnce()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunOnce()
+		}
+	}
+}
+
+// RunOnce 在推荐被标记过期时重新计算，返回是否执行了计算。
+func (r *RelatedPostRefresher) RunOnce() bool {
+	refreshed, err := r.related.RefreshIfStale()
+	if err != nil {
+		log.Printf("[related] refresh related posts failed: %v", err)
+	}
+	return refreshed
+}
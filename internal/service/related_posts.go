@@ -0,0 +1,391 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// RelatedPostLimit 是每篇文章保存的相关推荐数量。
+	RelatedPostLimit = 5
+	// relatedTagWeight 与 relatedTextWeight 是标签相似度与正文相似度在总分中的占比。
+	relatedTagWeight  = 0.4
+	relatedTextWeight = 0.6
+	// relatedMinScore 以下的文章相关性太弱，不作推荐。
+	relatedMinScore = 0.05
+)
+
+// relatedStopWords 是英文中常见但不区分主题的词。
+var relatedStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"are": true, "was": true, "you": true, "not": true, "but": true, "from": true,
+	"have": true, "has": true, "can": true, "will": true, "its": true, "into": true,
+}
+
+// relatedPostsStaleKey 是 system_settings 中记录相关推荐是否需要重新计算的键，值为 "1" 表示待计算。
+const relatedPostsStaleKey = "related_posts_stale"
+
+// RelatedPostService 按共同标签与正文相似度为已发布文章预先计算相关推荐。
+// 发布、重新发布、撤回、删除、恢复与修改摘要只在事务中标记推荐过期，
+// 由 RelatedPostRefresher 在后台统一重建，页面渲染只读取已保存的结果。
+type RelatedPostService struct {
+	db        *gorm.DB
+	posts     *PostService
+	tags      *TagService
+	plainText func(string) string
+
+	mu sync.Mutex
+}
+
+// NewRelatedPostService 创建相关推荐服务，plainText 将 Markdown 转为纯文本，为空时直接使用原文。
+func NewRelatedPostService(gdb *gorm.DB, plainText func(string) string) *RelatedPostService {
+	if plainText == nil {
+		plainText = strings.TrimSpace
+	}
+	return &RelatedPostService{
+		db:        gdb,
+		posts:     NewPostService(gdb),
+		tags:      NewTagService(gdb),
+		plainText: plainText,
+	}
+}
+
+// relatedDocument 是参与计算的一篇已发布文章。
+type relatedDocument struct {
+	PostID       uint
+	Discoverable bool
+	PublishedAt  time.Time
+	Tags         map[uint]bool
+	Vector       map[string]float64
+}
+
+// markRelatedPostsStale 标记相关推荐需要重新计算，应与改变已发布内容的写入处于同一事务。
+func markRelatedPostsStale(tx *gorm.DB) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"value": "1", "updated_at": time.Now()}),
+	}).Create(&db.SystemSetting{Key: relatedPostsStaleKey, Value: "1"}).Error
+}
+
+// RefreshIfStale 在推荐被标记过期时重新计算，返回是否执行了计算。
+// 从未计算过时同样视为过期；计算失败会恢复标记，留待下次重试。
+func (s *RelatedPostService) RefreshIfStale() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stale, err := s.claimStale()
+	if err != nil || !stale {
+		return false, err
+	}
+	if err := s.rebuild(); err != nil {
+		if markErr := markRelatedPostsStale(s.db); markErr != nil {
+			return false, errors.Join(err, markErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// claimStale 清除过期标记并返回清除前是否过期。计算期间再有文章发布会重新写入标记，由下一轮处理。
+func (s *RelatedPostService) claimStale() (bool, error) {
+	result := s.db.Model(&db.SystemSetting{}).
+		Where("key = ? AND value = ?", relatedPostsStaleKey, "1").
+		Update("value", "0")
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+	created := s.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&db.SystemSetting{Key: relatedPostsStaleKey, Value: "0"})
+	if created.Error != nil {
+		return false, created.Error
+	}
+	return created.RowsAffected > 0, nil
+}
+
+// Rebuild 按所有已发布文章的最新快照重新计算并保存推荐。
+func (s *RelatedPostService) Rebuild() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rebuild()
+}
+
+func (s *RelatedPostService) rebuild() error {
+	docs, err := s.loadDocuments()
+	if err != nil {
+		return err
+	}
+	tagWeights, err := s.tagWeights(docs)
+	if err != nil {
+		return err
+	}
+
+	var related []db.RelatedPost
+	for _, source := range docs {
+		type candidate struct {
+			doc   *relatedDocument
+			score float64
+		}
+		var candidates []candidate
+		for _, target := range docs {
+			if target.PostID == source.PostID || !target.Discoverable {
+				continue
+			}
+			score := relatedTagWeight*tagSimilarity(source.Tags, target.Tags, tagWeights) +
+				relatedTextWeight*cosineSimilarity(source.Vector, target.Vector)
+			if score < relatedMinScore {
+				continue
+			}
+			candidates = append(candidates, candidate{doc: target, score: score})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			if candidates[i].score != candidates[j].score {
+				return candidates[i].score > candidates[j].score
+			}
+			if !candidates[i].doc.PublishedAt.Equal(candidates[j].doc.PublishedAt) {
+				return candidates[i].doc.PublishedAt.After(candidates[j].doc.PublishedAt)
+			}
+			return candidates[i].doc.PostID > candidates[j].doc.PostID
+		})
+		if len(candidates) > RelatedPostLimit {
+			candidates = candidates[:RelatedPostLimit]
+		}
+		for position, item := range candidates {
+			related = append(related, db.RelatedPost{
+				PostID:        source.PostID,
+				RelatedPostID: item.doc.PostID,
+				Score:         math.Round(item.score*10000) / 10000,
+				Position:      position,
+			})
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&db.RelatedPost{}).Error; err != nil {
+			return err
+		}
+		if len(related) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&related, 200).Error
+	})
+}
+
+// loadDocuments 读取所有已发布文章的最新快照并计算 TF-IDF 向量。
+// 受密码保护的文章只使用标题与摘要，避免正文内容通过推荐结果间接暴露。
+func (s *RelatedPostService) loadDocuments() ([]*relatedDocument, error) {
+	var rows []struct {
+		PostID        uint
+		PublicationID uint
+		Content       string
+		Summary       string
+		Visibility    string
+		PublishedAt   time.Time
+	}
+	if err := s.db.Table("posts").
+		Select("posts.id AS post_id, post_publications.id AS publication_id, post_publications.content, post_publications.summary, post_publications.visibility, post_publications.published_at").
+		Joins("JOIN post_publications ON post_publications.id = posts.latest_publication_id").
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published").
+		Order("posts.id asc").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var tagRows []struct {
+		PostPublicationID uint
+		TagID             uint
+	}
+	if err := s.db.Table("post_publication_tags").
+		Select("post_publication_tags.post_publication_id, post_publication_tags.tag_id").
+		Joins("JOIN posts ON posts.latest_publication_id = post_publication_tags.post_publication_id").
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published").
+		Scan(&tagRows).Error; err != nil {
+		return nil, err
+	}
+	tagsByPublication := make(map[uint]map[uint]bool)
+	for _, row := range tagRows {
+		if tagsByPublication[row.PostPublicationID] == nil {
+			tagsByPublication[row.PostPublicationID] = make(map[uint]bool)
+		}
+		tagsByPublication[row.PostPublicationID][row.TagID] = true
+	}
+
+	docs := make([]*relatedDocument, 0, len(rows))
+	termCounts := make([]map[string]int, 0, len(rows))
+	documentFrequency := make(map[string]int)
+	for _, row := range rows {
+		visibility := db.NormalizePostVisibility(row.Visibility)
+		text := db.DeriveTitleFromContent(row.Content) + "\n" + row.Summary
+		if visibility != db.PostVisibilityProtected {
+			text = s.plainText(row.Content) + "\n" + row.Summary
+		}
+		counts := relatedTermCounts(text)
+		for term := range counts {
+			documentFrequency[term]++
+		}
+		termCounts = append(termCounts, counts)
+		docs = append(docs, &relatedDocument{
+			PostID:       row.PostID,
+			Discoverable: visibility == db.PostVisibilityPublic || visibility == db.PostVisibilityProtected,
+			PublishedAt:  row.PublishedAt,
+			Tags:         tagsByPublication[row.PublicationID],
+		})
+	}
+
+	total := float64(len(docs))
+	for i, counts := range termCounts {
+		vector := make(map[string]float64, len(counts))
+		var norm float64
+		for term, count := range counts {
+			weight := (1 + math.Log(float64(count))) * math.Log(1+total/float64(documentFrequency[term]))
+			vector[term] = weight
+			norm += weight * weight
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for term := range vector {
+				vector[term] /= norm
+			}
+		}
+		docs[i].Vector = vector
+	}
+	return docs, nil
+}
+
+// tagWeights 按标签在前台文章中的使用次数计算权重，越少见的标签越能说明两篇文章相关。
+func (s *RelatedPostService) tagWeights(docs []*relatedDocument) (map[uint]float64, error) {
+	usages, err := s.tags.PublishedUsage()
+	if err != nil {
+		return nil, err
+	}
+	discoverable := 0
+	for _, doc := range docs {
+		if doc.Discoverable {
+			discoverable++
+		}
+	}
+	weights := make(map[uint]float64, len(usages))
+	for _, usage := range usages {
+		count := usage.Count
+		if count < 1 {
+			count = 1
+		}
+		weights[usage.ID] = math.Log(1 + float64(discoverable)/float64(count))
+	}
+	return weights, nil
+}
+
+// tagSimilarity 是按标签权重计算的余弦相似度。
+func tagSimilarity(a, b map[uint]bool, weights map[uint]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	norm := func(tags map[uint]bool) float64 {
+		var sum float64
+		for id := range tags {
+			sum += weights[id] * weights[id]
+		}
+		return math.Sqrt(sum)
+	}
+	var shared float64
+	for id := range a {
+		if b[id] {
+			shared += weights[id] * weights[id]
+		}
+	}
+	if shared == 0 {
+		return 0
+	}
+	return shared / (norm(a) * norm(b))
+}
+
+// cosineSimilarity 计算两个已归一化向量的点积。
+func cosineSimilarity(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var sum float64
+	for term, weight := range a {
+		sum += weight * b[term]
+	}
+	return sum
+}
+
+// relatedTermCounts 将纯文本切分为词项并计数。
+// 中日韩文字按相邻两字切分，其他文字按字母与数字组成的单词切分。
+func relatedTermCounts(text string) map[string]int {
+	counts := make(map[string]int)
+	var word, cjk []rune
+	flushWord := func() {
+		if len(word) >= 2 {
+			term := string(word)
+			if !relatedStopWords[term] && strings.TrimFunc(term, unicode.IsDigit) != "" {
+				counts[term]++
+			}
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			counts[string(cjk)]++
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			counts[string(cjk[i:i+2])]++
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return counts
+}
+
+// List 返回文章的相关推荐，只包含仍会出现在前台列表中的已发布文章。
+func (s *RelatedPostService) List(postID uint, limit int) ([]db.PostPublication, error) {
+	if limit <= 0 || limit > RelatedPostLimit {
+		limit = RelatedPostLimit
+	}
+	query := s.db.Model(&db.PostPublication{}).
+		Select(publicationWithSlugColumns).
+		Joins("JOIN posts ON posts.latest_publication_id = post_publications.id").
+		Joins("JOIN related_posts ON related_posts.related_post_id = posts.id").
+		Where("related_posts.post_id = ?", postID).
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published")
+	query = s.posts.applyDiscoverablePublicationFilter(query, "post_publications")
+
+	var publications []db.PostPublication
+	if err := query.
+		Preload("Tags").
+		Order("related_posts.position asc").
+		Limit(limit).
+		Find(&publications).Error; err != nil {
+		return nil, err
+	}
+	for i := range publications {
+		publications[i].PopulateDerivedFields()
+	}
+	return publications, nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestRelatedTermCounts_UsesCJKBigrams(t *testing.T) {
+	got := relatedTermCounts("Go 并发模式：the Channel 2024 与 goroutine")
+	want := map[string]int{
+		"go": 1, "并发": 1, "发模": 1, "模式": 1, "channel": 1, "与": 1, "goroutine": 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRelatedPostService_RanksBySharedTagsAndContent(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	related := NewRelatedPostService(gdb, nil)
+	refresher := NewRelatedPostRefresher(related, 0)
+
+	user := db.User{Username: "related-tester"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	common := db.Tag{Name: "Go"}
+	rare := db.Tag{Name: "并发"}
+	if err := gdb.Create(&common).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+	if err := gdb.Create(&rare).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	publish := func(content, visibility string, tagIDs ...uint) *db.Post {
+		t.Helper()
+		input := PostInput{
+			Content:     content,
+			UserID:      user.ID,
+			Visibility:  visibility,
+			TagIDs:      tagIDs,
+			CoverURL:    "https://example.com/cover.jpg",
+			CoverWidth:  1200,
+			CoverHeight: 800,
+		}
+		if visibility == db.PostVisibilityProtected {
+			input.Password = "secret"
+		}
+		post, err := svc.Create(input)
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+			t.Fatalf("publish post: %v", err)
+		}
+		return post
+	}
+	refresh := func(reason string) {
+		t.Helper()
+		if !refresher.RunOnce() {
+			t.Fatalf("expected related posts to be recomputed after %s", reason)
+		}
+	}
+	listIDs := func(postID uint) []uint {
+		t.Helper()
+		publications, err := related.List(postID, 0)
+		if err != nil {
+			t.Fatalf("list related posts: %v", err)
+		}
+		ids := make([]uint, 0, len(publications))
+		for _, publication := range publications {
+			ids = append(ids, publication.PostID)
+		}
+		return ids
+	}
+
+	source := publish("# Go 并发编程\n使用 goroutine 与 channel 编写并发程序，理解调度器。", db.PostVisibilityPublic, common.ID, rare.ID)
+	closest := publish("# 深入理解 channel\n通过 goroutine 与 channel 实现常见的并发模式。", db.PostVisibilityPublic, common.ID, rare.ID)
+	sameTag := publish("# Go 模块管理\n使用 go mod 管理依赖版本。", db.PostVisibilityPublic, common.ID)
+	publish("# 周末烘焙记录\n面包、黄油与面粉的比例。", db.PostVisibilityPublic)
+	unlisted := publish("# 并发编程草记\n使用 goroutine 与 channel 编写并发程序。", db.PostVisibilityUnlisted, rare.ID)
+	// 受密码保护的文章只按标题参与计算，正文与其他文章高度相似也不会被推荐
+	publish("# 私人笔记\n使用 goroutine 与 channel 编写并发程序，理解调度器。", db.PostVisibilityProtected)
+
+	refresh("publishing")
+	if got, want := listIDs(source.ID), []uint{closest.ID, sameTag.ID}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected related posts %v, got %v", want, got)
+	}
+	// 未列出的文章不会被推荐，但自身可以展示相关推荐
+	if got := listIDs(unlisted.ID); len(got) == 0 || got[0] != source.ID {
+		t.Fatalf("expected unlisted post to recommend the source post first, got %v", got)
+	}
+
+	// 没有变化时后台任务不重复计算
+	if refresher.RunOnce() {
+		t.Fatalf("expected no recompute without changes")
+	}
+
+	// 发布新文章后重新计算
+	newer := publish("# goroutine 调度器\n调度器如何安排 goroutine 与 channel 的并发执行。", db.PostVisibilityPublic, common.ID, rare.ID)
+	refresh("publishing a new post")
+	if got := listIDs(source.ID); len(got) == 0 || (got[0] != newer.ID && got[0] != closest.ID) || len(got) != 3 {
+		t.Fatalf("expected newly published post to be ranked, got %v", got)
+	}
+
+	// 撤回后不再出现在推荐中，保存的推荐由后台任务而不是读取时重建
+	if _, err := svc.Withdraw(closest.ID); err != nil {
+		t.Fatalf("withdraw post: %v", err)
+	}
+	var stored int64
+	gdb.Model(&db.RelatedPost{}).Where("related_post_id = ?", closest.ID).Count(&stored)
+	if stored == 0 {
+		t.Fatalf("expected withdrawal to leave recomputing to the background job")
+	}
+	refresh("withdrawing")
+	for _, id := range listIDs(source.ID) {
+		if id == closest.ID {
+			t.Fatalf("expected withdrawn post to be excluded, got %v", listIDs(source.ID))
+		}
+	}
+	gdb.Model(&db.RelatedPost{}).Where("related_post_id = ?", closest.ID).Count(&stored)
+	if stored != 0 {
+		t.Fatalf("expected refresh after withdrawal to drop stored recommendations, got %d", stored)
+	}
+
+	// 修改已发布摘要、删除与从回收站恢复同样会触发重新计算
+	if err := svc.UpdateSummary(sameTag.ID, "go mod 依赖管理"); err != nil {
+		t.Fatalf("update summary: %v", err)
+	}
+	refresh("updating the summary")
+	if err := svc.Delete(sameTag.ID); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+	refresh("deleting")
+	if err := NewTrashService(gdb, t.TempDir(), "/uploads").Restore(TrashKindPost, sameTag.ID); err != nil {
+		t.Fatalf("restore post: %v", err)
+	}
+	refresh("restoring")
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/commitlog/internal/db"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+	"gorm.io/gorm"
+)
+
+// ErrSearchIndexUnavailable 表示当前 SQLite 未启用 FTS5，无法建立全文索引。
+var ErrSearchIndexUnavailable = errors.New("full-text search index is unavailable")
+
+// 全文检索片段中命中部分的起止标记，由前台转换为高亮标签。
+const (
+	SearchHighlightStart = "\x02"
+	SearchHighlightEnd   = "\x03"
+)
+
+// searchTokenSeparator 分隔索引文本中的中日韩二元词。
+// 零宽空格在 unicode61 分词器中属于分隔符，恢复原文时直接去除，不会改变原有空白。
+const searchTokenSeparator = "\u200b"
+
+// createSearchIndexSQL 建立文章全文索引，rowid 即文章 ID。
+// 中日韩文字在写入前切分为相邻两字的二元词，英文等由 unicode61 按单词切分并忽略大小写。
+const createSearchIndexSQL = `CREATE VIRTUAL TABLE IF NOT EXISTS post_search USING fts5(title, summary, body, tokenize = 'unicode61 remove_diacritics 2')`
+
+// searchRankExpr 按 BM25 排序，标题与摘要中的命中权重高于正文，数值越小越相关。
+const searchRankExpr = "bm25(post_search, 10.0, 4.0, 1.0)"
+
+// searchSnippetColumn 从正文中截取带高亮标记的片段。
+const searchSnippetColumn = "snippet(post_search, 2, char(2), char(3), '…', 32) AS search_snippet"
+
+// searchPlainParser 用于从 Markdown 中提取纯文本写入索引。
+var searchPlainParser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// EnsureSearchIndex 创建全文索引表，新建时为所有已发布文章建立索引。
+// SQLite 未启用 FTS5 时返回 false，搜索退回逐行匹配。
+func (s *PostService) EnsureSearchIndex() (bool, error) {
+	if searchIndexReady(s.db) {
+		return true, nil
+	}
+	created, err := createSearchIndex(s.db)
+	if err != nil || !created {
+		return false, err
+	}
+	if _, err := s.rebuildSearchIndex(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReindexSearch 清空并重建全文索引，返回写入索引的文章数量。
+func (s *PostService) ReindexSearch() (int, error) {
+	created, err := createSearchIndex(s.db)
+	if err != nil {
+		return 0, err
+	}
+	if !created {
+		return 0, ErrSearchIndexUnavailable
+	}
+	return s.rebuildSearchIndex()
+}
+
+func (s *PostService) rebuildSearchIndex() (int, error) {
+	indexed := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM post_search").Error; err != nil {
+			return err
+		}
+		rows, err := loadSearchIndexRows(tx, 0)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := insertSearchIndexRow(tx, row); err != nil {
+				return err
+			}
+		}
+		indexed = len(rows)
+		return nil
+	})
+	return indexed, err
+}
+
+// createSearchIndex 创建索引表，SQLite 未编译 FTS5 模块时返回 false。
+func createSearchIndex(tx *gorm.DB) (bool, error) {
+	if err := tx.Exec(createSearchIndexSQL).Error; err != nil {
+		if strings.Contains(err.Error(), "no such module") {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// searchIndexReady 判断索引表存在且可以查询。
+// 从启用 FTS5 的环境恢复的备份在未启用 FTS5 的环境中同样视为不可用。
+func searchIndexReady(tx *gorm.DB) bool {
+	var rowID int64
+	return tx.Raw("SELECT rowid FROM post_search LIMIT 1").Scan(&rowID).Error == nil
+}
+
+// searchIndexRow 是写入索引的文章最新发布快照。
+type searchIndexRow struct {
+	PostID     uint
+	Content    string
+	Summary    string
+	Visibility string
+}
+
+// loadSearchIndexRows 读取已发布文章的最新发布快照，postID 为 0 时读取全部。
+func loadSearchIndexRows(tx *gorm.DB, postID uint) ([]searchIndexRow, error) {
+	query := tx.Table("posts").
+		Select("posts.id AS post_id, post_publications.content, post_publications.summary, post_publications.visibility").
+		Joins("JOIN post_publications ON post_publications.id = posts.latest_publication_id").
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published")
+	if postID != 0 {
+		query = query.Where("posts.id = ?", postID)
+	}
+	var rows []searchIndexRow
+	err := query.Order("posts.id asc").Scan(&rows).Error
+	return rows, err
+}
+
+// insertSearchIndexRow 写入一篇文章，受密码保护的文章只索引标题，避免通过搜索探测正文。
+func insertSearchIndexRow(tx *gorm.DB, row searchIndexRow) error {
+	title := db.DeriveTitleFromContent(row.Content)
+	summary, body := "", ""
+	if db.NormalizePostVisibility(row.Visibility) != db.PostVisibilityProtected {
+		summary = strings.TrimSpace(row.Summary)
+		body = searchPlainText(contentAfterTitle(row.Content))
+	}
+	return tx.Exec(
+		"INSERT INTO post_search (rowid, title, summary, body) VALUES (?, ?, ?, ?)",
+		row.PostID, segmentSearchText(title), segmentSearchText(summary), segmentSearchText(body),
+	).Error
+}
+
+// syncSearchIndex 按文章当前状态更新索引：已发布且未删除的文章写入最新发布快照，其余情况移出索引。
+// 未启用全文索引时直接跳过。
+func syncSearchIndex(tx *gorm.DB, postID uint) error {
+	if !searchIndexReady(tx) {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM post_search WHERE rowid = ?", postID).Error; err != nil {
+		return err
+	}
+	rows, err := loadSearchIndexRows(tx, postID)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := insertSearchIndexRow(tx, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contentAfterTitle 去掉用作标题的首行，标题单独写入索引。
+func contentAfterTitle(content string) string {
+	if idx := strings.IndexByte(content, '\n'); idx >= 0 {
+		return content[idx+1:]
+	}
+	return ""
+}
+
+// searchPlainText 提取 Markdown 中的文字，保留链接文字、图片说明与代码，忽略链接地址与 HTML。
+func searchPlainText(content string) string {
+	source := []byte(StripDiagramFences(content))
+	document := searchPlainParser.Parse(text.NewReader(source))
+
+	var buf strings.Builder
+	_ = ast.Walk(document, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if node.Type() == ast.TypeBlock {
+				buf.WriteByte(' ')
+			}
+			return ast.WalkContinue, nil
+		}
+		switch n := node.(type) {
+		case *ast.Text:
+			buf.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(n.Value)
+		case *ast.AutoLink:
+			buf.Write(n.Label(source))
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				buf.Write(segment.Value(source))
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// isSearchCJK 判断字符是否按二元词切分。
+func isSearchCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// segmentSearchText 将连续的中日韩文字改写为相邻两字的二元词，并在末尾补上最后一个字，
+// 使单字查询也能命中位于词尾的字。例如“并发模式”写为“并发 发模 模式 式”，分隔符为零宽空格。
+func segmentSearchText(input string) string {
+	var out strings.Builder
+	var run []rune
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		out.WriteString(searchTokenSeparator)
+		if len(run) == 1 {
+			out.WriteRune(run[0])
+		} else {
+			for i := 0; i+1 < len(run); i++ {
+				out.WriteString(string(run[i : i+2]))
+				out.WriteString(searchTokenSeparator)
+			}
+			out.WriteRune(run[len(run)-1])
+		}
+		out.WriteString(searchTokenSeparator)
+		run = run[:0]
+	}
+	for _, r := range input {
+		if isSearchCJK(r) {
+			run = append(run, r)
+			continue
+		}
+		flush()
+		out.WriteRune(r)
+	}
+	flush()
+	return out.String()
+}
+
+// restoreSearchText 将索引文本（含 snippet 截取的片段）还原为原文，保留高亮标记。
+// 同一段文字中，紧跟在二元词之后的词与前一个词重叠一个字，去掉重叠部分即可还原；
+// 重叠的字已在前一个词中输出，因此位于两词之间的起始标记需要前移一个字。
+func restoreSearchText(input string) string {
+	if !strings.Contains(input, searchTokenSeparator) {
+		return input
+	}
+	separator := []rune(searchTokenSeparator)[0]
+	start := []rune(SearchHighlightStart)[0]
+	end := []rune(SearchHighlightEnd)[0]
+
+	runes := []rune(input)
+	out := make([]rune, 0, len(runes))
+	// previous 是上一个中日韩词，previousEnd 是它在 out 中的结束位置
+	var previous []rune
+	previousEnd := 0
+	afterSeparator := false
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == separator:
+			afterSeparator = true
+			i++
+		case r == start || r == end:
+			out = append(out, r)
+			i++
+		case isSearchCJK(r):
+			from := i
+			for i < len(runes) && isSearchCJK(runes[i]) {
+				i++
+			}
+			token := runes[from:i]
+			if afterSeparator && len(previous) == 2 && token[0] == previous[1] {
+				markers := string(out[previousEnd:])
+				out = out[:previousEnd]
+				switch {
+				case strings.Contains(markers, SearchHighlightEnd+SearchHighlightStart):
+					// 相邻的两段高亮合并为一段
+				case strings.Contains(markers, SearchHighlightStart):
+					last := out[len(out)-1]
+					out = append(out[:len(out)-1], start, last)
+				default:
+					out = append(out, []rune(markers)...)
+				}
+				out = append(out, token[1:]...)
+			} else {
+				out = append(out, token...)
+			}
+			previous = token
+			previousEnd = len(out)
+			afterSeparator = false
+		default:
+			out = append(out, r)
+			previous = nil
+			afterSeparator = false
+			i++
+		}
+	}
+	return string(out)
+}
+
+// buildSearchMatchQuery 将搜索词与短语转换为 FTS5 查询，各条件之间为“且”的关系。
+// 中日韩文字按二元词组成短语以匹配连续的原文，单字与英文单词按前缀匹配。
+// 没有可检索的文字时返回空字符串。
+func buildSearchMatchQuery(search string, phrases []string) string {
+	var parts []string
+	for _, token := range splitSearchTokens(search) {
+		for _, run := range searchTextRuns(token) {
+			switch {
+			case !run.cjk:
+				parts = append(parts, `"`+strings.ToLower(string(run.text))+`"*`)
+			case len(run.text) == 1:
+				parts = append(parts, `"`+string(run.text)+`"*`)
+			default:
+				parts = append(parts, `"`+strings.Join(searchBigrams(run.text), " ")+`"`)
+			}
+		}
+	}
+	for _, phrase := range phrases {
+		if part := buildSearchPhraseMatch(phrase); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// buildSearchPhraseMatch 将短语转换为与索引切分方式一致的 FTS5 短语查询。
+// 索引中每段中日韩文字的二元词之后还有末字，段落位于短语中间时需要一并匹配；
+// 短语以单个中日韩字结尾时，原文中该字可能与后文组成二元词，因此按前缀匹配。
+func buildSearchPhraseMatch(phrase string) string {
+	runs := searchTextRuns(phrase)
+	if len(runs) == 0 {
+		return ""
+	}
+	tokens := make([]string, 0, len(runs))
+	prefix := false
+	for i, run := range runs {
+		last := i == len(runs)-1
+		switch {
+		case !run.cjk:
+			tokens = append(tokens, strings.ToLower(string(run.text)))
+		case len(run.text) == 1:
+			tokens = append(tokens, string(run.text))
+			prefix = last
+		default:
+			tokens = append(tokens, searchBigrams(run.text)...)
+			if !last {
+				tokens = append(tokens, string(run.text[len(run.text)-1]))
+			}
+		}
+	}
+	query := `"` + strings.Join(tokens, " ") + `"`
+	if prefix {
+		query += "*"
+	}
+	return query
+}
+
+// searchTextRun 是一段连续的中日韩文字或一个单词。
+type searchTextRun struct {
+	text []rune
+	cjk  bool
+}
+
+// searchTextRuns 将文本切分为中日韩文字段与单词，忽略空白与标点。
+func searchTextRuns(input string) []searchTextRun {
+	var runs []searchTextRun
+	var current []rune
+	cjk := false
+	flush := func() {
+		if len(current) > 0 {
+			runs = append(runs, searchTextRun{text: current, cjk: cjk})
+			current = nil
+		}
+	}
+	for _, r := range input {
+		switch {
+		case isSearchCJK(r):
+			if !cjk {
+				flush()
+			}
+			cjk = true
+			current = append(current, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if cjk {
+				flush()
+			}
+			cjk = false
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return runs
+}
+
+func searchBigrams(run []rune) []string {
+	bigrams := make([]string, 0, len(run)-1)
+	for i := 0; i+1 < len(run); i++ {
+		bigrams = append(bigrams, string(run[i:i+2]))
+	}
+	return bigrams
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestSegmentSearchText_RestoresOriginal(t *testing.T) {
+	inputs := []string{
+		"Go并发模式，与 channel 的用法。",
+		"单 字",
+		"混合 text 与中文ひらがな한국어",
+	}
+	for _, input := range inputs {
+		segmented := segmentSearchText(input)
+		if restored := restoreSearchText(segmented); restored != input {
+			t.Fatalf("expected %q to round trip, got %q", input, restored)
+		}
+	}
+
+	segmented := segmentSearchText("并发模式")
+	if got := strings.Fields(strings.ReplaceAll(segmented, searchTokenSeparator, " ")); strings.Join(got, " ") != "并发 发模 模式 式" {
+		t.Fatalf("unexpected bigrams %q", got)
+	}
+
+	// snippet() 在命中的二元词两侧插入标记，还原时保留标记并去掉重叠的字
+	marked := strings.Replace(segmented, "发模"+searchTokenSeparator+"模式", SearchHighlightStart+"发模"+searchTokenSeparator+"模式"+SearchHighlightEnd, 1)
+	if restored := restoreSearchText(marked); restored != "并"+SearchHighlightStart+"发模式"+SearchHighlightEnd {
+		t.Fatalf("unexpected restored snippet %q", restored)
+	}
+	marked = strings.Replace(segmented, "并发"+searchTokenSeparator+"发模", SearchHighlightStart+"并发"+SearchHighlightEnd+searchTokenSeparator+SearchHighlightStart+"发模"+SearchHighlightEnd, 1)
+	if restored := restoreSearchText(marked); restored != SearchHighlightStart+"并发模"+SearchHighlightEnd+"式" {
+		t.Fatalf("expected adjacent highlights to merge, got %q", restored)
+	}
+}
+
+func TestBuildSearchMatchQuery(t *testing.T) {
+	cases := map[string]string{
+		"并发模式":       `"并发 发模 模式"`,
+		"Go 并":       `"go"* AND "并"*`,
+		"Go语言 C++":   `"go"* AND "语言" AND "c"*`,
+		"  \"*) ":    "",
+		"channel-用法": `"channel"* AND "用法"`,
+	}
+	for input, want := range cases {
+		if got := buildSearchMatchQuery(input, nil); got != want {
+			t.Fatalf("buildSearchMatchQuery(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	phrases := map[string]string{
+		"并发模式":          `"并发 发模 模式"`,
+		"Go 并发 channel": `"go 并发 发 channel"`,
+		"channel 并":     `"channel 并"*`,
+		"……":            "",
+	}
+	for phrase, want := range phrases {
+		if got := buildSearchMatchQuery("", []string{phrase}); got != want {
+			t.Fatalf("phrase %q = %q, want %q", phrase, got, want)
+		}
+	}
+	if got := buildSearchMatchQuery("go", []string{"error handling"}); got != `"go"* AND "error handling"` {
+		t.Fatalf("unexpected combined query %q", got)
+	}
+}
+
+func TestSearchPlainText_DropsMarkup(t *testing.T) {
+	content := "## 小节\n见 [官方文档](https://go.dev/doc) 与 **重点**。\n\n![架构图](/uploads/a.png)\n\n<div>忽略</div>\n\n```go\nfmt.Println(\"hi\")\n```"
+	got := searchPlainText(content)
+	want := `小节 见 官方文档 与 重点。 架构图 fmt.Println("hi")`
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPostService_ListPublishedUsesFullTextIndex(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	if ready, err := svc.EnsureSearchIndex(); err != nil {
+		t.Fatalf("ensure search index: %v", err)
+	} else if !ready {
+		t.Skip("sqlite is built without FTS5, run with -tags sqlite_fts5")
+	}
+
+	user := db.User{Username: "search-tester"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	publish := func(content, visibility string) *db.Post {
+		t.Helper()
+		input := PostInput{
+			Content:     content,
+			UserID:      user.ID,
+			Visibility:  visibility,
+			CoverURL:    "https://example.com/cover.jpg",
+			CoverWidth:  1200,
+			CoverHeight: 800,
+		}
+		if visibility == db.PostVisibilityProtected {
+			input.Password = "secret"
+		}
+		post, err := svc.Create(input)
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+			t.Fatalf("publish post: %v", err)
+		}
+		return post
+	}
+	search := func(keyword string) []db.PostPublication {
+		t.Helper()
+		result, err := svc.ListPublished(PostFilter{Search: keyword, Page: 1, PerPage: 10})
+		if err != nil {
+			t.Fatalf("search %q: %v", keyword, err)
+		}
+		return result.Publications
+	}
+
+	inBody := publish("# 周报\n本周整理了 Go 并发模式的笔记，重点是 channel。", db.PostVisibilityPublic)
+	inTitle := publish("# Go 并发模式\n介绍常见的并发模式。", db.PostVisibilityPublic)
+	protected := publish("# 私密笔记\n并发模式的草稿", db.PostVisibilityProtected)
+	publish("# 模式识别\n与并发无关。", db.PostVisibilityPublic)
+
+	results := search("并发模式")
+	if len(results) != 2 || results[0].PostID != inTitle.ID || results[1].PostID != inBody.ID {
+		t.Fatalf("expected title match ranked first and protected body hidden, got %+v", results)
+	}
+	if snippet := results[1].SearchSnippet; !strings.Contains(snippet, SearchHighlightStart+"并发模式"+SearchHighlightEnd) || strings.Contains(snippet, searchTokenSeparator) {
+		t.Fatalf("expected restored snippet with highlight, got %q", snippet)
+	}
+	if results := search("私密"); len(results) != 1 || results[0].PostID != protected.ID {
+		t.Fatalf("expected protected post to match by title, got %+v", results)
+	}
+	if results := search("式"); len(results) != 3 {
+		t.Fatalf("expected single character at the end of a word to match, got %d results", len(results))
+	}
+
+	// 下线与移入回收站后不再出现在索引中
+	if _, err := svc.Withdraw(inTitle.ID); err != nil {
+		t.Fatalf("withdraw post: %v", err)
+	}
+	if err := svc.Delete(inBody.ID); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+	var indexed int64
+	if err := gdb.Raw("SELECT COUNT(*) FROM post_search").Scan(&indexed).Error; err != nil || indexed != 2 {
+		t.Fatalf("expected 2 indexed posts, got %d (%v)", indexed, err)
+	}
+
+	if count, err := svc.ReindexSearch(); err != nil || count != 2 {
+		t.Fatalf("expected reindex to cover 2 published posts, got %d (%v)", count, err)
+	}
+}
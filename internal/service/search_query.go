@@ -0,0 +1,228 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 搜索语法中各类条件的种类。
+const (
+	SearchClauseTerm    = "term"
+	SearchClausePhrase  = "phrase"
+	SearchClauseExclude = "exclude"
+	SearchClauseTag     = "tag"
+	SearchClauseBefore  = "before"
+	SearchClauseAfter   = "after"
+	SearchClauseYear    = "year"
+	SearchClauseStatus  = "status"
+)
+
+// searchQueryDateLayout 是 before: 与 after: 接受的日期格式。
+const searchQueryDateLayout = "2006-01-02"
+
+// searchQueryStatuses 是 status: 可筛选的文章状态。
+var searchQueryStatuses = map[string]bool{
+	"draft":     true,
+	"published": true,
+	"scheduled": true,
+	"withdrawn": true,
+}
+
+// SearchClause 是搜索语句中的一个条件。
+type SearchClause struct {
+	Kind string
+	// Value 是去掉前缀与引号后的值，日期为 YYYY-MM-DD 形式。
+	Value string
+	// Raw 是条件在原搜索语句中的写法，用于移除单个条件后重建搜索语句。
+	Raw string
+}
+
+// SearchQuery 是解析后的搜索语句。
+type SearchQuery struct {
+	Clauses []SearchClause
+}
+
+// ParseSearchQuery 解析搜索语句，支持以下写法，其余内容按关键词处理：
+//
+//	tag:go             包含指定标签，多个标签需同时满足
+//	-word / -"短语"     排除包含该内容的文章
+//	"exact phrase"     按完整短语匹配
+//	before:2024-01-01  早于该日期（不含当天）
+//	after:2024-01-01   晚于该日期（不含当天）
+//	year:2023          指定年份
+//	status:draft       指定文章状态，仅在 allowStatus 为 true（后台）时生效
+//
+// 无法识别的前缀或非法的取值同样视为关键词，不会被静默丢弃。
+func ParseSearchQuery(raw string, allowStatus bool) SearchQuery {
+	var query SearchQuery
+	for _, token := range tokenizeSearchQuery(raw) {
+		if clause, ok := parseSearchClause(token, allowStatus); ok {
+			query.Clauses = append(query.Clauses, clause)
+		}
+	}
+	return query
+}
+
+// tokenizeSearchQuery 按空白切分搜索语句，双引号内的空白不切分，缺少右引号时延续到末尾。
+func tokenizeSearchQuery(raw string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+func parseSearchClause(token string, allowStatus bool) (SearchClause, bool) {
+	body := token
+	negated := len(body) > 1 && strings.HasPrefix(body, "-")
+	if negated {
+		body = body[1:]
+	}
+
+	if strings.HasPrefix(body, `"`) {
+		phrase := strings.Join(strings.Fields(strings.ReplaceAll(body, `"`, " ")), " ")
+		if phrase == "" {
+			return SearchClause{}, false
+		}
+		if negated {
+			return SearchClause{Kind: SearchClauseExclude, Value: phrase, Raw: token}, true
+		}
+		return SearchClause{Kind: SearchClausePhrase, Value: phrase, Raw: token}, true
+	}
+
+	if !negated {
+		if key, value, ok := strings.Cut(body, ":"); ok {
+			value = strings.TrimSpace(strings.Trim(value, `"`))
+			if clause, ok := parseSearchFilter(strings.ToLower(key), value, allowStatus); ok {
+				clause.Raw = token
+				return clause, true
+			}
+		}
+	}
+
+	term := strings.ReplaceAll(body, `"`, "")
+	if term == "" {
+		return SearchClause{}, false
+	}
+	if negated {
+		return SearchClause{Kind: SearchClauseExclude, Value: term, Raw: token}, true
+	}
+	return SearchClause{Kind: SearchClauseTerm, Value: term, Raw: token}, true
+}
+
+// parseSearchFilter 解析 key:value 形式的筛选条件，返回 false 表示应按关键词处理。
+func parseSearchFilter(key, value string, allowStatus bool) (SearchClause, bool) {
+	if value == "" {
+		return SearchClause{}, false
+	}
+	switch key {
+	case "tag":
+		return SearchClause{Kind: SearchClauseTag, Value: value}, true
+	case "before", "after":
+		date, err := time.Parse(searchQueryDateLayout, value)
+		if err != nil {
+			return SearchClause{}, false
+		}
+		return SearchClause{Kind: key, Value: date.Format(searchQueryDateLayout)}, true
+	case "year":
+		year, err := strconv.Atoi(value)
+		if err != nil || year < 1000 || year > 9999 {
+			return SearchClause{}, false
+		}
+		return SearchClause{Kind: SearchClauseYear, Value: strconv.Itoa(year)}, true
+	case "status":
+		status := strings.ToLower(value)
+		if !allowStatus || !searchQueryStatuses[status] {
+			return SearchClause{}, false
+		}
+		return SearchClause{Kind: SearchClauseStatus, Value: status}, true
+	}
+	return SearchClause{}, false
+}
+
+// Text 返回关键词与短语，用于高亮与截取摘要。
+func (q SearchQuery) Text() string {
+	parts := make([]string, 0, len(q.Clauses))
+	for _, clause := range q.Clauses {
+		if clause.Kind == SearchClauseTerm || clause.Kind == SearchClausePhrase {
+			parts = append(parts, clause.Value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Without 返回移除第 index 个条件后的搜索语句。
+func (q SearchQuery) Without(index int) string {
+	parts := make([]string, 0, len(q.Clauses))
+	for i, clause := range q.Clauses {
+		if i != index {
+			parts = append(parts, clause.Raw)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Apply 将搜索语句编译到筛选条件中：关键词写回 Search，日期与已有的日期范围取交集，
+// status: 覆盖原有的状态筛选。
+func (q SearchQuery) Apply(filter PostFilter) PostFilter {
+	terms := make([]string, 0, len(q.Clauses))
+	for _, clause := range q.Clauses {
+		switch clause.Kind {
+		case SearchClauseTerm:
+			terms = append(terms, clause.Value)
+		case SearchClausePhrase:
+			filter.Phrases = append(filter.Phrases, clause.Value)
+		case SearchClauseExclude:
+			filter.ExcludeTerms = append(filter.ExcludeTerms, clause.Value)
+		case SearchClauseTag:
+			filter.RequiredTags = append(filter.RequiredTags, clause.Value)
+		case SearchClauseBefore:
+			date, _ := time.Parse(searchQueryDateLayout, clause.Value)
+			filter.EndDate = earlierTime(filter.EndDate, date.Add(-time.Nanosecond))
+		case SearchClauseAfter:
+			date, _ := time.Parse(searchQueryDateLayout, clause.Value)
+			filter.StartDate = laterTime(filter.StartDate, date.AddDate(0, 0, 1))
+		case SearchClauseYear:
+			year, _ := strconv.Atoi(clause.Value)
+			start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			filter.StartDate = laterTime(filter.StartDate, start)
+			filter.EndDate = earlierTime(filter.EndDate, start.AddDate(1, 0, 0).Add(-time.Nanosecond))
+		case SearchClauseStatus:
+			filter.Status = clause.Value
+		}
+	}
+	filter.Search = strings.Join(terms, " ")
+	return filter
+}
+
+func earlierTime(current *time.Time, candidate time.Time) *time.Time {
+	if current != nil && current.Before(candidate) {
+		return current
+	}
+	return &candidate
+}
+
+func laterTime(current *time.Time, candidate time.Time) *time.Time {
+	if current != nil && current.After(candidate) {
+		return current
+	}
+	return &candidate
+}
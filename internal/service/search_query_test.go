@@ -0,0 +1,153 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestParseSearchQuery_RecognizesSyntax(t *testing.T) {
+	query := ParseSearchQuery(`并发 tag:Go -java "error handling" -"旧 写法" before:2024-01-01 after:2023-06-30 year:2023 status:draft TAG:"machine learning" foo:bar`, false)
+
+	want := []SearchClause{
+		{Kind: SearchClauseTerm, Value: "并发", Raw: "并发"},
+		{Kind: SearchClauseTag, Value: "Go", Raw: "tag:Go"},
+		{Kind: SearchClauseExclude, Value: "java", Raw: "-java"},
+		{Kind: SearchClausePhrase, Value: "error handling", Raw: `"error handling"`},
+		{Kind: SearchClauseExclude, Value: "旧 写法", Raw: `-"旧 写法"`},
+		{Kind: SearchClauseBefore, Value: "2024-01-01", Raw: "before:2024-01-01"},
+		{Kind: SearchClauseAfter, Value: "2023-06-30", Raw: "after:2023-06-30"},
+		{Kind: SearchClauseYear, Value: "2023", Raw: "year:2023"},
+		// 前台不允许按状态筛选，按关键词处理
+		{Kind: SearchClauseTerm, Value: "status:draft", Raw: "status:draft"},
+		{Kind: SearchClauseTag, Value: "machine learning", Raw: `TAG:"machine learning"`},
+		{Kind: SearchClauseTerm, Value: "foo:bar", Raw: "foo:bar"},
+	}
+	if !reflect.DeepEqual(query.Clauses, want) {
+		t.Fatalf("unexpected clauses:\n got %+v\nwant %+v", query.Clauses, want)
+	}
+
+	if got := query.Text(); got != "并发 error handling status:draft foo:bar" {
+		t.Fatalf("unexpected text %q", got)
+	}
+	if got := query.Without(1); got != `并发 -java "error handling" -"旧 写法" before:2024-01-01 after:2023-06-30 year:2023 status:draft TAG:"machine learning" foo:bar` {
+		t.Fatalf("unexpected query without tag %q", got)
+	}
+}
+
+func TestParseSearchQuery_InvalidFiltersFallBackToTerms(t *testing.T) {
+	query := ParseSearchQuery(`before:2024-13-01 year:23 tag: status:unknown - ""`, true)
+	var kinds []string
+	for _, clause := range query.Clauses {
+		kinds = append(kinds, clause.Kind)
+	}
+	want := []string{SearchClauseTerm, SearchClauseTerm, SearchClauseTerm, SearchClauseTerm, SearchClauseTerm}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("expected invalid filters to become terms, got %+v", query.Clauses)
+	}
+
+	admin := ParseSearchQuery("status:Draft", true)
+	if len(admin.Clauses) != 1 || admin.Clauses[0].Kind != SearchClauseStatus || admin.Clauses[0].Value != "draft" {
+		t.Fatalf("expected admin status filter, got %+v", admin.Clauses)
+	}
+}
+
+func TestSearchQuery_ApplyIntersectsDates(t *testing.T) {
+	start := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	filter := ParseSearchQuery("year:2023 before:2023-09-01 tag:go status:draft 关键词", true).Apply(PostFilter{
+		Search:    "ignored",
+		StartDate: &start,
+		Status:    "published",
+	})
+
+	if filter.Search != "关键词" {
+		t.Fatalf("expected remaining terms as search, got %q", filter.Search)
+	}
+	if filter.Status != "draft" {
+		t.Fatalf("expected status from query, got %q", filter.Status)
+	}
+	if !reflect.DeepEqual(filter.RequiredTags, []string{"go"}) {
+		t.Fatalf("unexpected required tags %v", filter.RequiredTags)
+	}
+	if filter.StartDate == nil || !filter.StartDate.Equal(start) {
+		t.Fatalf("expected later start date to be kept, got %v", filter.StartDate)
+	}
+	wantEnd := time.Date(2023, time.September, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+	if filter.EndDate == nil || !filter.EndDate.Equal(wantEnd) {
+		t.Fatalf("expected end date %v, got %v", wantEnd, filter.EndDate)
+	}
+}
+
+func TestPostService_ListPublishedAppliesSearchSyntax(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	if _, err := svc.EnsureSearchIndex(); err != nil {
+		t.Fatalf("ensure search index: %v", err)
+	}
+
+	user := db.User{Username: "search-syntax"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	goTag := db.Tag{Name: "Go"}
+	if err := gdb.Create(&goTag).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	publish := func(content string, publishedAt time.Time, tagIDs ...uint) {
+		t.Helper()
+		post, err := svc.Create(PostInput{
+			Content:     content,
+			UserID:      user.ID,
+			TagIDs:      tagIDs,
+			CoverURL:    "https://example.com/cover.jpg",
+			CoverWidth:  1200,
+			CoverHeight: 800,
+		})
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		if _, err := svc.Publish(post.ID, user.ID, &publishedAt); err != nil {
+			t.Fatalf("publish post: %v", err)
+		}
+	}
+	publish("# 并发模式\n介绍 error handling 与 channel。", time.Date(2023, time.May, 1, 8, 0, 0, 0, time.UTC), goTag.ID)
+	publish("# 并发陷阱\n只讨论 error 与 handling 的区别，附带 java 对比。", time.Date(2023, time.November, 1, 8, 0, 0, 0, time.UTC), goTag.ID)
+	publish("# 并发入门\n介绍 error handling。", time.Date(2024, time.February, 1, 8, 0, 0, 0, time.UTC))
+
+	titles := func(search string) []string {
+		t.Helper()
+		list, err := svc.ListPublished(PostFilter{Search: search, Page: 1, PerPage: 10})
+		if err != nil {
+			t.Fatalf("list published %q: %v", search, err)
+		}
+		result := make([]string, 0, len(list.Publications))
+		for _, publication := range list.Publications {
+			result = append(result, publication.Title)
+		}
+		// 全文索引按相关度排序，这里只比较命中的文章
+		sort.Strings(result)
+		return result
+	}
+
+	cases := []struct {
+		search string
+		want   []string
+	}{
+		{`"error handling"`, []string{"并发入门", "并发模式"}},
+		{`并发 tag:go`, []string{"并发模式", "并发陷阱"}},
+		{`并发 -java`, []string{"并发入门", "并发模式"}},
+		{`year:2023 -"error handling"`, []string{"并发陷阱"}},
+		{`before:2023-11-01`, []string{"并发模式"}},
+		{`after:2023-11-01`, []string{"并发入门"}},
+		{`tag:go tag:rust`, []string{}},
+	}
+	for _, tc := range cases {
+		if got := titles(tc.search); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("search %q: expected %v, got %v", tc.search, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSeriesNotFound      = errors.New("series not found")
+	ErrSeriesTitleRequired = errors.New("series title is required")
+	ErrSeriesSlugTaken     = errors.New("series slug is already in use")
+	ErrSeriesPostConflict  = errors.New("post already belongs to another series")
+	ErrSeriesPostDuplicate = errors.New("post appears more than once in series")
+)
+
+// SeriesService wraps series related operations.
+type SeriesService struct {
+	db *gorm.DB
+}
+
+// SeriesInput 表示创建或更新系列时接受的字段，PostIDs 的顺序即系列内的阅读顺序。
+type SeriesInput struct {
+	Title       string
+	Slug        string
+	Description string
+	CoverURL    string
+	CoverWidth  int
+	CoverHeight int
+	PostIDs     []uint
+}
+
+// PublishedSeries 描述前台展示的系列及其已发布成员的最新快照。
+type PublishedSeries struct {
+	Series       db.Series
+	Publications []db.PostPublication
+}
+
+// SeriesNavigation 描述文章在系列中的位置以及相邻的已发布文章。
+type SeriesNavigation struct {
+	Series   db.Series
+	Position int
+	Total    int
+	Previous *db.PostPublication
+	Next     *db.PostPublication
+}
+
+// NewSeriesService creates a SeriesService instance.
+func NewSeriesService(gdb *gorm.DB) *SeriesService {
+	return &SeriesService{db: gdb}
+}
+
+// List 返回全部系列及其成员数量。
+func (s *SeriesService) List() ([]db.Series, error) {
+	var series []db.Series
+	if err := s.db.Model(&db.Series{}).
+		Select("series.*, COUNT(series_posts.id) AS post_count").
+		Joins("LEFT JOIN series_posts ON series_posts.series_id = series.id").
+		Group("series.id").
+		Order("series.created_at desc").
+		Order("series.id desc").
+		Find(&series).Error; err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// Get 返回系列详情，成员按顺序排列并带上文章标题。
+func (s *SeriesService) Get(id uint) (*db.Series, error) {
+	var series db.Series
+	if err := s.db.Preload("Members", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("position asc, id asc")
+	}).First(&series, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSeriesNotFound
+		}
+		return nil, err
+	}
+
+	if len(series.Members) > 0 {
+		postIDs := make([]uint, 0, len(series.Members))
+		for _, member := range series.Members {
+			postIDs = append(postIDs, member.PostID)
+		}
+		// 回收站中的成员保留系列位置，恢复后自动回到原处
+		var posts []db.Post
+		if err := s.db.Unscoped().Select("id", "content").Where("id IN ?", postIDs).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		titles := make(map[uint]string, len(posts))
+		for _, post := range posts {
+			titles[post.ID] = post.Title
+		}
+		for i := range series.Members {
+			series.Members[i].Title = titles[series.Members[i].PostID]
+		}
+	}
+	series.PostCount = int64(len(series.Members))
+
+	return &series, nil
+}
+
+// Create 创建系列并写入有序成员。
+func (s *SeriesService) Create(input SeriesInput) (*db.Series, error) {
+	title := strings.TrimSpace(input.Title)
+	if title == "" {
+		return nil, ErrSeriesTitleRequired
+	}
+	slug, err := normalizeSlugInput(input.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	series := db.Series{
+		Title:       title,
+		Description: strings.TrimSpace(input.Description),
+		CoverURL:    strings.TrimSpace(input.CoverURL),
+		CoverWidth:  input.CoverWidth,
+		CoverHeight: input.CoverHeight,
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		resolved, err := resolveSeriesSlug(tx, slug, title, 0)
+		if err != nil {
+			return err
+		}
+		series.Slug = resolved
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		return replaceSeriesMembers(tx, series.ID, input.PostIDs)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.Get(series.ID)
+}
+
+// Update 更新系列信息并按给定顺序重建成员。
+func (s *SeriesService) Update(id uint, input SeriesInput) (*db.Series, error) {
+	title := strings.TrimSpace(input.Title)
+	if title == "" {
+		return nil, ErrSeriesTitleRequired
+	}
+	slug, err := normalizeSlugInput(input.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	var series db.Series
+	if err := s.db.First(&series, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSeriesNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if slug == "" {
+			slug = series.Slug
+		}
+		resolved, err := resolveSeriesSlug(tx, slug, title, series.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&series).Updates(map[string]interface{}{
+			"title":        title,
+			"slug":         resolved,
+			"description":  strings.TrimSpace(input.Description),
+			"cover_url":    strings.TrimSpace(input.CoverURL),
+			"cover_width":  input.CoverWidth,
+			"cover_height": input.CoverHeight,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceSeriesMembers(tx, series.ID, input.PostIDs)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.Get(series.ID)
+}
+
+// Delete 删除系列及其成员关系，文章本身不受影响。
+func (s *SeriesService) Delete(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Delete(&db.Series{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSeriesNotFound
+		}
+		return tx.Where("series_id = ?", id).Delete(&db.SeriesPost{}).Error
+	})
+}
+
+// GetPublishedBySlug 返回前台展示用的系列，只包含已发布的成员。
+func (s *SeriesService) GetPublishedBySlug(slug string) (*PublishedSeries, error) {
+	var series db.Series
+	if err := s.db.Where("slug = ?", strings.TrimSpace(slug)).First(&series).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSeriesNotFound
+		}
+		return nil, err
+	}
+
+	publications, err := s.publishedMembers(series.ID)
+	if err != nil {
+		return nil, err
+	}
+	series.PostCount = int64(len(publications))
+
+	return &PublishedSeries{Series: series, Publications: publications}, nil
+}
+
+// NavigationForPost 返回文章在所属系列中的位置，未加入系列或文章未发布时返回 nil。
+func (s *SeriesService) NavigationForPost(postID uint) (*SeriesNavigation, error) {
+	var member db.SeriesPost
+	if err := s.db.Where("post_id = ?", postID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var series db.Series
+	if err := s.db.First(&series, member.SeriesID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	publications, err := s.publishedMembers(series.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range publications {
+		if publications[idx].PostID != postID {
+			continue
+		}
+		nav := &SeriesNavigation{
+			Series:   series,
+			Position: idx + 1,
+			Total:    len(publications),
+		}
+		if idx > 0 {
+			nav.Previous = &publications[idx-1]
+		}
+		if idx+1 < len(publications) {
+			nav.Next = &publications[idx+1]
+		}
+		return nav, nil
+	}
+
+	return nil, nil
+}
+
+// ListPublished 返回前台可见的系列，PostCount 只统计可出现在列表中的已发布成员，没有此类成员的系列被跳过。
+func (s *SeriesService) ListPublished() ([]db.Series, error) {
+	members := s.db.Table("series_posts").
+		Select("series_posts.series_id, COUNT(*) AS post_count").
+		Joins("JOIN posts ON posts.id = series_posts.post_id").
+		Joins("JOIN post_publications ON post_publications.id = posts.latest_publication_id").
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published")
+	members = applyDiscoverablePublicationFilter(members, "post_publications").Group("series_posts.series_id")
+
+	var series []db.Series
+	if err := s.db.Model(&db.Series{}).
+		Select("series.*, members.post_count AS post_count").
+		Joins("JOIN (?) AS members ON members.series_id = series.id", members).
+		Order("series.created_at desc").
+		Order("series.id desc").
+		Find(&series).Error; err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// publishedMembers 按系列顺序返回可出现在列表中的成员最新发布快照，未发布与不公开列出的成员会被跳过。
+func (s *SeriesService) publishedMembers(seriesID uint) ([]db.PostPublication, error) {
+	query := s.db.Preload("Tags").
+		Select(publicationWithSlugColumns).
+		Joins("JOIN posts ON posts.latest_publication_id = post_publications.id").
+		Joins("JOIN series_posts ON series_posts.post_id = posts.id").
+		Where("series_posts.series_id = ? AND posts.status = ? AND posts.deleted_at IS NULL", seriesID, "published")
+	query = applyDiscoverablePublicationFilter(query, "post_publications")
+
+	var publications []db.PostPublication
+	if err := query.
+		Order("series_posts.position asc").
+		Order("series_posts.id asc").
+		Find(&publications).Error; err != nil {
+		return nil, err
+	}
+	return publications, nil
+}
+
+func resolveSeriesSlug(tx *gorm.DB, slug, title string, seriesID uint) (string, error) {
+	if slug != "" {
+		var count int64
+		if err := tx.Model(&db.Series{}).Where("slug = ? AND id <> ?", slug, seriesID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count > 0 {
+			return "", ErrSeriesSlugTaken
+		}
+		return slug, nil
+	}
+
+	base := Slugify(title)
+	if base == "" || onlyDigits(base) {
+		base = "series"
+	}
+	candidate := base
+	for suffix := 2; ; suffix++ {
+		var count int64
+		if err := tx.Model(&db.Series{}).Where("slug = ? AND id <> ?", candidate, seriesID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
+
+func replaceSeriesMembers(tx *gorm.DB, seriesID uint, postIDs []uint) error {
+	seen := make(map[uint]struct{}, len(postIDs))
+	for _, id := range postIDs {
+		if _, ok := seen[id]; ok {
+			return ErrSeriesPostDuplicate
+		}
+		seen[id] = struct{}{}
+	}
+
+	if len(postIDs) > 0 {
+		var count int64
+		if err := tx.Unscoped().Model(&db.Post{}).Where("id IN ?", postIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != int64(len(postIDs)) {
+			return ErrPostNotFound
+		}
+
+		if err := tx.Model(&db.SeriesPost{}).
+			Where("post_id IN ? AND series_id <> ?", postIDs, seriesID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrSeriesPostConflict
+		}
+	}
+
+	if err := tx.Where("series_id = ?", seriesID).Delete(&db.SeriesPost{}).Error; err != nil {
+		return err
+	}
+	for idx, postID := range postIDs {
+		member := db.SeriesPost{SeriesID: seriesID, PostID: postID, Position: idx}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestSeriesService_CreateAndNavigate(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	seriesSvc := NewSeriesService(gdb)
+
+	user := db.User{Username: "series-author"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	part1 := createSchedulablePost(t, svc, user.ID, "# 第一部分\n正文")
+	part2 := createSchedulablePost(t, svc, user.ID, "# 第二部分\n正文")
+	part3 := createSchedulablePost(t, svc, user.ID, "# 第三部分\n正文")
+	for _, post := range []*db.Post{part1, part3} {
+		if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+			t.Fatalf("publish post %d: %v", post.ID, err)
+		}
+	}
+
+	series, err := seriesSvc.Create(SeriesInput{
+		Title:   "Go 并发入门",
+		PostIDs: []uint{part1.ID, part2.ID, part3.ID},
+	})
+	if err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+	if series.Slug != "go-bing-fa-ru-men" {
+		t.Fatalf("expected slug generated from title, got %q", series.Slug)
+	}
+	if len(series.Members) != 3 || series.Members[1].Title != "第二部分" {
+		t.Fatalf("expected ordered members with titles, got %+v", series.Members)
+	}
+
+	published, err := seriesSvc.GetPublishedBySlug(series.Slug)
+	if err != nil {
+		t.Fatalf("get published series: %v", err)
+	}
+	if len(published.Publications) != 2 {
+		t.Fatalf("expected unpublished part to be skipped, got %d", len(published.Publications))
+	}
+
+	nav, err := seriesSvc.NavigationForPost(part3.ID)
+	if err != nil {
+		t.Fatalf("navigation: %v", err)
+	}
+	if nav == nil || nav.Position != 2 || nav.Total != 2 {
+		t.Fatalf("expected part 2 of 2, got %+v", nav)
+	}
+	if nav.Previous == nil || nav.Previous.PostID != part1.ID || nav.Next != nil {
+		t.Fatalf("expected previous to skip unpublished part, got %+v", nav)
+	}
+
+	nav, err = seriesSvc.NavigationForPost(part2.ID)
+	if err != nil || nav != nil {
+		t.Fatalf("expected no navigation for unpublished member, got %+v, %v", nav, err)
+	}
+
+	if _, err := seriesSvc.Create(SeriesInput{Title: "另一个系列", PostIDs: []uint{part1.ID}}); !errors.Is(err, ErrSeriesPostConflict) {
+		t.Fatalf("expected ErrSeriesPostConflict, got %v", err)
+	}
+	if _, err := seriesSvc.Create(SeriesInput{Title: "Go 并发入门", Slug: series.Slug}); !errors.Is(err, ErrSeriesSlugTaken) {
+		t.Fatalf("expected ErrSeriesSlugTaken, got %v", err)
+	}
+}
+
+func TestSeriesService_UpdateReordersAndDeleteKeepsPosts(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	seriesSvc := NewSeriesService(gdb)
+
+	user := db.User{Username: "series-editor"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	first := createSchedulablePost(t, svc, user.ID, "# 甲\n正文")
+	second := createSchedulablePost(t, svc, user.ID, "# 乙\n正文")
+
+	series, err := seriesSvc.Create(SeriesInput{Title: "Ordering", PostIDs: []uint{first.ID, second.ID}})
+	if err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+
+	updated, err := seriesSvc.Update(series.ID, SeriesInput{Title: "Ordering", PostIDs: []uint{second.ID, first.ID}})
+	if err != nil {
+		t.Fatalf("update series: %v", err)
+	}
+	if updated.Slug != series.Slug {
+		t.Fatalf("expected slug to be kept, got %q", updated.Slug)
+	}
+	if updated.Members[0].PostID != second.ID || updated.Members[1].PostID != first.ID {
+		t.Fatalf("expected members to be reordered, got %+v", updated.Members)
+	}
+
+	if _, err := seriesSvc.Update(series.ID, SeriesInput{Title: "Ordering", PostIDs: []uint{first.ID, first.ID}}); !errors.Is(err, ErrSeriesPostDuplicate) {
+		t.Fatalf("expected ErrSeriesPostDuplicate, got %v", err)
+	}
+
+	if err := seriesSvc.Delete(series.ID); err != nil {
+		t.Fatalf("delete series: %v", err)
+	}
+	var memberCount int64
+	if err := gdb.Model(&db.SeriesPost{}).Count(&memberCount).Error; err != nil {
+		t.Fatalf("count members: %v", err)
+	}
+	if memberCount != 0 {
+		t.Fatalf("expected members to be removed, got %d", memberCount)
+	}
+	if _, err := svc.Get(first.ID); err != nil {
+		t.Fatalf("expected post to survive series deletion: %v", err)
+	}
+}
+
+func TestSeriesService_SkipsUnlistedMembers(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+	seriesSvc := NewSeriesService(gdb)
+
+	user := db.User{Username: "series-unlisted"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	listed := createSchedulablePost(t, svc, user.ID, "# 公开部分\n正文")
+	unlisted, err := svc.Create(PostInput{
+		Content:     "# 隐藏部分\n正文",
+		UserID:      user.ID,
+		Visibility:  db.PostVisibilityUnlisted,
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create unlisted post: %v", err)
+	}
+	for _, post := range []*db.Post{listed, unlisted} {
+		if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+			t.Fatalf("publish post %d: %v", post.ID, err)
+		}
+	}
+
+	series, err := seriesSvc.Create(SeriesInput{Title: "可见性", PostIDs: []uint{unlisted.ID, listed.ID}})
+	if err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+	hidden, err := seriesSvc.Create(SeriesInput{Title: "全部隐藏", PostIDs: []uint{}})
+	if err != nil {
+		t.Fatalf("create empty series: %v", err)
+	}
+
+	published, err := seriesSvc.GetPublishedBySlug(series.Slug)
+	if err != nil {
+		t.Fatalf("get published series: %v", err)
+	}
+	if len(published.Publications) != 1 || published.Publications[0].PostID != listed.ID {
+		t.Fatalf("expected only listed member, got %+v", published.Publications)
+	}
+
+	nav, err := seriesSvc.NavigationForPost(listed.ID)
+	if err != nil {
+		t.Fatalf("navigation: %v", err)
+	}
+	if nav == nil || nav.Total != 1 || nav.Previous != nil {
+		t.Fatalf("expected navigation to skip unlisted part, got %+v", nav)
+	}
+
+	list, err := seriesSvc.ListPublished()
+	if err != nil {
+		t.Fatalf("list published series: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != series.ID || list[0].PostCount != 1 {
+		t.Fatalf("expected only series with listed members, got %+v (hidden series %d)", list, hidden.ID)
+	}
+}
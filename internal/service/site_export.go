@@ -0,0 +1,406 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// SiteExporter 将整站内容导出为可移植的 Markdown + front matter 压缩包。
+//
+// 压缩包结构：
+//
+//	posts/<slug>.md              每篇文章一个文件
+//	templates/template-<id>.md   文章模板
+//	about.md                     关于页面
+//	gallery.yaml                 摄影作品元数据
+//	uploads/...                  被引用到的上传文件，链接改写为相对路径
+type SiteExporter struct {
+	db        *gorm.DB
+	uploadDir string
+	uploadURL string
+	now       func() time.Time
+}
+
+// NewSiteExporter 创建站点导出器。
+func NewSiteExporter(gdb *gorm.DB, uploadDir, uploadURL string) *SiteExporter {
+	return &SiteExporter{db: gdb, uploadDir: uploadDir, uploadURL: uploadURL, now: time.Now}
+}
+
+type exportedPostFrontMatter struct {
+	Title      string              `yaml:"title"`
+	Slug       string              `yaml:"slug,omitempty"`
+	Date       *time.Time          `yaml:"date,omitempty"`
+	Updated    time.Time           `yaml:"updated"`
+	Status     string              `yaml:"status"`
+	Draft      bool                `yaml:"draft"`
+	Visibility string              `yaml:"visibility"`
+	Summary    string              `yaml:"summary,omitempty"`
+	Tags       []string            `yaml:"tags,omitempty"`
+	Cover      *exportedCoverField `yaml:"cover,omitempty"`
+	Version    int                 `yaml:"version,omitempty"`
+}
+
+type exportedTemplateFrontMatter struct {
+	Name        string              `yaml:"name"`
+	Description string              `yaml:"description,omitempty"`
+	Visibility  string              `yaml:"visibility"`
+	Summary     string              `yaml:"summary,omitempty"`
+	Tags        []string            `yaml:"tags,omitempty"`
+	Cover       *exportedCoverField `yaml:"cover,omitempty"`
+}
+
+type exportedPageFrontMatter struct {
+	Title   string    `yaml:"title"`
+	Slug    string    `yaml:"slug"`
+	Summary string    `yaml:"summary,omitempty"`
+	Updated time.Time `yaml:"updated"`
+}
+
+type exportedCoverField struct {
+	Image  string `yaml:"image"`
+	Width  int    `yaml:"width,omitempty"`
+	Height int    `yaml:"height,omitempty"`
+}
+
+type exportedGalleryImage struct {
+	Title       string `yaml:"title,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Image       string `yaml:"image"`
+	Width       int    `yaml:"width"`
+	Height      int    `yaml:"height"`
+	Status      string `yaml:"status"`
+	SortOrder   int    `yaml:"sort_order"`
+}
+
+type exportedFile struct {
+	name    string
+	content []byte
+}
+
+// siteExportBuilder 在一次导出中收集文件并记录被引用的上传文件。
+type siteExportBuilder struct {
+	exporter *SiteExporter
+	pattern  *regexp.Regexp
+	prefix   string
+	files    []exportedFile
+	uploads  map[string]bool
+}
+
+// Export 将站点内容写入 zip 并输出到 w，回收站中的记录不会被导出。
+func (s *SiteExporter) Export(w io.Writer) error {
+	prefix := s.uploadURLPrefix()
+	builder := &siteExportBuilder{
+		exporter: s,
+		// 同时匹配站内绝对地址（含协议与域名）和以上传前缀开头的相对地址
+		pattern: regexp.MustCompile(`(?:https?://[^\s"'()<>\[\]/]+)?` + regexp.QuoteMeta(prefix) + `/[^\s"'()<>\[\]?#]+`),
+		prefix:  prefix,
+		uploads: make(map[string]bool),
+	}
+
+	if err := builder.addPosts(); err != nil {
+		return err
+	}
+	if err := builder.addTemplates(); err != nil {
+		return err
+	}
+	if err := builder.addAboutPage(); err != nil {
+		return err
+	}
+	if err := builder.addGallery(); err != nil {
+		return err
+	}
+	return builder.write(w)
+}
+
+func (b *siteExportBuilder) addPosts() error {
+	var posts []db.Post
+	if err := b.exporter.db.Preload("Tags").Order("id ASC").Find(&posts).Error; err != nil {
+		return err
+	}
+
+	// 已发布过的文章导出最近一次发布的内容，未发布过的文章才导出草稿
+	latestIDs := make(map[uint]uint)
+	var versions []db.PostPublication
+	if err := b.exporter.db.Select("id", "post_id", "version").
+		Order("post_id ASC, version ASC").Find(&versions).Error; err != nil {
+		return err
+	}
+	for _, publication := range versions {
+		latestIDs[publication.PostID] = publication.ID
+	}
+	latest := make(map[uint]db.PostPublication, len(latestIDs))
+	if len(latestIDs) > 0 {
+		ids := make([]uint, 0, len(latestIDs))
+		for _, id := range latestIDs {
+			ids = append(ids, id)
+		}
+		var publications []db.PostPublication
+		if err := b.exporter.db.Preload("Tags").Where("id IN ?", ids).Find(&publications).Error; err != nil {
+			return err
+		}
+		for _, publication := range publications {
+			latest[publication.PostID] = publication
+		}
+	}
+
+	used := make(map[string]struct{}, len(posts))
+	for _, post := range posts {
+		meta := exportedPostFrontMatter{
+			Title:      post.Title,
+			Slug:       post.Slug,
+			Updated:    post.UpdatedAt,
+			Status:     post.Status,
+			Draft:      post.Status != "published",
+			Visibility: db.NormalizePostVisibility(post.Visibility),
+			Summary:    post.Summary,
+			Tags:       exportTagNames(post.Tags),
+			Cover:      b.cover(post.CoverURL, post.CoverWidth, post.CoverHeight, "../"),
+		}
+		body := post.Content
+		if publication, ok := latest[post.ID]; ok {
+			publishedAt := publication.PublishedAt
+			meta.Date = &publishedAt
+			meta.Version = publication.Version
+			meta.Title = publication.Title
+			meta.Visibility = db.NormalizePostVisibility(publication.Visibility)
+			meta.Summary = publication.Summary
+			meta.Tags = exportTagNames(publication.Tags)
+			meta.Cover = b.cover(publication.CoverURL, publication.CoverWidth, publication.CoverHeight, "../")
+			body = publication.Content
+		} else if post.Status == "published" && !post.PublishedAt.IsZero() {
+			publishedAt := post.PublishedAt
+			meta.Date = &publishedAt
+		}
+
+		name := strings.TrimSpace(post.Slug)
+		if name == "" {
+			name = fmt.Sprintf("post-%d", post.ID)
+		}
+		if _, exists := used[name]; exists {
+			name = fmt.Sprintf("%s-%d", name, post.ID)
+		}
+		used[name] = struct{}{}
+
+		content, err := renderFrontMatterDocument(meta, b.rewrite(body, "../"))
+		if err != nil {
+			return fmt.Errorf("export post %d: %w", post.ID, err)
+		}
+		b.files = append(b.files, exportedFile{name: "posts/" + name + ".md", content: content})
+	}
+	return nil
+}
+
+func (b *siteExportBuilder) addTemplates() error {
+	var templates []db.PostTemplate
+	if err := b.exporter.db.Preload("Tags").Order("id ASC").Find(&templates).Error; err != nil {
+		return err
+	}
+	for _, tpl := range templates {
+		meta := exportedTemplateFrontMatter{
+			Name:        tpl.Name,
+			Description: tpl.Description,
+			Visibility:  tpl.Visibility,
+			Summary:     tpl.Summary,
+			Tags:        exportTagNames(tpl.Tags),
+			Cover:       b.cover(tpl.CoverURL, tpl.CoverWidth, tpl.CoverHeight, "../"),
+		}
+		content, err := renderFrontMatterDocument(meta, b.rewrite(tpl.Content, "../"))
+		if err != nil {
+			return fmt.Errorf("export template %d: %w", tpl.ID, err)
+		}
+		b.files = append(b.files, exportedFile{name: fmt.Sprintf("templates/template-%d.md", tpl.ID), content: content})
+	}
+	return nil
+}
+
+func (b *siteExportBuilder) addAboutPage() error {
+	var page db.Page
+	result := b.exporter.db.Where("slug = ?", "about").Limit(1).Find(&page)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	meta := exportedPageFrontMatter{
+		Title:   page.Title,
+		Slug:    page.Slug,
+		Summary: page.Summary,
+		Updated: page.UpdatedAt,
+	}
+	content, err := renderFrontMatterDocument(meta, b.rewrite(page.Content, ""))
+	if err != nil {
+		return fmt.Errorf("export about page: %w", err)
+	}
+	b.files = append(b.files, exportedFile{name: "about.md", content: content})
+	return nil
+}
+
+func (b *siteExportBuilder) addGallery() error {
+	var images []db.GalleryImage
+	if err := b.exporter.db.Order("sort_order ASC, id ASC").Find(&images).Error; err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return nil
+	}
+
+	items := make([]exportedGalleryImage, 0, len(images))
+	for _, image := range images {
+		items = append(items, exportedGalleryImage{
+			Title:       image.Title,
+			Description: image.Description,
+			Image:       b.rewrite(image.ImageURL, ""),
+			Width:       image.ImageWidth,
+			Height:      image.ImageHeight,
+			Status:      image.Status,
+			SortOrder:   image.SortOrder,
+		})
+	}
+	content, err := yaml.Marshal(map[string]interface{}{"images": items})
+	if err != nil {
+		return fmt.Errorf("export gallery: %w", err)
+	}
+	b.files = append(b.files, exportedFile{name: "gallery.yaml", content: content})
+	return nil
+}
+
+func (b *siteExportBuilder) cover(url string, width, height int, base string) *exportedCoverField {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil
+	}
+	return &exportedCoverField{Image: b.rewrite(url, base), Width: width, Height: height}
+}
+
+// rewrite 将指向上传目录且文件存在的链接改写为相对 base 的 uploads/ 路径。
+func (b *siteExportBuilder) rewrite(value, base string) string {
+	return b.pattern.ReplaceAllStringFunc(value, func(match string) string {
+		idx := strings.Index(match, b.prefix+"/")
+		if idx < 0 {
+			return match
+		}
+		rel := path.Clean(match[idx+len(b.prefix)+1:])
+		if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || strings.HasPrefix(rel, "/") {
+			return match
+		}
+		if !b.uploadExists(rel) {
+			return match
+		}
+		return base + "uploads/" + rel
+	})
+}
+
+func (b *siteExportBuilder) uploadExists(rel string) bool {
+	if exists, ok := b.uploads[rel]; ok {
+		return exists
+	}
+	exists := false
+	if strings.TrimSpace(b.exporter.uploadDir) != "" {
+		info, err := os.Stat(filepath.Join(b.exporter.uploadDir, filepath.FromSlash(rel)))
+		exists = err == nil && info.Mode().IsRegular()
+	}
+	b.uploads[rel] = exists
+	return exists
+}
+
+func (b *siteExportBuilder) write(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	modified := b.exporter.now()
+
+	for _, file := range b.files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(file.content); err != nil {
+			return err
+		}
+	}
+
+	refs := make([]string, 0, len(b.uploads))
+	for rel, exists := range b.uploads {
+		if exists {
+			refs = append(refs, rel)
+		}
+	}
+	sort.Strings(refs)
+	for _, rel := range refs {
+		if err := b.copyUpload(archive, rel); err != nil {
+			return fmt.Errorf("export upload %s: %w", rel, err)
+		}
+	}
+	return archive.Close()
+}
+
+func (b *siteExportBuilder) copyUpload(archive *zip.Writer, rel string) error {
+	source, err := os.Open(filepath.Join(b.exporter.uploadDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = "uploads/" + rel
+	header.Method = zip.Deflate
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, source)
+	return err
+}
+
+func (s *SiteExporter) uploadURLPrefix() string {
+	prefix := strings.TrimRight(strings.TrimSpace(s.uploadURL), "/")
+	if prefix == "" {
+		prefix = "/uploads"
+	}
+	return prefix
+}
+
+// renderFrontMatterDocument 生成以 YAML front matter 开头的 Markdown 文档，可被导入器重新读取。
+func renderFrontMatterDocument(meta interface{}, body string) ([]byte, error) {
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n\n")
+	buf.WriteString(strings.TrimSpace(body))
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+func exportTagNames(tags []db.Tag) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
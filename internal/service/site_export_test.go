@@ -0,0 +1,168 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func readExportArchive(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open export archive: %v", err)
+	}
+	files := make(map[string]string, len(reader.File))
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", file.Name, err)
+		}
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func TestSiteExporter_ExportWritesPortableMarkdown(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	if err := gdb.AutoMigrate(&db.Page{}); err != nil {
+		t.Fatalf("migrate pages: %v", err)
+	}
+	posts := NewPostService(gdb)
+	templates := NewTemplateService(gdb)
+	galleries := NewGalleryService(gdb)
+	uploadDir := t.TempDir()
+	writeUploadFile(t, uploadDir, "cover.jpg")
+	writeUploadFile(t, uploadDir, "inline.png")
+	writeUploadFile(t, uploadDir, "photo.jpg")
+	writeUploadFile(t, uploadDir, "unused.jpg")
+
+	user := db.User{Username: "exporter"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	tag := db.Tag{Name: "Go"}
+	if err := gdb.Create(&tag).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	post, err := posts.Create(PostInput{
+		Content:     "# 导出文章\n![配图](https://blog.example.com/uploads/inline.png)\n![缺失](/uploads/missing.png)",
+		Summary:     "导出摘要",
+		Slug:        "export-post",
+		UserID:      user.ID,
+		TagIDs:      []uint{tag.ID},
+		CoverURL:    "/uploads/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if _, err := posts.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish post: %v", err)
+	}
+	if _, err := posts.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("republish post: %v", err)
+	}
+	// 发布后未发布的草稿修改不应出现在导出中
+	if _, err := posts.Update(post.ID, PostInput{
+		Content: "# 未发布的修改\n草稿正文",
+		Summary: "草稿摘要",
+		Slug:    "export-post",
+		UserID:  user.ID,
+	}); err != nil {
+		t.Fatalf("update post draft: %v", err)
+	}
+
+	draft, err := posts.Create(PostInput{Content: "# 草稿\n正文", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+	trashed, err := posts.Create(PostInput{Content: "# 回收站\n正文", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("create trashed post: %v", err)
+	}
+	if err := posts.Delete(trashed.ID); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+
+	tpl, err := templates.Create(PostTemplateInput{Name: "周报", Content: "# {{title}}\n![](/uploads/inline.png)"})
+	if err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	if _, err := galleries.Create(GalleryInput{Title: "山景", ImageURL: "/uploads/photo.jpg", ImageWidth: 800, ImageHeight: 600}); err != nil {
+		t.Fatalf("create gallery image: %v", err)
+	}
+	if err := gdb.Create(&db.Page{Slug: "about", Title: "关于", Content: "你好 ![头像](/uploads/photo.jpg)"}).Error; err != nil {
+		t.Fatalf("create about page: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewSiteExporter(gdb, uploadDir, "/uploads").Export(&buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	files := readExportArchive(t, buf.Bytes())
+
+	exported, ok := files["posts/export-post.md"]
+	if !ok {
+		t.Fatalf("expected post file, got %v", files)
+	}
+	for _, want := range []string{"title: 导出文章", "visibility: public", "summary: 导出摘要", "- Go", "image: ../uploads/cover.jpg", "version: 2", "draft: false"} {
+		if !strings.Contains(exported, want) {
+			t.Fatalf("expected front matter to contain %q, got:\n%s", want, exported)
+		}
+	}
+	if strings.Contains(exported, "未发布的修改") || strings.Contains(exported, "草稿摘要") {
+		t.Fatalf("expected published content instead of draft edits, got:\n%s", exported)
+	}
+	if !strings.Contains(exported, "![配图](../uploads/inline.png)") {
+		t.Fatalf("expected upload link to be relative, got:\n%s", exported)
+	}
+	if !strings.Contains(exported, "![缺失](/uploads/missing.png)") {
+		t.Fatalf("expected missing upload link to stay untouched, got:\n%s", exported)
+	}
+
+	doc, err := parseImportedDocument("posts/export-post.md", exported)
+	if err != nil {
+		t.Fatalf("exported post should be importable: %v", err)
+	}
+	if doc.Slug != "export-post" || doc.Draft || doc.Date.IsZero() || doc.Cover != "../uploads/cover.jpg" {
+		t.Fatalf("unexpected re-imported document: %+v", doc)
+	}
+
+	if draftFile := files[fmt.Sprintf("posts/post-%d.md", draft.ID)]; !strings.Contains(draftFile, "draft: true") {
+		t.Fatalf("expected draft post to be exported as draft, got:\n%s", draftFile)
+	}
+	if _, exists := files[fmt.Sprintf("posts/post-%d.md", trashed.ID)]; exists {
+		t.Fatal("expected trashed post to be skipped")
+	}
+	if templateFile := files[fmt.Sprintf("templates/template-%d.md", tpl.ID)]; !strings.Contains(templateFile, "name: 周报") || !strings.Contains(templateFile, "](../uploads/inline.png)") {
+		t.Fatalf("unexpected template export:\n%s", templateFile)
+	}
+	if about := files["about.md"]; !strings.Contains(about, "title: 关于") || !strings.Contains(about, "](uploads/photo.jpg)") {
+		t.Fatalf("unexpected about export:\n%s", about)
+	}
+	if gallery := files["gallery.yaml"]; !strings.Contains(gallery, "image: uploads/photo.jpg") || !strings.Contains(gallery, "title: 山景") {
+		t.Fatalf("unexpected gallery export:\n%s", gallery)
+	}
+
+	for _, name := range []string{"uploads/cover.jpg", "uploads/inline.png", "uploads/photo.jpg"} {
+		if _, exists := files[name]; !exists {
+			t.Fatalf("expected %s in export", name)
+		}
+	}
+	if _, exists := files["uploads/unused.jpg"]; exists {
+		t.Fatal("expected unreferenced upload to be skipped")
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/commitlog/internal/db"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSlugInvalid = errors.New("post slug is invalid")
+	ErrSlugTaken   = errors.New("post slug is already in use")
+)
+
+const maxSlugLength = 96
+
+// reservedPostSlugs 与 /posts 下的固定路由冲突，不能作为 slug 使用。
+var reservedPostSlugs = map[string]struct{}{
+	"more": {},
+}
+
+// latinLigatures 是无法通过 Unicode 分解去掉附加符号的拉丁字母。
+var latinLigatures = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'œ': "oe",
+	'ø': "o",
+	'đ': "d",
+	'ð': "d",
+	'ł': "l",
+	'þ': "th",
+	'ı': "i",
+}
+
+var (
+	pinyinIndexOnce sync.Once
+	pinyinIndex     map[rune]string
+)
+
+// Slugify 将标题转换为 URL 友好的 slug，汉字按无声调拼音音译，带附加符号的拉丁字母去掉符号。
+func Slugify(title string) string {
+	words := make([]string, 0, 8)
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, current.String())
+			current.Reset()
+		}
+	}
+
+	// 先按 NFD 分解，é 变为 e 与组合用重音符，后者在下方直接跳过
+	for _, r := range norm.NFD.String(strings.ToLower(title)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			current.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+		case latinLigatures[r] != "":
+			current.WriteString(latinLigatures[r])
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if syllable := lookupPinyin(r); syllable != "" {
+				words = append(words, syllable)
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	var builder strings.Builder
+	for _, word := range words {
+		if builder.Len() > 0 && builder.Len()+1+len(word) > maxSlugLength {
+			break
+		}
+		if builder.Len() > 0 {
+			builder.WriteByte('-')
+		}
+		builder.WriteString(word)
+	}
+
+	slug := builder.String()
+	if len(slug) > maxSlugLength {
+		slug = strings.Trim(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
+
+func lookupPinyin(r rune) string {
+	pinyinIndexOnce.Do(func() {
+		pinyinIndex = make(map[rune]string, 7000)
+		for syllable, chars := range pinyinGroups {
+			for _, char := range chars {
+				pinyinIndex[char] = syllable
+			}
+		}
+	})
+	return pinyinIndex[r]
+}
+
+// normalizeSlugInput 规范用户输入的 slug，纯数字或保留字会与路由冲突。
+func normalizeSlugInput(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+
+	slug := Slugify(raw)
+	if slug == "" || onlyDigits(slug) {
+		return "", ErrSlugInvalid
+	}
+	if _, reserved := reservedPostSlugs[slug]; reserved {
+		return "", ErrSlugInvalid
+	}
+	return slug, nil
+}
+
+// slugAvailable 判断 slug 是否未被其他文章的当前或历史链接占用，回收站中的文章同样占用。
+func slugAvailable(tx *gorm.DB, slug string, postID uint) (bool, error) {
+	var count int64
+	if err := tx.Unscoped().Model(&db.Post{}).
+		Where("slug = ? AND id <> ?", slug, postID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	if err := tx.Model(&db.PostSlugRedirect{}).
+		Where("slug = ? AND post_id <> ?", slug, postID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// generateUniqueSlug 基于标题生成 slug，冲突时追加数字后缀。
+func generateUniqueSlug(tx *gorm.DB, title string, postID uint) (string, error) {
+	base := Slugify(title)
+	if _, reserved := reservedPostSlugs[base]; reserved || base == "" || onlyDigits(base) {
+		base = fmt.Sprintf("post-%d", postID)
+	}
+
+	candidate := base
+	for suffix := 2; ; suffix++ {
+		available, err := slugAvailable(tx, candidate, postID)
+		if err != nil {
+			return "", err
+		}
+		if available {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
+
+// applySlugChange 在文章 slug 变更时校验唯一性，并为已发布文章保留旧 slug 的重定向。
+func applySlugChange(tx *gorm.DB, post *db.Post, nextSlug string) error {
+	if nextSlug == "" || nextSlug == post.Slug {
+		return nil
+	}
+
+	available, err := slugAvailable(tx, nextSlug, post.ID)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return ErrSlugTaken
+	}
+
+	if post.ID != 0 {
+		// 重新启用历史 slug 时移除对应的重定向记录
+		if err := tx.Where("post_id = ? AND slug = ?", post.ID, nextSlug).
+			Delete(&db.PostSlugRedirect{}).Error; err != nil {
+			return err
+		}
+	}
+
+	if previous := strings.TrimSpace(post.Slug); previous != "" && post.PublicationCount > 0 {
+		if err := tx.Create(&db.PostSlugRedirect{PostID: post.ID, Slug: previous}).Error; err != nil {
+			return err
+		}
+	}
+
+	post.Slug = nextSlug
+	return nil
+}
+
+func onlyDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
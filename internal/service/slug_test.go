@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Hello, World!":        "hello-world",
+		"  Go 1.24 发布说明  ":     "go-1-24-fa-bu-shuo-ming",
+		"中文标题":                 "zhong-wen-biao-ti",
+		"---":                  "",
+		"Rust&Go：性能对比":         "rust-go-xing-neng-dui-bi",
+		"Ünïcödé accents only": "unicode-accents-only",
+		"Café Über":            "cafe-uber",
+		"Straße señor Ørsted":  "strasse-senor-orsted",
+	}
+	for input, expected := range cases {
+		if got := Slugify(input); got != expected {
+			t.Errorf("Slugify(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestNormalizeSlugInputRejectsRouteConflicts(t *testing.T) {
+	for _, input := range []string{"12345", "more", "!!!"} {
+		if _, err := normalizeSlugInput(input); !errors.Is(err, ErrSlugInvalid) {
+			t.Fatalf("expected ErrSlugInvalid for %q, got %v", input, err)
+		}
+	}
+	if slug, err := normalizeSlugInput(""); err != nil || slug != "" {
+		t.Fatalf("expected blank input to be accepted as empty slug, got %q, %v", slug, err)
+	}
+}
+
+func TestPostService_SlugLifecycle(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "slug-tester"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	input := PostInput{
+		Content:     "# 你好世界\n正文",
+		UserID:      user.ID,
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	}
+	first, err := svc.Create(input)
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	publication, err := svc.Publish(first.ID, user.ID, nil)
+	if err != nil {
+		t.Fatalf("publish post: %v", err)
+	}
+	if publication.PostSlug != "ni-hao-shi-jie" {
+		t.Fatalf("expected slug generated from title, got %q", publication.PostSlug)
+	}
+
+	second, err := svc.Create(input)
+	if err != nil {
+		t.Fatalf("create duplicate post: %v", err)
+	}
+	if _, err := svc.Publish(second.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish duplicate post: %v", err)
+	}
+	reloaded, err := svc.Get(second.ID)
+	if err != nil {
+		t.Fatalf("reload duplicate post: %v", err)
+	}
+	if reloaded.Slug != "ni-hao-shi-jie-2" {
+		t.Fatalf("expected suffixed slug, got %q", reloaded.Slug)
+	}
+
+	input.Slug = "ni-hao-shi-jie"
+	if _, err := svc.Update(second.ID, input); !errors.Is(err, ErrSlugTaken) {
+		t.Fatalf("expected ErrSlugTaken, got %v", err)
+	}
+
+	input.Slug = "hello-world"
+	if _, err := svc.Update(first.ID, input); err != nil {
+		t.Fatalf("rename slug: %v", err)
+	}
+
+	ref, err := svc.ResolvePostRef("ni-hao-shi-jie")
+	if err != nil {
+		t.Fatalf("resolve old slug: %v", err)
+	}
+	if ref.PostID != first.ID || ref.Slug != "hello-world" || ref.Canonical {
+		t.Fatalf("expected old slug to redirect to hello-world, got %+v", ref)
+	}
+
+	// 历史 slug 仍被原文章占用，其他文章不能抢占
+	input.Slug = "ni-hao-shi-jie"
+	if _, err := svc.Update(second.ID, input); !errors.Is(err, ErrSlugTaken) {
+		t.Fatalf("expected redirect slug to stay reserved, got %v", err)
+	}
+
+	ref, err = svc.ResolvePostRef("hello-world")
+	if err != nil || !ref.Canonical || ref.PostID != first.ID {
+		t.Fatalf("expected canonical ref for current slug, got %+v, %v", ref, err)
+	}
+
+	ref, err = svc.ResolvePostRef("1")
+	if err != nil || ref.Canonical || ref.Slug != "hello-world" {
+		t.Fatalf("expected numeric ref to redirect, got %+v, %v", ref, err)
+	}
+
+	if _, err := svc.ResolvePostRef("missing-slug"); !errors.Is(err, ErrPostNotFound) {
+		t.Fatalf("expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestPostService_BackfillSlugs(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	legacy := db.Post{Content: "# Legacy Post\n正文", Status: "published", UserID: 1}
+	draft := db.Post{Content: "# Draft Post\n正文", Status: "draft", UserID: 1}
+	if err := gdb.Create(&legacy).Error; err != nil {
+		t.Fatalf("create legacy post: %v", err)
+	}
+	if err := gdb.Create(&draft).Error; err != nil {
+		t.Fatalf("create draft post: %v", err)
+	}
+
+	count, err := svc.BackfillSlugs()
+	if err != nil {
+		t.Fatalf("backfill slugs: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 backfilled post, got %d", count)
+	}
+
+	var reloaded db.Post
+	if err := gdb.First(&reloaded, legacy.ID).Error; err != nil {
+		t.Fatalf("reload legacy post: %v", err)
+	}
+	if reloaded.Slug != "legacy-post" {
+		t.Fatalf("expected legacy-post slug, got %q", reloaded.Slug)
+	}
+	var reloadedDraft db.Post
+	if err := gdb.First(&reloadedDraft, draft.ID).Error; err != nil {
+		t.Fatalf("reload draft post: %v", err)
+	}
+	if reloadedDraft.Slug != "" {
+		t.Fatalf("expected draft to keep empty slug, got %q", reloadedDraft.Slug)
+	}
+}
@@ -76,6 +76,7 @@ type SystemSettings struct {
 	AIRewritePrompt  string
 	GallerySubtitle  string
 	GalleryEnabled   bool
+	LiteEmbeds       bool
 	NavButtons       []NavButton
 }
 
@@ -104,6 +105,7 @@ type SystemSettingsInput struct {
 	AIRewritePrompt  string
 	GallerySubtitle  string
 	GalleryEnabled   *bool
+	LiteEmbeds       *bool
 	NavButtons       []NavButton
 }
 
@@ -147,6 +149,7 @@ var settingKeys = []string{
 	db.SettingKeyAISummaryPrompt,
 	db.SettingKeyAIRewritePrompt,
 	db.SettingKeyGalleryEnabled,
+	db.SettingKeyLiteEmbeds,
 	db.SettingKeyNavButtons,
 }
 
@@ -229,6 +232,10 @@ func (s *SystemSettingService) GetSettings() (SystemSettings, error) {
 			if parsed, err := strconv.ParseBool(strings.TrimSpace(record.Value)); err == nil {
 				result.GalleryEnabled = parsed
 			}
+		case db.SettingKeyLiteEmbeds:
+			if parsed, err := strconv.ParseBool(strings.TrimSpace(record.Value)); err == nil {
+				result.LiteEmbeds = parsed
+			}
 		case db.SettingKeyNavButtons:
 			if parsed := parseNavButtons(record.Value); len(parsed) > 0 {
 				result.NavButtons = parsed
@@ -288,6 +295,11 @@ func (s *SystemSettingService) UpdateSettings(input SystemSettingsInput) (System
 		galleryEnabled = *input.GalleryEnabled
 	}
 
+	liteEmbeds := false
+	if input.LiteEmbeds != nil {
+		liteEmbeds = *input.LiteEmbeds
+	}
+
 	sanitized := SystemSettings{
 		SiteName:         strings.TrimSpace(input.SiteName),
 		SiteLogoURL:      strings.TrimSpace(input.SiteLogoURL),
@@ -306,6 +318,7 @@ func (s *SystemSettingService) UpdateSettings(input SystemSettingsInput) (System
 		AIRewritePrompt:  strings.TrimSpace(input.AIRewritePrompt),
 		GallerySubtitle:  strings.TrimSpace(input.GallerySubtitle),
 		GalleryEnabled:   galleryEnabled,
+		LiteEmbeds:       liteEmbeds,
 		NavButtons:       normalizeNavButtons(input.NavButtons),
 	}
 
@@ -404,6 +417,9 @@ func (s *SystemSettingService) UpdateSettings(input SystemSettingsInput) (System
 		if err := upsertSetting(tx, db.SettingKeyGalleryEnabled, strconv.FormatBool(sanitized.GalleryEnabled)); err != nil {
 			return err
 		}
+		if err := upsertSetting(tx, db.SettingKeyLiteEmbeds, strconv.FormatBool(sanitized.LiteEmbeds)); err != nil {
+			return err
+		}
 		navButtonsJSON, err := json.Marshal(sanitized.NavButtons)
 		if err != nil {
 			return fmt.Errorf("marshal nav buttons: %w", err)
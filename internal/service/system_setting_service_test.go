@@ -112,6 +112,7 @@ func TestSystemSettingServiceUpdateAndRetrieve(t *testing.T) {
 		OpenAIAPIKey:     "sk-xxxx",
 		DeepSeekAPIKey:   "ds-12345",
 		GalleryEnabled:   boolPtr(false),
+		LiteEmbeds:       boolPtr(true),
 		NavButtons: []NavButton{
 			{Type: NavButtonTypeCustom, Title: " 文档 ", URL: " https://example.com/docs "},
 			{Type: NavButtonTypeRSS},
@@ -226,6 +227,9 @@ func TestSystemSettingServiceUpdateAndRetrieve(t *testing.T) {
 	if fetched.GalleryEnabled {
 		t.Fatalf("expected gallery to remain disabled, got %v", fetched.GalleryEnabled)
 	}
+	if !fetched.LiteEmbeds {
+		t.Fatal("expected lite embeds to stay enabled")
+	}
 	if fetched.AISummaryPrompt != "摘要提示" {
 		t.Fatalf("expected summary prompt %q, got %q", "摘要提示", fetched.AISummaryPrompt)
 	}
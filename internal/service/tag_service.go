@@ -71,7 +71,7 @@ func (s *TagService) PublishedUsage() ([]TagUsage, error) {
 		Joins("JOIN post_publication_tags ON post_publication_tags.tag_id = tags.id").
 		Joins("JOIN post_publications ON post_publications.id = post_publication_tags.post_publication_id").
 		Joins("JOIN posts ON posts.latest_publication_id = post_publications.id").
-		Where("posts.status = ? AND COALESCE(NULLIF(LOWER(TRIM(post_publications.visibility)), ''), ?) = ?", "published", db.PostVisibilityPublic, db.PostVisibilityPublic).
+		Where("posts.status = ? AND posts.deleted_at IS NULL AND COALESCE(NULLIF(LOWER(TRIM(post_publications.visibility)), ''), ?) IN ?", "published", db.PostVisibilityPublic, []string{db.PostVisibilityPublic, db.PostVisibilityProtected}).
 		Group("tags.id, tags.name").
 		Order("tags.sort_order asc").
 		Order("tags.name asc").
@@ -210,7 +210,8 @@ func (s *TagService) Reorder(ids []uint) error {
 
 func (s *TagService) postUsageCount(id uint) (int64, error) {
 	var count int64
-	if err := s.db.Model(&db.Post{}).
+	// 回收站中的文章仍引用标签，恢复前不允许删除
+	if err := s.db.Unscoped().Model(&db.Post{}).
 		Joins("JOIN post_tags ON posts.id = post_tags.post_id").
 		Where("post_tags.tag_id = ?", id).
 		Count(&count).Error; err != nil {
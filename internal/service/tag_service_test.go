@@ -20,7 +20,7 @@ func setupTagServiceTestDB(t *testing.T) (*gorm.DB, func()) {
 		t.Fatalf("failed to open test db: %v", err)
 	}
 
-	if err := gdb.AutoMigrate(&db.Tag{}, &db.Post{}, &db.PostPublication{}); err != nil {
+	if err := gdb.AutoMigrate(&db.Tag{}, &db.Post{}, &db.PostPublication{}, &db.PostSlugRedirect{}); err != nil {
 		t.Fatalf("failed to migrate test db: %v", err)
 	}
 
@@ -190,7 +190,7 @@ func (s *TemplateService) Update(id uint, input PostTemplateInput) (*db.PostTemp
 	return s.Get(template.ID)
 }
 
-// Delete 物理删除模板，并解除文章来源引用。
+// Delete 将模板移入回收站。
 func (s *TemplateService) Delete(id uint) error {
 	var template db.PostTemplate
 	if err := s.db.First(&template, id).Error; err != nil {
@@ -200,17 +200,8 @@ func (s *TemplateService) Delete(id uint) error {
 		return err
 	}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&db.Post{}).
-			Where("source_template_id = ?", id).
-			Update("source_template_id", nil).Error; err != nil {
-			return err
-		}
-		if err := tx.Exec("DELETE FROM post_template_tags WHERE post_template_id = ?", id).Error; err != nil {
-			return err
-		}
-		return tx.Unscoped().Delete(&template).Error
-	})
+	// 仅移入回收站，标签关联与文章来源引用在彻底清除时处理
+	return s.db.Delete(&template).Error
 }
 
 // RenderContent 根据占位符变量渲染模板正文。
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultTrashPurgeInterval 是后台检查过期回收站记录的默认间隔。
+const DefaultTrashPurgeInterval = time.Hour
+
+// TrashPurger 在后台定期彻底清除超过保留期的回收站记录。
+type TrashPurger struct {
+	trash     *TrashService
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewTrashPurger 创建回收站自动清理器，interval 非正数时使用默认间隔。
+func NewTrashPurger(trash *TrashService, retention, interval time.Duration) *TrashPurger {
+	if interval <= 0 {
+		interval = DefaultTrashPurgeInterval
+	}
+	return &TrashPurger{trash: trash, retention: retention, interval: interval, now: time.Now}
+}
+
+// Run 持续执行清理直到 ctx 被取消，启动时先执行一次。
+func (p *TrashPurger) Run(ctx context.Context) {
+	p.RunOnce()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.RunOnce()
+		}
+	}
+}
+
+// RunOnce 清除所有删除时间早于保留期的记录，返回清除数量。
+func (p *TrashPurger) RunOnce() int {
+	purged, err := p.trash.PurgeExpired(p.now().Add(-p.retention))
+	if err != nil {
+		log.Printf("[trash] purge expired items failed: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("[trash] purged %d expired items", purged)
+	}
+	return purged
+}
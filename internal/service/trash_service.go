@@ -0,0 +1,408 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTrashKindInvalid  = errors.New("trash item kind is invalid")
+	ErrTrashItemNotFound = errors.New("trash item not found")
+)
+
+const (
+	TrashKindPost     = "post"
+	TrashKindTemplate = "template"
+	TrashKindGallery  = "gallery"
+)
+
+// uploadReferenceColumns 列出可能引用上传文件的字段，清除文件前需确认这些字段都不再引用。
+// 查询不区分是否软删除，回收站中的数据仍视为引用。
+var uploadReferenceColumns = []struct {
+	Table  string
+	Column string
+}{
+	{"posts", "cover_url"},
+	{"posts", "content"},
+	{"post_publications", "cover_url"},
+	{"post_publications", "content"},
+	{"post_draft_versions", "cover_url"},
+	{"post_draft_versions", "content"},
+	{"post_templates", "cover_url"},
+	{"post_templates", "content"},
+	{"gallery_images", "image_url"},
+	{"pages", "content"},
+	{"series", "cover_url"},
+	{"system_settings", "value"},
+}
+
+// TrashItem 描述回收站中的一条记录。
+type TrashItem struct {
+	Kind      string
+	ID        uint
+	Title     string
+	ImageURL  string
+	DeletedAt time.Time
+}
+
+// TrashService 管理软删除的文章、模板与摄影作品。
+type TrashService struct {
+	db        *gorm.DB
+	uploadDir string
+	uploadURL string
+}
+
+// NewTrashService 创建回收站服务，uploadDir 与 uploadURL 用于定位需要清理的上传文件。
+func NewTrashService(gdb *gorm.DB, uploadDir, uploadURL string) *TrashService {
+	return &TrashService{db: gdb, uploadDir: uploadDir, uploadURL: uploadURL}
+}
+
+// List 返回回收站中的记录，kind 为空时返回全部类型，按删除时间倒序排列。
+func (s *TrashService) List(kind string) ([]TrashItem, error) {
+	kind = strings.TrimSpace(kind)
+	if kind != "" && !validTrashKind(kind) {
+		return nil, ErrTrashKindInvalid
+	}
+
+	items := make([]TrashItem, 0)
+	if kind == "" || kind == TrashKindPost {
+		var posts []db.Post
+		if err := s.trashed(&db.Post{}).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			items = append(items, TrashItem{Kind: TrashKindPost, ID: post.ID, Title: post.Title, ImageURL: post.CoverURL, DeletedAt: post.DeletedAt.Time})
+		}
+	}
+	if kind == "" || kind == TrashKindTemplate {
+		var templates []db.PostTemplate
+		if err := s.trashed(&db.PostTemplate{}).Find(&templates).Error; err != nil {
+			return nil, err
+		}
+		for _, template := range templates {
+			items = append(items, TrashItem{Kind: TrashKindTemplate, ID: template.ID, Title: template.Name, ImageURL: template.CoverURL, DeletedAt: template.DeletedAt.Time})
+		}
+	}
+	if kind == "" || kind == TrashKindGallery {
+		var images []db.GalleryImage
+		if err := s.trashed(&db.GalleryImage{}).Find(&images).Error; err != nil {
+			return nil, err
+		}
+		for _, image := range images {
+			items = append(items, TrashItem{Kind: TrashKindGallery, ID: image.ID, Title: image.Title, ImageURL: image.ImageURL, DeletedAt: image.DeletedAt.Time})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// Restore 将记录移出回收站，标签、发布历史等关联在软删除期间保持不变。
+func (s *TrashService) Restore(kind string, id uint) error {
+	model, err := trashModel(kind)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Unscoped().Model(model).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTrashItemNotFound
+	}
+	if kind == TrashKindPost {
+		if err := syncSearchIndex(s.db, id); err != nil {
+			return err
+		}
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			if err := refreshPostLinks(tx, id); err != nil {
+				return err
+			}
+			return markRelatedPostsStale(tx)
+		})
+	}
+	return nil
+}
+
+// Purge 彻底删除回收站中的记录及其关联数据，并清理不再被引用的上传文件。
+func (s *TrashService) Purge(kind string, id uint) error {
+	if !validTrashKind(kind) {
+		return ErrTrashKindInvalid
+	}
+
+	var uploads []string
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		switch kind {
+		case TrashKindPost:
+			uploads, err = s.purgePost(tx, id)
+		case TrashKindTemplate:
+			uploads, err = s.purgeTemplate(tx, id)
+		case TrashKindGallery:
+			uploads, err = s.purgeGalleryImage(tx, id)
+		}
+		return err
+	}); err != nil {
+		return err
+	}
+
+	s.removeUnreferencedUploads(uploads)
+	return nil
+}
+
+// PurgeExpired 彻底删除在 cutoff 之前移入回收站的全部记录，返回清除数量。
+func (s *TrashService) PurgeExpired(cutoff time.Time) (int, error) {
+	purged := 0
+	var errs []error
+	for _, kind := range []string{TrashKindPost, TrashKindTemplate, TrashKindGallery} {
+		model, _ := trashModel(kind)
+		var ids []uint
+		if err := s.trashed(model).Where("deleted_at < ?", cutoff).Pluck("id", &ids).Error; err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, id := range ids {
+			if err := s.Purge(kind, id); err != nil {
+				errs = append(errs, fmt.Errorf("purge %s %d: %w", kind, id, err))
+				continue
+			}
+			purged++
+		}
+	}
+	return purged, errors.Join(errs...)
+}
+
+func (s *TrashService) trashed(model interface{}) *gorm.DB {
+	return s.db.Unscoped().Model(model).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at desc").
+		Order("id desc")
+}
+
+func (s *TrashService) purgePost(tx *gorm.DB, id uint) ([]string, error) {
+	var post db.Post
+	if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&post, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTrashItemNotFound
+		}
+		return nil, err
+	}
+	uploads := s.uploadRefs(post.CoverURL, post.Content)
+
+	var publications []db.PostPublication
+	if err := tx.Unscoped().Select("id", "content", "cover_url").Where("post_id = ?", id).Find(&publications).Error; err != nil {
+		return nil, err
+	}
+	publicationIDs := make([]uint, 0, len(publications))
+	for _, publication := range publications {
+		publicationIDs = append(publicationIDs, publication.ID)
+		uploads = append(uploads, s.uploadRefs(publication.CoverURL, publication.Content)...)
+	}
+
+	var drafts []db.PostDraftVersion
+	if err := tx.Unscoped().Select("id", "content", "cover_url").Where("post_id = ?", id).Find(&drafts).Error; err != nil {
+		return nil, err
+	}
+	draftIDs := make([]uint, 0, len(drafts))
+	for _, draft := range drafts {
+		draftIDs = append(draftIDs, draft.ID)
+		uploads = append(uploads, s.uploadRefs(draft.CoverURL, draft.Content)...)
+	}
+
+	if len(publicationIDs) > 0 {
+		if err := tx.Exec("DELETE FROM post_publication_tags WHERE post_publication_id IN ?", publicationIDs).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Unscoped().Where("id IN ?", publicationIDs).Delete(&db.PostPublication{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(draftIDs) > 0 {
+		if err := tx.Exec("DELETE FROM post_draft_version_tags WHERE post_draft_version_id IN ?", draftIDs).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Unscoped().Where("id IN ?", draftIDs).Delete(&db.PostDraftVersion{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Exec("DELETE FROM post_tags WHERE post_id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("post_id = ?", id).Delete(&db.SeriesPost{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("post_id = ?", id).Delete(&db.PostSlugRedirect{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("post_id = ?", id).Delete(&db.PostPreviewToken{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("post_id = ?", id).Delete(&db.PostStatistic{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("post_id = ?", id).Delete(&db.PostVisit{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("source_post_id = ? OR target_post_id = ?", id, id).Delete(&db.PostLink{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("post_id = ? OR related_post_id = ?", id, id).Delete(&db.RelatedPost{}).Error; err != nil {
+		return nil, err
+	}
+	if err := syncSearchIndex(tx, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Delete(&post).Error; err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+func (s *TrashService) purgeTemplate(tx *gorm.DB, id uint) ([]string, error) {
+	var template db.PostTemplate
+	if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTrashItemNotFound
+		}
+		return nil, err
+	}
+
+	if err := tx.Unscoped().Model(&db.Post{}).
+		Where("source_template_id = ?", id).
+		Update("source_template_id", nil).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Exec("DELETE FROM post_template_tags WHERE post_template_id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Delete(&template).Error; err != nil {
+		return nil, err
+	}
+	return s.uploadRefs(template.CoverURL, template.Content), nil
+}
+
+func (s *TrashService) purgeGalleryImage(tx *gorm.DB, id uint) ([]string, error) {
+	var image db.GalleryImage
+	if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&image, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTrashItemNotFound
+		}
+		return nil, err
+	}
+	if err := tx.Unscoped().Delete(&image).Error; err != nil {
+		return nil, err
+	}
+	return s.uploadRefs(image.ImageURL), nil
+}
+
+// uploadRefs 从字段值中提取指向本站上传目录的相对路径。
+func (s *TrashService) uploadRefs(values ...string) []string {
+	prefix := s.uploadURLPrefix()
+	pattern := regexp.MustCompile(regexp.QuoteMeta(prefix) + `/[^\s"'()<>\[\]?#]+`)
+
+	var refs []string
+	for _, value := range values {
+		for _, match := range pattern.FindAllString(value, -1) {
+			rel := path.Clean(strings.TrimPrefix(match, prefix+"/"))
+			if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || strings.HasPrefix(rel, "/") {
+				continue
+			}
+			refs = append(refs, rel)
+		}
+	}
+	return refs
+}
+
+// removeUnreferencedUploads 删除不再被任何记录引用的上传文件，失败时仅记录日志。
+func (s *TrashService) removeUnreferencedUploads(refs []string) {
+	if strings.TrimSpace(s.uploadDir) == "" {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(refs))
+	for _, rel := range refs {
+		if _, ok := seen[rel]; ok {
+			continue
+		}
+		seen[rel] = struct{}{}
+
+		referenced, err := s.uploadReferenced(s.uploadURLPrefix() + "/" + rel)
+		if err != nil {
+			log.Printf("[trash] check upload reference %s failed: %v", rel, err)
+			continue
+		}
+		if referenced {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.uploadDir, filepath.FromSlash(rel))); err != nil && !os.IsNotExist(err) {
+			log.Printf("[trash] remove upload %s failed: %v", rel, err)
+		}
+	}
+}
+
+func (s *TrashService) uploadReferenced(fileURL string) (bool, error) {
+	like := "%" + escapeLikePattern(fileURL) + "%"
+	migrator := s.db.Migrator()
+	for _, ref := range uploadReferenceColumns {
+		if !migrator.HasTable(ref.Table) {
+			continue
+		}
+		var count int64
+		if err := s.db.Table(ref.Table).
+			Where(fmt.Sprintf("%s LIKE ? ESCAPE '\\'", ref.Column), like).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *TrashService) uploadURLPrefix() string {
+	prefix := strings.TrimRight(strings.TrimSpace(s.uploadURL), "/")
+	if prefix == "" {
+		prefix = "/uploads"
+	}
+	return prefix
+}
+
+func escapeLikePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+func validTrashKind(kind string) bool {
+	_, err := trashModel(kind)
+	return err == nil
+}
+
+func trashModel(kind string) (interface{}, error) {
+	switch kind {
+	case TrashKindPost:
+		return &db.Post{}, nil
+	case TrashKindTemplate:
+		return &db.PostTemplate{}, nil
+	case TrashKindGallery:
+		return &db.GalleryImage{}, nil
+	default:
+		return nil, ErrTrashKindInvalid
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+)
+
+func writeUploadFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("image"), 0o644); err != nil {
+		t.Fatalf("write upload file: %v", err)
+	}
+	return path
+}
+
+func TestTrashService_RestorePostKeepsAssociations(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	posts := NewPostService(gdb)
+	trash := NewTrashService(gdb, t.TempDir(), "/uploads")
+
+	user := db.User{Username: "trash-restore"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	tag := db.Tag{Name: "Go"}
+	if err := gdb.Create(&tag).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	post, err := posts.Create(PostInput{
+		Content:     "# 回收站文章\n正文",
+		UserID:      user.ID,
+		TagIDs:      []uint{tag.ID},
+		CoverURL:    "/uploads/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if _, err := posts.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish post: %v", err)
+	}
+
+	if err := posts.Delete(post.ID); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+	if _, err := posts.Get(post.ID); !errors.Is(err, ErrPostNotFound) {
+		t.Fatalf("expected trashed post to be hidden, got %v", err)
+	}
+	published, err := posts.ListAllPublished()
+	if err != nil {
+		t.Fatalf("list published: %v", err)
+	}
+	if len(published) != 0 {
+		t.Fatalf("expected trashed post to leave public listings, got %d", len(published))
+	}
+
+	items, err := trash.List("")
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].Kind != TrashKindPost || items[0].ID != post.ID || items[0].Title != "回收站文章" {
+		t.Fatalf("unexpected trash items: %+v", items)
+	}
+	if _, err := trash.List("unknown"); !errors.Is(err, ErrTrashKindInvalid) {
+		t.Fatalf("expected ErrTrashKindInvalid, got %v", err)
+	}
+
+	if err := trash.Restore(TrashKindPost, post.ID); err != nil {
+		t.Fatalf("restore post: %v", err)
+	}
+	if err := trash.Restore(TrashKindPost, post.ID); !errors.Is(err, ErrTrashItemNotFound) {
+		t.Fatalf("expected ErrTrashItemNotFound for live post, got %v", err)
+	}
+
+	restored, err := posts.Get(post.ID)
+	if err != nil {
+		t.Fatalf("get restored post: %v", err)
+	}
+	if len(restored.Tags) != 1 || restored.Tags[0].ID != tag.ID {
+		t.Fatalf("expected tags to be restored, got %+v", restored.Tags)
+	}
+	if _, err := posts.PublicPublication(post.ID); err != nil {
+		t.Fatalf("expected publication to be restored, got %v", err)
+	}
+}
+
+func TestTrashService_PurgeRemovesUnreferencedUploads(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	posts := NewPostService(gdb)
+	galleries := NewGalleryService(gdb)
+	uploadDir := t.TempDir()
+	trash := NewTrashService(gdb, uploadDir, "/uploads/")
+
+	coverPath := writeUploadFile(t, uploadDir, "cover.jpg")
+	inlinePath := writeUploadFile(t, uploadDir, "inline.png")
+	sharedPath := writeUploadFile(t, uploadDir, "shared.jpg")
+
+	user := db.User{Username: "trash-purge"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	post, err := posts.Create(PostInput{
+		Content:     "# 清除文章\n![配图](/uploads/inline.png)\n![共享](/uploads/shared.jpg)",
+		UserID:      user.ID,
+		CoverURL:    "https://blog.example.com/uploads/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if _, err := posts.Publish(post.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish post: %v", err)
+	}
+
+	image, err := galleries.Create(GalleryInput{ImageURL: "/uploads/shared.jpg", ImageWidth: 800, ImageHeight: 600})
+	if err != nil {
+		t.Fatalf("create gallery image: %v", err)
+	}
+
+	if err := trash.Purge(TrashKindPost, post.ID); !errors.Is(err, ErrTrashItemNotFound) {
+		t.Fatalf("expected live post purge to be rejected, got %v", err)
+	}
+
+	if err := posts.Delete(post.ID); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+	if err := trash.Purge(TrashKindPost, post.ID); err != nil {
+		t.Fatalf("purge post: %v", err)
+	}
+
+	var count int64
+	gdb.Unscoped().Model(&db.Post{}).Where("id = ?", post.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected post row to be purged, found %d", count)
+	}
+	gdb.Unscoped().Model(&db.PostPublication{}).Where("post_id = ?", post.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected publications to be purged, found %d", count)
+	}
+	gdb.Unscoped().Model(&db.PostDraftVersion{}).Where("post_id = ?", post.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected draft versions to be purged, found %d", count)
+	}
+
+	for _, path := range []string{coverPath, inlinePath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got %v", filepath.Base(path), err)
+		}
+	}
+	if _, err := os.Stat(sharedPath); err != nil {
+		t.Fatalf("expected shared upload to be kept, got %v", err)
+	}
+
+	// 回收站中的作品仍视为引用，清除作品后共享文件才会被删除
+	if err := galleries.Delete(image.ID); err != nil {
+		t.Fatalf("delete gallery image: %v", err)
+	}
+	if _, err := os.Stat(sharedPath); err != nil {
+		t.Fatalf("expected trashed reference to keep upload, got %v", err)
+	}
+	if err := trash.Purge(TrashKindGallery, image.ID); err != nil {
+		t.Fatalf("purge gallery image: %v", err)
+	}
+	if _, err := os.Stat(sharedPath); !os.IsNotExist(err) {
+		t.Fatalf("expected shared upload to be removed, got %v", err)
+	}
+}
+
+func TestTrashService_PurgeExpired(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	templates := NewTemplateService(gdb)
+	galleries := NewGalleryService(gdb)
+	trash := NewTrashService(gdb, t.TempDir(), "/uploads")
+
+	oldTemplate, err := templates.Create(PostTemplateInput{Name: "旧模板", Content: "# {{title}}"})
+	if err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	image, err := galleries.Create(GalleryInput{ImageURL: "/uploads/photo.jpg", ImageWidth: 800, ImageHeight: 600})
+	if err != nil {
+		t.Fatalf("create gallery image: %v", err)
+	}
+
+	if err := templates.Delete(oldTemplate.ID); err != nil {
+		t.Fatalf("delete template: %v", err)
+	}
+	if err := galleries.Delete(image.ID); err != nil {
+		t.Fatalf("delete gallery image: %v", err)
+	}
+
+	now := time.Now()
+	if err := gdb.Unscoped().Model(&db.PostTemplate{}).Where("id = ?", oldTemplate.ID).
+		Update("deleted_at", now.Add(-40*24*time.Hour)).Error; err != nil {
+		t.Fatalf("age template: %v", err)
+	}
+
+	purger := NewTrashPurger(trash, 30*24*time.Hour, 0)
+	purger.now = func() time.Time { return now }
+	if purged := purger.RunOnce(); purged != 1 {
+		t.Fatalf("expected one expired item to be purged, got %d", purged)
+	}
+
+	items, err := trash.List("")
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].Kind != TrashKindGallery || items[0].ID != image.ID {
+		t.Fatalf("expected only the recent gallery image to remain, got %+v", items)
+	}
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/commitlog/internal/db"
+	"gorm.io/gorm"
+)
+
+// wikiLinkPattern 匹配 [[目标]] 与 [[目标|显示文字]]，目标中不允许出现方括号与换行。
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]\n|]+)(?:\|([^\[\]\n]*))?\]\]`)
+
+// inlineCodePattern 匹配行内代码，其中的 [[...]] 不视为链接。
+var inlineCodePattern = regexp.MustCompile("`+[^`\n]*`+")
+
+// wikiLinkTitlePrefixBytes 是解析标题时读取的正文长度，标题只取自首行。
+const wikiLinkTitlePrefixBytes = 1024
+
+// WikiLink 是正文中的一个内部链接。
+type WikiLink struct {
+	// Target 是标题或 post:ID 形式的引用。
+	Target string
+	// Label 是显示文字，未指定时与 Target 相同。
+	Label string
+}
+
+// ParseWikiLink 解析 [[ 与 ]] 之间的内容，返回 false 表示不是合法的内部链接。
+func ParseWikiLink(inner string) (WikiLink, bool) {
+	target, label, _ := strings.Cut(inner, "|")
+	target = strings.TrimSpace(target)
+	label = strings.TrimSpace(label)
+	if target == "" || strings.ContainsAny(target, "[]\n") {
+		return WikiLink{}, false
+	}
+	if label == "" {
+		label = target
+	}
+	return WikiLink{Target: target, Label: label}, true
+}
+
+// ParseWikiLinks 提取正文中的内部链接，忽略代码块与行内代码中的内容。
+func ParseWikiLinks(content string) []WikiLink {
+	if !strings.Contains(content, "[[") {
+		return nil
+	}
+	var links []WikiLink
+	fence := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		line = inlineCodePattern.ReplaceAllString(line, "")
+		for _, match := range wikiLinkPattern.FindAllStringSubmatch(line, -1) {
+			inner := match[1]
+			if match[2] != "" {
+				inner += "|" + match[2]
+			}
+			if link, ok := ParseWikiLink(inner); ok {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// WikiLinkTarget 是内部链接解析到的已发布文章。
+type WikiLinkTarget struct {
+	PostID uint
+	Title  string
+	Path   string
+}
+
+// WikiLinkIndex 按标题与文章 ID 查找已发布文章。
+type WikiLinkIndex struct {
+	byTitle map[string]WikiLinkTarget
+	byID    map[uint]WikiLinkTarget
+}
+
+// wikiLinkRow 是构建索引时读取的文章信息。
+type wikiLinkRow struct {
+	ID      uint
+	Slug    string
+	Content string
+}
+
+func newWikiLinkIndex(rows []wikiLinkRow) *WikiLinkIndex {
+	index := &WikiLinkIndex{
+		byTitle: make(map[string]WikiLinkTarget, len(rows)),
+		byID:    make(map[uint]WikiLinkTarget, len(rows)),
+	}
+	for _, row := range rows {
+		title := db.DeriveTitleFromContent(row.Content)
+		target := WikiLinkTarget{PostID: row.ID, Title: title, Path: db.PostPath(row.Slug, row.ID)}
+		index.byID[row.ID] = target
+		// 标题重复时保留最近发布的文章，rows 已按发布时间倒序排列
+		if key := normalizeWikiLinkTitle(title); key != "" {
+			if _, exists := index.byTitle[key]; !exists {
+				index.byTitle[key] = target
+			}
+		}
+	}
+	return index
+}
+
+// Resolve 将 post:ID 或文章标题解析为已发布文章，标题匹配忽略大小写与多余空白。
+func (i *WikiLinkIndex) Resolve(target string) (WikiLinkTarget, bool) {
+	if i == nil {
+		return WikiLinkTarget{}, false
+	}
+	target = strings.TrimSpace(target)
+	if prefix, rawID, ok := strings.Cut(target, ":"); ok && strings.EqualFold(strings.TrimSpace(prefix), "post") {
+		id, err := strconv.ParseUint(strings.TrimSpace(rawID), 10, 64)
+		if err != nil {
+			return WikiLinkTarget{}, false
+		}
+		resolved, found := i.byID[uint(id)]
+		return resolved, found
+	}
+	resolved, found := i.byTitle[normalizeWikiLinkTitle(target)]
+	return resolved, found
+}
+
+// normalizeWikiLinkTitle 按推导标题的规则去除标题标记与强调符号，再忽略大小写与多余空白。
+func normalizeWikiLinkTitle(title string) string {
+	title = db.DeriveTitleFromContent(strings.TrimSpace(title))
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// WikiLinkIndex 读取所有已发布文章的标题，用于在渲染时解析内部链接。
+// 只读取正文开头用于推导标题，标题以最近一次发布快照为准。
+func (s *PostService) WikiLinkIndex() (*WikiLinkIndex, error) {
+	rows, err := loadWikiLinkRows(s.db, wikiLinkTitlePrefixBytes)
+	if err != nil {
+		return nil, err
+	}
+	return newWikiLinkIndex(rows), nil
+}
+
+// loadWikiLinkRows 读取已发布文章，prefixBytes 大于 0 时只读取正文开头。
+func loadWikiLinkRows(tx *gorm.DB, prefixBytes int) ([]wikiLinkRow, error) {
+	var rows []wikiLinkRow
+	err := wikiLinkRowsQuery(tx, prefixBytes).Scan(&rows).Error
+	return rows, err
+}
+
+func wikiLinkRowsQuery(tx *gorm.DB, prefixBytes int) *gorm.DB {
+	contentColumn := "post_publications.content AS content"
+	if prefixBytes > 0 {
+		contentColumn = "substr(post_publications.content, 1, " + strconv.Itoa(prefixBytes) + ") AS content"
+	}
+	return tx.Table("posts").
+		Select("posts.id, posts.slug, "+contentColumn).
+		Joins("JOIN post_publications ON post_publications.id = posts.latest_publication_id").
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published").
+		Order("post_publications.published_at desc, posts.id desc")
+}
+
+// refreshPostLinks 在文章发布、下线、删除或恢复后增量更新链接索引：重建该文章自身的出链，
+// 并重新解析其他文章中以 post:ID 或该文章新旧标题为目标的链接，它们可能因此指向别的文章或失效。
+// previousTitles 为本次变更前的标题，标题未变化时可以省略。
+func refreshPostLinks(tx *gorm.DB, postID uint, previousTitles ...string) error {
+	titleRows, err := loadWikiLinkRows(tx, wikiLinkTitlePrefixBytes)
+	if err != nil {
+		return err
+	}
+	index := newWikiLinkIndex(titleRows)
+
+	keys := make(map[string]bool, len(previousTitles)+1)
+	for _, title := range previousTitles {
+		if key := normalizeWikiLinkTitle(title); key != "" {
+			keys[key] = true
+		}
+	}
+	var current []string
+	if err := tx.Table("posts").
+		Joins("JOIN post_publications ON post_publications.id = posts.latest_publication_id").
+		Where("posts.id = ?", postID).
+		Pluck("substr(post_publications.content, 1, "+strconv.Itoa(wikiLinkTitlePrefixBytes)+")", &current).Error; err != nil {
+		return err
+	}
+	for _, content := range current {
+		if key := normalizeWikiLinkTitle(db.DeriveTitleFromContent(content)); key != "" {
+			keys[key] = true
+		}
+	}
+
+	// 只有包含 [[ 的文章才可能受影响，无需解析全部正文
+	var sources []wikiLinkRow
+	if err := wikiLinkRowsQuery(tx, 0).
+		Where("post_publications.content LIKE ?", "%[[%").
+		Scan(&sources).Error; err != nil {
+		return err
+	}
+
+	affected := []uint{postID}
+	var links []db.PostLink
+	for _, row := range sources {
+		parsed := ParseWikiLinks(row.Content)
+		if row.ID != postID && !wikiLinksReference(parsed, postID, keys) {
+			continue
+		}
+		if row.ID != postID {
+			affected = append(affected, row.ID)
+		}
+		seen := make(map[uint]bool)
+		for _, link := range parsed {
+			target, ok := index.Resolve(link.Target)
+			if !ok || target.PostID == row.ID || seen[target.PostID] {
+				continue
+			}
+			seen[target.PostID] = true
+			links = append(links, db.PostLink{SourcePostID: row.ID, TargetPostID: target.PostID})
+		}
+	}
+
+	if err := tx.Where("source_post_id IN ?", affected).Delete(&db.PostLink{}).Error; err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&links, 200).Error
+}
+
+// wikiLinksReference 判断链接中是否有以 post:ID 或指定标题为目标的链接。
+func wikiLinksReference(links []WikiLink, postID uint, titleKeys map[string]bool) bool {
+	idTarget := "post:" + strconv.FormatUint(uint64(postID), 10)
+	for _, link := range links {
+		target := strings.ToLower(strings.Join(strings.Fields(link.Target), ""))
+		if target == idTarget || titleKeys[normalizeWikiLinkTitle(link.Target)] {
+			return true
+		}
+	}
+	return false
+}
+
+// ListBacklinks 返回引用了指定文章、且会出现在前台列表中的已发布文章。
+func (s *PostService) ListBacklinks(postID uint) ([]db.PostPublication, error) {
+	query := s.db.Model(&db.PostPublication{}).
+		Select(publicationWithSlugColumns).
+		Joins("JOIN posts ON posts.latest_publication_id = post_publications.id").
+		Joins("JOIN post_links ON post_links.source_post_id = posts.id").
+		Where("post_links.target_post_id = ?", postID).
+		Where("posts.status = ? AND posts.deleted_at IS NULL", "published")
+	query = s.applyDiscoverablePublicationFilter(query, "post_publications")
+
+	var publications []db.PostPublication
+	if err := query.
+		Order("post_publications.published_at desc, post_publications.id desc").
+		Find(&publications).Error; err != nil {
+		return nil, err
+	}
+	for i := range publications {
+		publications[i].PopulateDerivedFields()
+	}
+	return publications, nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/commitlog/internal/db"
+)
+
+func TestParseWikiLinks_SkipsCode(t *testing.T) {
+	content := "见 [[Go 并发|并发笔记]] 与 [[post:42]]。\n" +
+		"`[[行内代码]]` 不算链接\n" +
+		"```\n[[代码块]]\n```\n" +
+		"    [[缩进代码]]\n" +
+		"[[ ]] 与 [[a[b]]] 不合法"
+
+	got := ParseWikiLinks(content)
+	want := []WikiLink{
+		{Target: "Go 并发", Label: "并发笔记"},
+		{Target: "post:42", Label: "post:42"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestPostService_PublishRebuildsBacklinks(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "wiki-tester"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	publish := func(content, visibility string) *db.Post {
+		t.Helper()
+		post, err := svc.Create(PostInput{
+			Content:     content,
+			UserID:      user.ID,
+			Visibility:  visibility,
+			CoverURL:    "https://example.com/cover.jpg",
+			CoverWidth:  1200,
+			CoverHeight: 800,
+		})
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+			t.Fatalf("publish post: %v", err)
+		}
+		published, err := svc.Get(post.ID)
+		if err != nil {
+			t.Fatalf("reload post: %v", err)
+		}
+		return published
+	}
+
+	// 引用的文章尚未发布，此时无法解析
+	source := publish("# 读书笔记\n参考 [[**Go** 并发模式]] 一文。", db.PostVisibilityPublic)
+	if backlinks, err := svc.ListBacklinks(source.ID); err != nil || len(backlinks) != 0 {
+		t.Fatalf("expected no backlinks yet, got %v (%v)", backlinks, err)
+	}
+
+	target := publish("# **Go** 并发模式\n正文", db.PostVisibilityPublic)
+	publish("# 按编号引用\n见 [[post:"+fmt.Sprint(target.ID)+"]]", db.PostVisibilityPublic)
+	publish("# 未列出的文章\n[[go 并发模式]]", db.PostVisibilityUnlisted)
+
+	index, err := svc.WikiLinkIndex()
+	if err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	if resolved, ok := index.Resolve("  go   并发模式 "); !ok || resolved.PostID != target.ID || resolved.Path != target.PublicPath() {
+		t.Fatalf("expected title lookup to resolve target, got %+v %v", resolved, ok)
+	}
+	if _, ok := index.Resolve("不存在的文章"); ok {
+		t.Fatal("expected unknown title to stay unresolved")
+	}
+
+	backlinks, err := svc.ListBacklinks(target.ID)
+	if err != nil {
+		t.Fatalf("list backlinks: %v", err)
+	}
+	var titles []string
+	for _, publication := range backlinks {
+		titles = append(titles, publication.Title)
+	}
+	// 目标文章发布时重新解析引用其标题的链接，先前无法解析的链接也会生效；未列出的文章不出现在反向链接中
+	if want := []string{"按编号引用", "读书笔记"}; len(titles) != 2 || !containsAll(titles, want) {
+		t.Fatalf("expected backlinks %v, got %v", want, titles)
+	}
+}
+
+func TestPostService_RefreshesLinksWhenTitleChangesOrPostIsWithdrawn(t *testing.T) {
+	gdb := setupPostServiceTestDB(t)
+	svc := NewPostService(gdb)
+
+	user := db.User{Username: "wiki-refresh"}
+	if err := gdb.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	create := func(content string) *db.Post {
+		t.Helper()
+		post, err := svc.Create(PostInput{
+			Content:     content,
+			UserID:      user.ID,
+			CoverURL:    "https://example.com/cover.jpg",
+			CoverWidth:  1200,
+			CoverHeight: 800,
+		})
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		if _, err := svc.Publish(post.ID, user.ID, nil); err != nil {
+			t.Fatalf("publish post: %v", err)
+		}
+		return post
+	}
+	backlinkTitles := func(postID uint) []string {
+		t.Helper()
+		backlinks, err := svc.ListBacklinks(postID)
+		if err != nil {
+			t.Fatalf("list backlinks: %v", err)
+		}
+		titles := make([]string, 0, len(backlinks))
+		for _, publication := range backlinks {
+			titles = append(titles, publication.Title)
+		}
+		return titles
+	}
+
+	target := create("# 旧标题\n正文")
+	create("# 引用旧标题\n见 [[旧标题]]")
+	create("# 引用新标题\n见 [[新标题]]")
+	if titles := backlinkTitles(target.ID); len(titles) != 1 || titles[0] != "引用旧标题" {
+		t.Fatalf("expected link to the old title, got %v", titles)
+	}
+
+	// 改标题后重新发布：引用旧标题的链接失效，此前无法解析的新标题链接生效
+	if _, err := svc.Update(target.ID, PostInput{
+		Content:     "# 新标题\n正文",
+		UserID:      user.ID,
+		CoverURL:    "https://example.com/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	}); err != nil {
+		t.Fatalf("update post: %v", err)
+	}
+	if _, err := svc.Publish(target.ID, user.ID, nil); err != nil {
+		t.Fatalf("republish post: %v", err)
+	}
+	if titles := backlinkTitles(target.ID); len(titles) != 1 || titles[0] != "引用新标题" {
+		t.Fatalf("expected link to follow the new title, got %v", titles)
+	}
+
+	// 回到旧版本时按快照标题重新解析
+	if _, err := svc.RepublishVersion(target.ID, 1, user.ID, false); err != nil {
+		t.Fatalf("republish version: %v", err)
+	}
+	if titles := backlinkTitles(target.ID); len(titles) != 1 || titles[0] != "引用旧标题" {
+		t.Fatalf("expected link to follow the republished title, got %v", titles)
+	}
+
+	if _, err := svc.Withdraw(target.ID); err != nil {
+		t.Fatalf("withdraw post: %v", err)
+	}
+	var count int64
+	if err := gdb.Model(&db.PostLink{}).Where("target_post_id = ?", target.ID).Count(&count).Error; err != nil {
+		t.Fatalf("count links: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected links to a withdrawn post to be removed, got %d", count)
+	}
+}
+
+func containsAll(values, want []string) bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	for _, value := range want {
+		if !set[value] {
+			return false
+		}
+	}
+	return true
+}
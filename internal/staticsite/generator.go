@@ -0,0 +1,412 @@
+// Package staticsite 将前台站点预渲染为可部署到任意静态托管服务的 HTML 目录。
+package staticsite
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/commitlog/internal/service"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBaseURLRequired   = errors.New("site base url is required")
+	ErrOutputDirRequired = errors.New("output directory is required")
+)
+
+// notFoundProbePath 用于渲染 404.html，任何不存在的路径都会命中 NoRoute。
+const notFoundProbePath = "/__static_not_found__"
+
+var (
+	// linkAttrPattern 匹配需要处理的站内链接属性，值以单个斜杠开头
+	linkAttrPattern = regexp.MustCompile(`(\s(?:href|src|action|hx-get)=")(/[^"/][^"]*|/)"`)
+	// crawlAttrNames 中的属性指向页面，会继续渲染；src 等资源由目录复制提供
+	crawlAttrNames = map[string]bool{"href": true, "hx-get": true}
+)
+
+// Options 描述静态站点的输出位置与资源来源。
+type Options struct {
+	// OutputDir 为生成目录，已有同名文件会被覆盖
+	OutputDir string
+	// BaseURL 为静态站点的对外地址，绝对链接据此生成，包含子路径时站内链接会加上前缀
+	BaseURL string
+	// StaticDir 为 /static 对应的本地目录
+	StaticDir string
+	// UploadDir 与 UploadURL 描述上传文件所在目录及其访问前缀
+	UploadDir string
+	UploadURL string
+}
+
+// Result 汇总一次生成的产物数量。
+type Result struct {
+	Pages  int
+	Assets int
+}
+
+// Generator 通过进程内请求复用前台处理器与模板渲染页面，并改写为静态路径。
+type Generator struct {
+	engine   http.Handler
+	posts    *service.PostService
+	series   *service.SeriesService
+	opts     Options
+	basePath string
+}
+
+// NewGenerator 创建静态站点生成器，engine 通常来自 router.SetupStaticRouter。
+func NewGenerator(gdb *gorm.DB, engine http.Handler, opts Options) *Generator {
+	return &Generator{
+		engine: engine,
+		posts:  service.NewPostService(gdb),
+		series: service.NewSeriesService(gdb),
+		opts:   opts,
+	}
+}
+
+// Generate 渲染首页分页、标签筛选列表、文章、专栏、关于、作品集、RSS 与 Sitemap，
+// 并复制静态资源与上传文件。
+func (g *Generator) Generate() (*Result, error) {
+	if strings.TrimSpace(g.opts.OutputDir) == "" {
+		return nil, ErrOutputDirRequired
+	}
+	rawBase := strings.TrimSpace(g.opts.BaseURL)
+	if rawBase != "" && !strings.Contains(rawBase, "://") {
+		rawBase = "https://" + rawBase
+	}
+	base, err := url.Parse(rawBase)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, ErrBaseURLRequired
+	}
+	g.basePath = strings.TrimRight(base.Path, "/")
+
+	if err := os.MkdirAll(g.opts.OutputDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	seeds, err := g.seedPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	queued := make(map[string]struct{}, len(seeds))
+	queue := make([]string, 0, len(seeds))
+	enqueue := func(target string) {
+		if _, exists := queued[target]; exists {
+			return
+		}
+		queued[target] = struct{}{}
+		queue = append(queue, target)
+	}
+	for _, seed := range seeds {
+		enqueue(seed)
+	}
+
+	for len(queue) > 0 {
+		target := queue[0]
+		queue = queue[1:]
+
+		written, links, err := g.renderPage(target)
+		if err != nil {
+			return nil, err
+		}
+		if written {
+			result.Pages++
+		}
+		for _, link := range links {
+			enqueue(link)
+		}
+	}
+
+	if err := g.renderNotFound(); err != nil {
+		return nil, err
+	}
+
+	copied, err := g.copyAssets()
+	if err != nil {
+		return nil, err
+	}
+	result.Assets = copied
+	return result, nil
+}
+
+// seedPaths 返回渲染起点，其余页面通过页面中的链接发现。
+func (g *Generator) seedPaths() ([]string, error) {
+	seeds := []string{"/", "/tags", "/about", "/gallery", "/rss.xml", "/sitemap.xml", "/robots.txt"}
+
+	// 不公开列出的文章没有入口链接，需要单独加入
+	postPaths, err := g.posts.ListPublishedPaths()
+	if err != nil {
+		return nil, err
+	}
+	seeds = append(seeds, postPaths...)
+
+	seriesList, err := g.series.ListPublished()
+	if err != nil {
+		return nil, err
+	}
+	for _, series := range seriesList {
+		seeds = append(seeds, series.PublicPath())
+	}
+	return seeds, nil
+}
+
+// renderPage 渲染单个地址并写入对应文件，返回页面中可继续渲染的站内链接。
+func (g *Generator) renderPage(target string) (bool, []string, error) {
+	file, _, ok := staticLocation(target)
+	if !ok {
+		return false, nil, nil
+	}
+
+	status, contentType, body := g.fetch(target)
+	if status != http.StatusOK {
+		// 重定向的旧地址、未开放的作品集等页面不生成文件
+		return false, nil, nil
+	}
+
+	var links []string
+	if strings.HasPrefix(contentType, "text/html") {
+		body, links = g.rewriteHTML(body)
+	}
+	if err := g.writeFile(file, body); err != nil {
+		return false, nil, fmt.Errorf("write %s: %w", file, err)
+	}
+	return true, links, nil
+}
+
+func (g *Generator) renderNotFound() error {
+	_, _, body := g.fetch(notFoundProbePath)
+	body, _ = g.rewriteHTML(body)
+	return g.writeFile("404.html", body)
+}
+
+func (g *Generator) fetch(target string) (int, string, string) {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	g.engine.ServeHTTP(rec, req)
+	return rec.Code, rec.Header().Get("Content-Type"), rec.Body.String()
+}
+
+// rewriteHTML 将带查询参数的站内地址改写为静态路径，并为站内链接加上子路径前缀。
+func (g *Generator) rewriteHTML(body string) (string, []string) {
+	var links []string
+	rewritten := linkAttrPattern.ReplaceAllStringFunc(body, func(match string) string {
+		parts := linkAttrPattern.FindStringSubmatch(match)
+		prefix, raw := parts[1], html.UnescapeString(parts[2])
+		attr := strings.TrimSuffix(strings.TrimSpace(prefix), `="`)
+
+		target := raw
+		if _, href, ok := staticLocation(raw); ok {
+			if crawlAttrNames[attr] {
+				links = append(links, crawlTargets(raw)...)
+			}
+			target = href
+		}
+		return prefix + html.EscapeString(g.basePath+target) + `"`
+	})
+	return rewritten, links
+}
+
+func (g *Generator) writeFile(name, content string) error {
+	target := filepath.Join(g.opts.OutputDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, []byte(content), 0o644)
+}
+
+// copyAssets 复制 /static 目录与上传目录，返回复制的文件数。
+func (g *Generator) copyAssets() (int, error) {
+	total := 0
+	if dir := strings.TrimSpace(g.opts.StaticDir); dir != "" {
+		count, err := copyTree(dir, filepath.Join(g.opts.OutputDir, "static"))
+		if err != nil {
+			return total, fmt.Errorf("copy static files: %w", err)
+		}
+		total += count
+	}
+	if dir := strings.TrimSpace(g.opts.UploadDir); dir != "" {
+		uploadPath := strings.Trim(strings.TrimSpace(g.opts.UploadURL), "/")
+		if uploadPath == "" {
+			uploadPath = "uploads"
+		}
+		count, err := copyTree(dir, filepath.Join(g.opts.OutputDir, filepath.FromSlash(uploadPath)))
+		if err != nil {
+			return total, fmt.Errorf("copy uploads: %w", err)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func copyTree(src, dst string) (int, error) {
+	if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	count := 0
+	err := filepath.WalkDir(src, func(current string, entry os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(src, current)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if err := copyFile(current, target); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// staticLocation 将前台地址映射为生成文件名与静态站点中的访问地址。
+// 查询参数形式的分页与标签筛选会转换为目录形式，例如 /?tags=Go&page=2 对应 /tags/Go/page/2/。
+// 无法静态化的地址（如搜索）返回 ok=false。
+func staticLocation(raw string) (file, href string, ok bool) {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host != "" || parsed.Scheme != "" {
+		return "", "", false
+	}
+	query := parsed.Query()
+	page := 1
+	if value := query.Get("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return "", "", false
+		}
+	}
+
+	switch parsed.Path {
+	case "/", "/posts/more":
+		if query.Get("search") != "" {
+			return "", "", false
+		}
+		tags := query["tags"]
+		if len(tags) > 1 {
+			return "", "", false
+		}
+		dir := "/"
+		if len(tags) == 1 {
+			name := tagDirName(tags[0])
+			if name == "" {
+				return "", "", false
+			}
+			dir = "/tags/" + url.PathEscape(name) + "/"
+			file = "tags/" + name + "/"
+		}
+		if page > 1 {
+			dir += fmt.Sprintf("page/%d/", page)
+			file += fmt.Sprintf("page/%d/", page)
+		}
+		if parsed.Path == "/posts/more" {
+			if page < 2 {
+				return "", "", false
+			}
+			return file + "more.html", dir + "more.html", true
+		}
+		return file + "index.html", dir, true
+	case "/gallery", "/gallery/more":
+		if parsed.Path == "/gallery/more" {
+			if page < 2 {
+				return "", "", false
+			}
+			return fmt.Sprintf("gallery/page/%d/more.html", page), fmt.Sprintf("/gallery/page/%d/more.html", page), true
+		}
+		if page > 1 {
+			return fmt.Sprintf("gallery/page/%d/index.html", page), fmt.Sprintf("/gallery/page/%d/", page), true
+		}
+		return "gallery/index.html", "/gallery", true
+	case "/rss.xml", "/sitemap.xml", "/robots.txt":
+		return strings.TrimPrefix(parsed.Path, "/"), parsed.Path, true
+	case "/tags", "/about":
+		if len(query) > 0 {
+			return "", "", false
+		}
+		return strings.TrimPrefix(parsed.Path, "/") + "/index.html", parsed.Path, true
+	}
+
+	// 文章与专栏详情页保持原地址，由静态托管的目录索引提供
+	for _, prefix := range []string{"/posts/", "/series/"} {
+		if !strings.HasPrefix(parsed.Path, prefix) || len(query) > 0 {
+			continue
+		}
+		slug := strings.TrimPrefix(parsed.Path, prefix)
+		if slug == "" || strings.Contains(slug, "/") || slug == "." || slug == ".." {
+			return "", "", false
+		}
+		return path.Join(strings.Trim(prefix, "/"), slug, "index.html"), parsed.Path, true
+	}
+	return "", "", false
+}
+
+// tagDirName 生成标签筛选页的目录名，去除路径分隔符避免越出目录。
+func tagDirName(tag string) string {
+	name := strings.TrimSpace(tag)
+	name = strings.NewReplacer("/", "-", `\`, "-").Replace(name)
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// crawlTargets 返回需要渲染的地址；加载更多的片段同时生成对应的完整分页页面。
+func crawlTargets(raw string) []string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	query := parsed.Query().Encode()
+	withQuery := func(p string) string {
+		if query == "" {
+			return p
+		}
+		return p + "?" + query
+	}
+
+	targets := []string{withQuery(parsed.Path)}
+	switch parsed.Path {
+	case "/posts/more":
+		targets = append(targets, withQuery("/"))
+	case "/gallery/more":
+		targets = append(targets, withQuery("/gallery"))
+	}
+	return targets
+}
@@ -0,0 +1,196 @@
+package staticsite
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/router"
+	"github.com/commitlog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func readOutput(t *testing.T, dir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatalf("expected %s to be generated: %v", name, err)
+	}
+	return string(content)
+}
+
+func TestGeneratorRendersPublicSite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := db.Init(filepath.Join(t.TempDir(), "static.db")); err != nil {
+		t.Fatalf("init database: %v", err)
+	}
+
+	user := db.User{Username: "static-author"}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	tag := db.Tag{Name: "Go"}
+	if err := db.DB.Create(&tag).Error; err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	posts := service.NewPostService(db.DB)
+	publishedAt := time.Now().Add(-time.Hour)
+	for i := 1; i <= 8; i++ {
+		post, err := posts.Create(service.PostInput{
+			Content:     fmt.Sprintf("# 静态文章 %d\n正文 %d", i, i),
+			Slug:        fmt.Sprintf("static-%d", i),
+			UserID:      user.ID,
+			TagIDs:      []uint{tag.ID},
+			CoverURL:    "/uploads/cover.jpg",
+			CoverWidth:  1200,
+			CoverHeight: 800,
+		})
+		if err != nil {
+			t.Fatalf("create post %d: %v", i, err)
+		}
+		at := publishedAt.Add(time.Duration(i) * time.Minute)
+		if _, err := posts.Publish(post.ID, user.ID, &at); err != nil {
+			t.Fatalf("publish post %d: %v", i, err)
+		}
+	}
+	unlisted, err := posts.Create(service.PostInput{
+		Content:     "# 隐藏文章\n只有链接可见",
+		Slug:        "hidden-post",
+		Visibility:  db.PostVisibilityUnlisted,
+		UserID:      user.ID,
+		CoverURL:    "/uploads/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create unlisted post: %v", err)
+	}
+	if _, err := posts.Publish(unlisted.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish unlisted post: %v", err)
+	}
+
+	protected, err := posts.Create(service.PostInput{
+		Content:     "# 加密文章\n仅限持有密码的读者",
+		Slug:        "secret-post",
+		Visibility:  db.PostVisibilityProtected,
+		Password:    "s3cret",
+		UserID:      user.ID,
+		CoverURL:    "/uploads/cover.jpg",
+		CoverWidth:  1200,
+		CoverHeight: 800,
+	})
+	if err != nil {
+		t.Fatalf("create protected post: %v", err)
+	}
+	if _, err := posts.Publish(protected.ID, user.ID, nil); err != nil {
+		t.Fatalf("publish protected post: %v", err)
+	}
+
+	staticDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(staticDir, "app.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatalf("write static file: %v", err)
+	}
+	uploadDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(uploadDir, "cover.jpg"), []byte("image"), 0o644); err != nil {
+		t.Fatalf("write upload: %v", err)
+	}
+
+	outDir := t.TempDir()
+	engine := router.SetupStaticRouter(uploadDir, "/uploads", "https://static.example.com/blog")
+	result, err := NewGenerator(db.DB, engine, Options{
+		OutputDir: outDir,
+		BaseURL:   "https://static.example.com/blog/",
+		StaticDir: staticDir,
+		UploadDir: uploadDir,
+		UploadURL: "/uploads",
+	}).Generate()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if result.Pages == 0 || result.Assets != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	home := readOutput(t, outDir, "index.html")
+	if !strings.Contains(home, `hx-get="/blog/page/2/more.html"`) {
+		t.Fatalf("expected load-more link to point at static fragment, got:\n%s", home)
+	}
+	if !strings.Contains(home, `href="/blog/posts/static-8"`) {
+		t.Fatal("expected post links to be prefixed with base path")
+	}
+	if strings.Contains(home, `href="/static/`) {
+		t.Fatal("expected static asset links to be prefixed with base path")
+	}
+
+	readOutput(t, outDir, "page/2/index.html")
+	readOutput(t, outDir, "page/2/more.html")
+	readOutput(t, outDir, "tags/index.html")
+	tagPage := readOutput(t, outDir, "tags/Go/index.html")
+	if !strings.Contains(tagPage, "静态文章 8") {
+		t.Fatal("expected tag listing to include tagged posts")
+	}
+	if !strings.Contains(readOutput(t, outDir, "tags/index.html"), `href="/blog/tags/Go/"`) {
+		t.Fatal("expected tag archive to link to static tag listing")
+	}
+
+	detail := readOutput(t, outDir, "posts/static-1/index.html")
+	if !strings.Contains(detail, "https://static.example.com/blog/posts/static-1") {
+		t.Fatal("expected canonical url to use the site base url")
+	}
+	if strings.Contains(detail, "次浏览") {
+		t.Fatal("expected static pages to omit view counters")
+	}
+	readOutput(t, outDir, "posts/hidden-post/index.html")
+	locked := readOutput(t, outDir, "posts/secret-post/index.html")
+	if strings.Contains(locked, `name="password"`) || strings.Contains(locked, "仅限持有密码的读者") {
+		t.Fatalf("expected protected post to render without unlock form or body, got:\n%s", locked)
+	}
+	if !strings.Contains(locked, "静态站点中无法阅读") {
+		t.Fatal("expected protected post to explain it is unavailable in the static export")
+	}
+
+	if rss := readOutput(t, outDir, "rss.xml"); !strings.Contains(rss, "https://static.example.com/blog/posts/static-8") {
+		t.Fatal("expected rss to use absolute base url")
+	}
+	readOutput(t, outDir, "sitemap.xml")
+	readOutput(t, outDir, "404.html")
+	readOutput(t, outDir, "static/app.css")
+	readOutput(t, outDir, "uploads/cover.jpg")
+
+	var stats int64
+	db.DB.Model(&db.PostStatistic{}).Count(&stats)
+	if stats != 0 {
+		t.Fatalf("expected static build not to record views, found %d statistics", stats)
+	}
+}
+
+func TestStaticLocation(t *testing.T) {
+	tests := []struct {
+		raw  string
+		file string
+		href string
+		ok   bool
+	}{
+		{"/", "index.html", "/", true},
+		{"/?page=3", "page/3/index.html", "/page/3/", true},
+		{"/?tags=Go", "tags/Go/index.html", "/tags/Go/", true},
+		{"/posts/more?page=2&tags=%E7%94%9F%E6%B4%BB", "tags/生活/page/2/more.html", "/tags/%E7%94%9F%E6%B4%BB/page/2/more.html", true},
+		{"/?search=go", "", "", false},
+		{"/?tags=a&tags=b", "", "", false},
+		{"/posts/hello", "posts/hello/index.html", "/posts/hello", true},
+		{"/posts/hello/unlock", "", "", false},
+		{"/gallery/more?page=2", "gallery/page/2/more.html", "/gallery/page/2/more.html", true},
+		{"/admin/login", "", "", false},
+	}
+	for _, tt := range tests {
+		file, href, ok := staticLocation(tt.raw)
+		if ok != tt.ok || file != tt.file || href != tt.href {
+			t.Errorf("staticLocation(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.raw, file, href, ok, tt.file, tt.href, tt.ok)
+		}
+	}
+}
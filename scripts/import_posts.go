@@ -0,0 +1,92 @@
+//go:build tools
+// +build tools
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/commitlog/internal/config"
+	"github.com/commitlog/internal/db"
+	"github.com/commitlog/internal/service"
+)
+
+// 从 Hugo/Hexo/Jekyll 站点目录或 zip 包导入文章
+// 用法: go run -tags tools scripts/import_posts.go -user admin <站点目录|站点.zip>
+func main() {
+	username := flag.String("user", "", "文章作者用户名，默认使用第一个用户")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "用法: go run -tags tools scripts/import_posts.go [-user 用户名] <站点目录|站点.zip>")
+		os.Exit(2)
+	}
+	source := flag.Arg(0)
+
+	// 初始化数据库
+	cfg := config.Load()
+	if err := db.Init(cfg.DatabasePath); err != nil {
+		log.Fatal("数据库初始化失败:", err)
+	}
+
+	var user db.User
+	query := db.DB.Order("id ASC")
+	if strings.TrimSpace(*username) != "" {
+		query = query.Where("username = ?", strings.TrimSpace(*username))
+	}
+	if err := query.First(&user).Error; err != nil {
+		log.Fatal("未找到文章作者，请先初始化用户:", err)
+	}
+
+	importer := service.NewPostImporter(db.DB, cfg.UploadDir, cfg.UploadURLPath)
+
+	var (
+		result *service.PostImportResult
+		err    error
+	)
+	if strings.EqualFold(filepath.Ext(source), ".zip") {
+		file, openErr := os.Open(source)
+		if openErr != nil {
+			log.Fatal("打开压缩包失败:", openErr)
+		}
+		defer file.Close()
+		info, statErr := file.Stat()
+		if statErr != nil {
+			log.Fatal("读取压缩包失败:", statErr)
+		}
+		result, err = importer.ImportArchive(file, info.Size(), user.ID)
+	} else {
+		result, err = importer.ImportDir(source, user.ID)
+	}
+	if err != nil {
+		log.Fatal("导入失败:", err)
+	}
+
+	for _, post := range result.Imported {
+		status := "草稿"
+		if post.Published {
+			status = "已发布"
+		}
+		if post.ScheduledAt != nil {
+			status += "，定时发布于 " + post.ScheduledAt.Local().Format("2006-01-02 15:04")
+		}
+		switch post.Action {
+		case service.ImportActionUpdated:
+			status += "，已更新"
+		case service.ImportActionUnchanged:
+			status += "，无变化"
+		}
+		fmt.Printf("✓ [%s] %s (#%d, %d 张图片) ← %s\n", status, post.Title, post.PostID, post.Images, post.Source)
+		for _, warning := range post.Warnings {
+			fmt.Printf("    ! %s\n", warning)
+		}
+	}
+	for _, failure := range result.Failed {
+		fmt.Printf("✗ %s: %s\n", failure.Source, failure.Error)
+	}
+	fmt.Printf("导入完成：成功 %d 篇，失败 %d 篇\n", len(result.Imported), len(result.Failed))
+}
@@ -103,6 +103,9 @@ func newE2ESuite(t *testing.T) *e2eSuite {
 		&db.User{},
 		&db.Post{},
 		&db.PostPublication{},
+		&db.PostSlugRedirect{},
+		&db.Series{},
+		&db.SeriesPost{},
 		&db.PostDraftVersion{},
 		&db.Tag{},
 		&db.Page{},
@@ -112,6 +115,8 @@ func newE2ESuite(t *testing.T) *e2eSuite {
 		&db.SiteHourlySnapshot{},
 		&db.SiteHourlyVisitor{},
 		&db.SystemSetting{},
+		&db.PostLink{},
+		&db.RelatedPost{},
 	); err != nil {
 		t.Fatalf("failed to migrate schema: %v", err)
 	}
@@ -252,11 +257,19 @@ func (s *e2eSuite) testPublicEndpoints(t *testing.T) {
 	}
 
 	checkHTML("home", "/", "已发布文章", http.StatusOK)
-	checkHTML("post detail", "/posts/"+idStr(publishedID), "已发布文章", http.StatusOK)
+	var published db.Post
+	if err := db.DB.First(&published, publishedID).Error; err != nil {
+		t.Fatalf("failed to reload published post: %v", err)
+	}
+	if published.Slug == "" {
+		t.Fatalf("expected publish to assign a slug")
+	}
+	checkHTML("post detail legacy id", "/posts/"+idStr(publishedID), "", http.StatusMovedPermanently)
+	checkHTML("post detail", published.PublicPath(), "已发布文章", http.StatusOK)
 	checkHTML("tags page", "/tags", "标签", http.StatusOK)
 	checkHTML("about page", "/about", "About Me", http.StatusOK)
 	checkHTML("load more", "/posts/more?page=2", "", http.StatusOK)
-	checkHTML("search suggestions", "/search/suggestions?search=已发布", "posts/"+idStr(publishedID), http.StatusOK)
+	checkHTML("search suggestions", "/search/suggestions?search=已发布", published.PublicPath(), http.StatusOK)
 	checkHTML("robots", "/robots.txt", "User-agent", http.StatusOK)
 	checkHTML("sitemap", "/sitemap.xml", "<urlset", http.StatusOK)
 	checkHTML("rss", "/rss.xml", "<rss", http.StatusOK)
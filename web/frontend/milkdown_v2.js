@@ -270,6 +270,7 @@ const DEFAULT_CHANGE_POLL_INTERVAL = 3000;
 const DEFAULT_AUTOSAVE_INTERVAL = 60000;
 const POST_VISIBILITY_PUBLIC = "public";
 const POST_VISIBILITY_UNLISTED = "unlisted";
+const POST_VISIBILITY_PROTECTED = "protected";
 
 function generateDraftSessionId() {
   if (typeof crypto !== "undefined" && typeof crypto.randomUUID === "function") {
@@ -310,6 +311,9 @@ function normalizePostVisibility(value) {
   if (normalized === POST_VISIBILITY_UNLISTED) {
     return POST_VISIBILITY_UNLISTED;
   }
+  if (normalized === POST_VISIBILITY_PROTECTED) {
+    return POST_VISIBILITY_PROTECTED;
+  }
   return POST_VISIBILITY_PUBLIC;
 }
 
@@ -2532,6 +2536,7 @@ class PostDraftController {
     this.changeMonitorId = null;
     this.visibilityHandler = null;
     this.unloadHandler = null;
+    this.pendingPassword = "";
     this.boundKeyHandler = this.handleKeydown.bind(this);
     this.pendingAutoSaveFlush = false;
     this.contentMetrics = { words: 0, characters: 0, paragraphs: 0 };
@@ -2618,6 +2623,9 @@ class PostDraftController {
     if (source.UserID === undefined && source.user_id !== undefined) {
       source.UserID = source.user_id;
     }
+    if (source.Slug === undefined && typeof source.slug === "string") {
+      source.Slug = source.slug;
+    }
 
     const normalizedTags = this.normalizeTags(source.Tags);
     const primaryTags = normalizedTags.map(
@@ -2634,6 +2642,7 @@ class PostDraftController {
 
     return {
       Title: "",
+      Slug: "",
       Summary: "",
       Content: "",
       Status: "draft",
@@ -3038,6 +3047,18 @@ class PostDraftController {
     return normalized;
   }
 
+  setSlug(value) {
+    const normalized = coerceString(value, "").trim();
+    if (coerceString(this.postData.Slug, "") === normalized) {
+      return normalized;
+    }
+    this.postData.Slug = normalized;
+    this.postData.slug = normalized;
+    this.markDirty();
+    this.notifyPostChange("slug");
+    return normalized;
+  }
+
   setVisibility(value) {
     const normalized = normalizePostVisibility(value);
     const previous = normalizePostVisibility(
@@ -3053,6 +3074,16 @@ class PostDraftController {
     return normalized;
   }
 
+  setPassword(value) {
+    const password = coerceString(value, "");
+    if (password === this.pendingPassword) {
+      return;
+    }
+    // 密码只在下次保存时随请求提交一次，服务器仅保存哈希
+    this.pendingPassword = password;
+    this.markDirty();
+  }
+
   setCover(info = {}) {
     const nextUrl = coerceString(
       pickProperty(info, ["url", "CoverURL", "cover_url"], ""),
@@ -3196,6 +3227,10 @@ class PostDraftController {
       pickProperty(post, ["Summary", "summary"], ""),
       "",
     );
+    const slug = coerceString(
+      pickProperty(post, ["Slug", "slug"], ""),
+      "",
+    ).trim();
     const visibility = normalizePostVisibility(
       pickProperty(post, ["Visibility", "visibility"], POST_VISIBILITY_PUBLIC),
     );
@@ -3222,8 +3257,9 @@ class PostDraftController {
       .map((tag) => this.getTagId(tag))
       .filter((id) => id !== null);
 
-    return {
+    const payload = {
       title,
+      slug,
       summary,
       visibility,
       content,
@@ -3233,6 +3269,10 @@ class PostDraftController {
       cover_height: coverHeight,
       draft_session_id: this.draftSessionId,
     };
+    if (this.pendingPassword) {
+      payload.password = this.pendingPassword;
+    }
+    return payload;
   }
 
   updatePostData(nextPost) {
@@ -3468,6 +3508,9 @@ class PostDraftController {
       if (data.post) {
         this.updatePostData(data.post);
       }
+      if (payload.password && payload.password === this.pendingPassword) {
+        this.pendingPassword = "";
+      }
 
       const shouldNotify = !silent || notifyOnSilent;
       if (shouldNotify && Array.isArray(data.notices)) {
@@ -56,6 +56,53 @@ function initializeVideoEmbedLoadingState(root = document) {
         return containers.length;
 }
 
+const LITE_EMBED_ALLOW = 'accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share';
+
+// 读者已经点击播放，替换成 iframe 后直接开始播放
+function withAutoplay(src) {
+        try {
+                const url = new URL(src);
+                url.searchParams.set('autoplay', '1');
+                return url.toString();
+        } catch {
+                return src;
+        }
+}
+
+// 轻量嵌入：点击缩略图后才创建 iframe，此前页面不会向第三方发出任何请求
+function activateLiteEmbed(container) {
+        const src = container?.dataset?.embedSrc;
+        if (!src) {
+                return;
+        }
+        const iframe = document.createElement('iframe');
+        iframe.src = withAutoplay(src);
+        iframe.title = container.dataset.embedTitle || '';
+        iframe.allow = LITE_EMBED_ALLOW;
+        iframe.allowFullscreen = true;
+        iframe.setAttribute('frameborder', '0');
+        iframe.referrerPolicy = 'strict-origin-when-cross-origin';
+        if (container.dataset.embedSandbox) {
+                iframe.setAttribute('sandbox', container.dataset.embedSandbox);
+        }
+        delete container.dataset.embedSrc;
+        delete container.dataset.videoEmbedLoadingBound;
+        container.classList.remove('is-lite');
+        container.replaceChildren(iframe);
+        bindVideoEmbedLoading(container);
+}
+
+function setupLiteEmbeds() {
+        document.addEventListener('click', event => {
+                const trigger = event.target instanceof Element ? event.target.closest('[data-embed-play]') : null;
+                if (!trigger) {
+                        return;
+                }
+                event.preventDefault();
+                activateLiteEmbed(trigger.closest('[data-video-embed]'));
+        });
+}
+
 function ensurePostToc() {
         if (!document.querySelector('[data-toc-card]')) {
                 return;
@@ -70,10 +117,145 @@ function ensurePostToc() {
         }
 }
 
+const MERMAID_MODULE_URL = 'https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.esm.min.mjs';
+const PLANTUML_SERVER_URL = 'https://www.plantuml.com/plantuml/svg/';
+
+function encodePlantUmlHex(source) {
+        return Array.from(new TextEncoder().encode(source), byte => byte.toString(16).padStart(2, '0')).join('');
+}
+
+// 服务端将图表源码放在 figure[data-diagram] 中，Mermaid 按需加载渲染，PlantUML 交给公共渲染服务
+function renderDiagrams(root = document) {
+        if (!root || typeof root.querySelectorAll !== 'function') {
+                return;
+        }
+        const figures = Array.from(root.querySelectorAll('figure[data-diagram]')).filter(
+                figure => figure.dataset.diagramRendered !== 'true',
+        );
+        figures
+                .filter(figure => figure.dataset.diagram === 'plantuml')
+                .forEach(figure => {
+                        const source = figure.querySelector('.diagram-source');
+                        if (!source) {
+                                return;
+                        }
+                        const image = document.createElement('img');
+                        image.src = `${PLANTUML_SERVER_URL}~h${encodePlantUmlHex(source.textContent || '')}`;
+                        image.alt = 'PlantUML 图表';
+                        image.loading = 'lazy';
+                        source.replaceWith(image);
+                        figure.dataset.diagramRendered = 'true';
+                });
+
+        const mermaidNodes = figures
+                .filter(figure => figure.dataset.diagram === 'mermaid')
+                .map(figure => figure.querySelector('.diagram-source'))
+                .filter(Boolean);
+        if (!mermaidNodes.length) {
+                return;
+        }
+        import(/* @vite-ignore */ MERMAID_MODULE_URL)
+                .then(module => {
+                        const mermaid = module.default || module;
+                        const prefersDark =
+                                typeof window.matchMedia === 'function' &&
+                                window.matchMedia('(prefers-color-scheme: dark)').matches;
+                        mermaid.initialize({
+                                startOnLoad: false,
+                                securityLevel: 'strict',
+                                theme: prefersDark ? 'dark' : 'default',
+                        });
+                        return mermaid.run({ nodes: mermaidNodes });
+                })
+                .then(() => {
+                        mermaidNodes.forEach(node => {
+                                node.closest('figure').dataset.diagramRendered = 'true';
+                        });
+                })
+                .catch(error => {
+                        console.error('[mermaid] 图表渲染失败', error);
+                });
+}
+
+// 服务端输出的公式为 \(...\) 与 \[...\] 文本，按需加载 KaTeX 渲染
+function renderServerMath(root = document) {
+        if (!root || typeof root.querySelectorAll !== 'function') {
+                return;
+        }
+        const nodes = Array.from(root.querySelectorAll('.math-inline, .math-display')).filter(
+                node => node.dataset.mathRendered !== 'true',
+        );
+        if (!nodes.length) {
+                return;
+        }
+        Promise.all([import('katex'), import('katex/dist/katex.min.css')])
+                .then(([module]) => {
+                        const katex = module.default || module;
+                        nodes.forEach(node => {
+                                const displayMode = node.classList.contains('math-display');
+                                const tex = (node.textContent || '')
+                                        .trim()
+                                        .replace(/^\\[([]/, '')
+                                        .replace(/\\[)\]]$/, '');
+                                try {
+                                        katex.render(tex, node, { displayMode, throwOnError: false });
+                                        node.dataset.mathRendered = 'true';
+                                } catch (error) {
+                                        console.warn('[katex] 公式渲染失败', error);
+                                }
+                        });
+                })
+                .catch(error => {
+                        console.error('[katex] 加载失败', error);
+                });
+}
+
+// 阅读器在客户端重新渲染标题，沿用服务端生成的锚点，保证深链接稳定
+function adoptServerHeadingIds(fallback, mount) {
+        if (!fallback || !mount) {
+                return;
+        }
+        const selector = 'h1, h2, h3, h4, h5, h6';
+        const source = Array.from(fallback.querySelectorAll(selector)).filter(heading => heading.id);
+        const targets = Array.from(mount.querySelectorAll(selector));
+        let cursor = 0;
+        targets.forEach(heading => {
+                const text = (heading.textContent || '').trim();
+                const index = source.findIndex(
+                        (candidate, i) => i >= cursor && (candidate.textContent || '').trim() === text,
+                );
+                if (index === -1) {
+                        return;
+                }
+                const id = source[index].id;
+                source[index].removeAttribute('id');
+                heading.id = id;
+                cursor = index + 1;
+        });
+        const hash = decodeURIComponent(window.location.hash || '').replace(/^#/, '');
+        if (hash) {
+                const target = document.getElementById(hash);
+                if (target && mount.contains(target)) {
+                        target.scrollIntoView({ block: 'start' });
+                }
+        }
+}
+
+const SERVER_ONLY_EMBED_SELECTOR = [
+        '[data-post-fallback] [data-video-platform]:not([data-video-platform="youtube"]):not([data-video-platform="bilibili"])',
+        '[data-post-fallback] [data-embed-src]',
+        '[data-post-fallback] [data-link-card]',
+        '[data-post-fallback] .wiki-link',
+].join(', ');
+
 function initMilkdownViewer() {
         const markdownNode = document.getElementById('post-markdown-data');
         const mount = document.querySelector('[data-milkdown-viewer]');
-        if (!markdownNode || !mount) {
+        // 阅读器不支持图表，含图表的文章直接使用服务端渲染结果
+        const hasDiagrams = Boolean(document.querySelector('[data-post-fallback] figure[data-diagram]'));
+        // 阅读器只识别 YouTube 与 B 站且不支持轻量嵌入，其余情况同样沿用服务端渲染结果
+        const hasServerEmbeds = Boolean(document.querySelector(SERVER_ONLY_EMBED_SELECTOR));
+        if (!markdownNode || !mount || hasDiagrams || hasServerEmbeds) {
                 initializeVideoEmbedLoadingState(document);
                 ensurePostToc();
                 return;
@@ -113,6 +295,7 @@ function initMilkdownViewer() {
                         const fallback = document.querySelector('[data-post-fallback]');
                         if (fallback) {
                                 fallback.classList.add('hidden');
+                                adoptServerHeadingIds(fallback, mount);
                         }
                         initializeVideoEmbedLoadingState(mount);
                         ensurePostToc();
@@ -190,10 +373,13 @@ function setupSearchSuggestions() {
 function bootPublic() {
         // 构建只读渲染与目录浮框
         initializeVideoEmbedLoadingState(document);
+        renderServerMath(document);
+        renderDiagrams(document);
         initMilkdownViewer();
         ensurePostToc();
         ensureMasonryGrid();
         setupSearchSuggestions();
+        setupLiteEmbeds();
 }
 
 if (document.readyState === 'loading') {
@@ -205,6 +391,8 @@ if (document.readyState === 'loading') {
 document.addEventListener('htmx:afterSwap', event => {
         ensureMasonryGrid();
         initializeVideoEmbedLoadingState(event?.target || document);
+        renderServerMath(event?.target || document);
+        renderDiagrams(event?.target || document);
 });
 
 window.addEventListener('pagehide', () => {
@@ -188,11 +188,14 @@ export function createTocController(rawOptions = {}) {
         };
         const headingFilter = options.headingFilter || DEFAULTS.headingFilter;
         const slugify = text => {
+                // 与服务端 slugifyHeading 保持一致：保留中文等文字，空白折叠为 -
                 const base = text
                         .toLowerCase()
                         .trim()
-                        .replace(/[\s]+/g, '-')
-                        .replace(/[^a-z0-9\-]/g, '');
+                        .replace(/[\s-]+/g, '-')
+                        .replace(/[^\p{L}\p{N}_-]/gu, '')
+                        .replace(/-{2,}/g, '-')
+                        .replace(/^-+|-+$/g, '');
                 const safe = base || 'section';
                 const count = slugCounts.get(safe) || 0;
                 slugCounts.set(safe, count + 1);